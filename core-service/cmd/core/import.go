@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/shameoff/more-than-trip/core/internal/lib/catalog"
+	"github.com/shameoff/more-than-trip/core/internal/lib/logger/sl"
+	coreService "github.com/shameoff/more-than-trip/core/internal/services/core"
+)
+
+// runImport выполняет команду импорта справочника из файла:
+//
+//	main -config dev.yml import -catalog regions -file regions.geojson [-format geojson] [-apply]
+//
+// Без -apply печатает отчет о разнице с БД, ничего не изменяя
+func runImport(ctx context.Context, log *slog.Logger, service *coreService.CoreService, args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	catalogName := fs.String("catalog", "", "catalog to import: regions, places or tags")
+	filePath := fs.String("file", "", "path to the csv or geojson file")
+	format := fs.String("format", "", "file format: csv or geojson (detected by extension by default)")
+	apply := fs.Bool("apply", false, "apply changes instead of dry-run")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *catalogName == "" || *filePath == "" {
+		fs.Usage()
+		return 2
+	}
+	if *format == "" {
+		*format = catalog.FormatFromFileName(*filePath)
+	}
+
+	file, err := os.Open(*filePath)
+	if err != nil {
+		log.Error("failed to open catalog file", sl.Err(err))
+		return 1
+	}
+	defer file.Close()
+
+	report, err := service.ImportCatalog(ctx, *catalogName, *format, file, *apply)
+	if err != nil {
+		log.Error("failed to import catalog", sl.Err(err))
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Error("failed to print import report", sl.Err(err))
+		return 1
+	}
+	if report.Failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d rows failed, nothing was applied\n", report.Failed)
+		return 1
+	}
+	return 0
+}
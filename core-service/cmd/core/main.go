@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"flag"
+	"log/slog"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
 	"github.com/shameoff/more-than-trip/core/internal/app"
@@ -21,6 +24,47 @@ func main() {
 	// Инициализируем приложение (app)
 	application := app.New(log, cfg)
 	ctx := context.Background()
+
+	// Команды, выполняемые вместо запуска сервера: main -config dev.yml <команда> [флаги]
+	switch flag.Arg(0) {
+	case "migrate":
+		os.Exit(runMigrate(ctx, log, application.Storage, cfg.MigrationsPath, flag.Args()[1:]))
+	case "import":
+		os.Exit(runImport(ctx, log, application.CoreService, flag.Args()[1:]))
+	}
+
+	// Применяем миграции перед запуском сервера
+	if cfg.AutoMigrate {
+		applied, err := application.Storage.Migrate(ctx, cfg.MigrationsPath)
+		if err != nil {
+			log.Error("failed to apply migrations", sl.Err(err))
+			os.Exit(1)
+		}
+		for _, m := range applied {
+			log.Info("migration applied", slog.Int64("version", m.Version), slog.String("name", m.Name))
+		}
+	}
+
+	// Очередь фоновых задач запускается только после миграций: задачи работают с новой схемой
+	application.Jobs.Start()
+
+	// Фоновые процессы: запись просмотров пачками, пересчет рейтинга популярного,
+	// получение событий для потока обновлений и доставка вебхуков
+	workersCtx, stopWorkers := context.WithCancel(ctx)
+	var workers sync.WaitGroup
+	for _, run := range []func(context.Context){
+		application.CoreService.RunViewWriter,
+		application.CoreService.RunTrendingWorker,
+		application.CoreService.RunStreamListener,
+		application.CoreService.RunWebhookDispatcher,
+	} {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workersCtx)
+		}()
+	}
+
 	go func() {
 		application.HTTPServer.MustRun()
 	}()
@@ -33,6 +77,14 @@ func main() {
 
 	// initiate graceful shutdown
 	application.HTTPServer.Stop(ctx) // Assuming GRPCServer has Stop() method for graceful shutdown
+	// Фоновые задачи дорабатывают начатое; не успевшие за DrainTimeout будут повторены после запуска
+	drainCtx, cancelDrain := context.WithTimeout(ctx, cfg.Jobs.DrainTimeout)
+	if err := application.Jobs.Stop(drainCtx); err != nil {
+		log.Warn("failed to drain job workers", sl.Err(err))
+	}
+	cancelDrain()
+	stopWorkers()
+	workers.Wait()
 	log.Info("Gracefully stopped")
 	os.Exit(0)
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/shameoff/more-than-trip/core/internal/lib/logger/sl"
+	"github.com/shameoff/more-than-trip/core/internal/storage/postgres"
+)
+
+// runMigrate выполняет команду управления миграциями:
+//
+//	main -config dev.yml migrate [up]          применить все новые миграции
+//	main -config dev.yml migrate down [-steps N] откатить N последних миграций (по умолчанию 1)
+//	main -config dev.yml migrate status        показать примененные и ожидающие миграции
+//	main -config dev.yml migrate check         найти висячие ссылки, мешающие внешним ключам
+func runMigrate(ctx context.Context, log *slog.Logger, storage *postgres.Storage, dir string, args []string) int {
+	action := "up"
+	if len(args) > 0 {
+		action, args = args[0], args[1:]
+	}
+
+	switch action {
+	case "up":
+		applied, err := storage.Migrate(ctx, dir)
+		for _, m := range applied {
+			log.Info("migration applied", slog.Int64("version", m.Version), slog.String("name", m.Name))
+		}
+		if err != nil {
+			log.Error("failed to apply migrations", sl.Err(err))
+			return 1
+		}
+		log.Info("database is up to date", slog.Int("applied", len(applied)))
+
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "number of migrations to revert")
+		if err := fs.Parse(args); err != nil {
+			return 2
+		}
+		reverted, err := storage.MigrateDown(ctx, dir, *steps)
+		for _, m := range reverted {
+			log.Info("migration reverted", slog.Int64("version", m.Version), slog.String("name", m.Name))
+		}
+		if err != nil {
+			log.Error("failed to revert migrations", sl.Err(err))
+			return 1
+		}
+
+	case "status":
+		statuses, err := storage.MigrationStatuses(ctx, dir)
+		if err != nil {
+			log.Error("failed to get migration status", sl.Err(err))
+			return 1
+		}
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(os.Stdout, "%04d_%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+
+	case "check":
+		dangling, err := storage.CheckReferences(ctx)
+		if err != nil {
+			log.Error("failed to check references", sl.Err(err))
+			return 1
+		}
+		for _, d := range dangling {
+			fmt.Fprintf(os.Stdout, "%s.%s -> %s: %d rows, e.g. %s\n",
+				d.Table, d.Column, d.References, d.Count, strings.Join(d.Sample, ", "))
+		}
+		if len(dangling) > 0 {
+			return 1
+		}
+		log.Info("no dangling references found")
+
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate action %q, expected up, down, status or check\n", action)
+		return 2
+	}
+	return 0
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"crypto/rand"
 	"fmt"
 	"log/slog"
 	"os"
@@ -11,6 +12,7 @@ import (
 	httpapp "github.com/shameoff/more-than-trip/core/internal/app/http"
 	"github.com/shameoff/more-than-trip/core/internal/config"
 	controllers "github.com/shameoff/more-than-trip/core/internal/http-server/core"
+	"github.com/shameoff/more-than-trip/core/internal/jobs"
 	"github.com/shameoff/more-than-trip/core/internal/lib/converter"
 	"github.com/shameoff/more-than-trip/core/internal/lib/logger/sl"
 	coreService "github.com/shameoff/more-than-trip/core/internal/services/core"
@@ -21,7 +23,10 @@ import (
 )
 
 type App struct {
-	HTTPServer *httpapp.HttpApp
+	HTTPServer  *httpapp.HttpApp
+	CoreService *coreService.CoreService
+	Storage     *postgres.Storage
+	Jobs        *jobs.Runner
 }
 
 func New(log *slog.Logger,
@@ -58,13 +63,80 @@ func New(log *slog.Logger,
 	s3Client := s3.New(sess)
 	s3PhotoService := s3Storage.NewS3Storage(config.S3.PhotosBucket, s3Client)
 
+	textPolicy := coreService.TextPolicy{
+		Profanity:      config.TextPolicy.Profanity,
+		StopWords:      config.TextPolicy.StopWords,
+		Links:          config.TextPolicy.Links,
+		Phones:         config.TextPolicy.Phones,
+		ExtraProfanity: config.TextPolicy.ExtraProfanity,
+		StopWordList:   config.TextPolicy.StopWordList,
+		MaxLength:      config.TextPolicy.MaxLength,
+	}
+	if err := textPolicy.Validate(); err != nil {
+		log.Error("invalid text policy", sl.Err(err))
+		panic(err)
+	}
+
+	streamSecret := []byte(config.Stream.TokenSecret)
+	if len(streamSecret) == 0 {
+		log.Warn("STREAM_TOKEN_SECRET is not set, stream tokens will only work on this instance")
+		streamSecret = make([]byte, 32)
+		if _, err := rand.Read(streamSecret); err != nil {
+			panic(err)
+		}
+	}
+
 	// Init core service (Business Logic Layer)
-	coreService := coreService.NewCoreService("TOBECONTINUED", log, storage, s3PhotoService)
+	coreService := coreService.NewCoreService("TOBECONTINUED", log, storage, s3PhotoService, coreService.Options{
+		Views: coreService.ViewTracking{
+			DedupWindow:     config.Views.DedupWindow,
+			FingerprintSalt: config.Views.FingerprintSalt,
+			BatchSize:       config.Views.BatchSize,
+			FlushInterval:   config.Views.FlushInterval,
+			QueueSize:       config.Views.QueueSize,
+		},
+		Trending: coreService.TrendingRanking{
+			RefreshInterval: config.Trending.RefreshInterval,
+			LikeWeight:      config.Trending.LikeWeight,
+			ViewWeight:      config.Trending.ViewWeight,
+		},
+		Webhooks: coreService.WebhookDispatch{
+			PollInterval: config.Webhooks.PollInterval,
+			BatchSize:    config.Webhooks.BatchSize,
+			Timeout:      config.Webhooks.Timeout,
+			MaxAttempts:  config.Webhooks.MaxAttempts,
+			RetryBase:    config.Webhooks.RetryBase,
+			RetryMax:     config.Webhooks.RetryMax,
+		},
+		Reports: coreService.ReportPolicy{
+			AutoHideThreshold: config.Reports.AutoHideThreshold,
+		},
+		Text: textPolicy,
+		Share: coreService.ShareLinks{
+			BaseUrl: config.Share.BaseUrl,
+		},
+		Stream: coreService.StreamAuth{
+			TokenSecret:    streamSecret,
+			TokenTTL:       config.Stream.TokenTTL,
+			AllowedOrigins: config.Stream.AllowedOrigins,
+		},
+	})
+	// Воркеры фоновых задач; запускаются вместе с сервером после миграций
+	jobRunner := jobs.NewRunner(storage, log, jobs.Config{
+		Workers:      config.Jobs.Workers,
+		PollInterval: config.Jobs.PollInterval,
+		Timeout:      config.Jobs.Timeout,
+		RetryBase:    config.Jobs.RetryBase,
+		RetryMax:     config.Jobs.RetryMax,
+	})
+	coreService.RegisterJobs(jobRunner)
+
 	coreHandler := controllers.NewCoreHandler(coreService, log)
 
 	// Создание HTTP обработчика
 	httpServer := httpapp.New(log, config.HTTP.Port)
 	controllers.RegisterRoutes(httpServer.Router, coreHandler)
+	httpServer.RegisterOnShutdown(coreService.CloseStreams)
 
 	// // Init swagger
 	httpServer.Router.Get("/swagger/*", httpSwagger.Handler(
@@ -77,6 +149,9 @@ func New(log *slog.Logger,
 	fmt.Println("AWS_SECRET_ACCESS_KEY:", secretKey)
 
 	return &App{
-		HTTPServer: httpServer,
+		HTTPServer:  httpServer,
+		CoreService: coreService,
+		Storage:     storage,
+		Jobs:        jobRunner,
 	}
 }
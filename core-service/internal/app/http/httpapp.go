@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -26,7 +27,8 @@ func New(log *slog.Logger, port int) *HttpApp {
 	router.Use(middleware.RequestID)
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
-	router.Use(middleware.Timeout(60 * time.Second))
+	// Поток обновлений держит соединение открытым, ограничение времени к нему не применяется
+	router.Use(skipPrefix("/api/stream", middleware.Timeout(60*time.Second)))
 
 	// Пример обработчика для health-check
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -44,6 +46,12 @@ func New(log *slog.Logger, port int) *HttpApp {
 	}
 }
 
+// RegisterOnShutdown регистрирует функцию, которая вызывается в начале остановки сервера.
+// Нужна для закрытия долгих соединений, которых Shutdown не дожидается сам
+func (a *HttpApp) RegisterOnShutdown(f func()) {
+	a.server.RegisterOnShutdown(f)
+}
+
 // MustRun запускает HTTP-сервер и паникует в случае ошибки
 func (a *HttpApp) MustRun() {
 	if err := a.Run(); err != nil {
@@ -66,3 +74,17 @@ func (a *HttpApp) Stop(ctx context.Context) error {
 	a.log.Info("stopping HTTP server", slog.Int("port", a.port))
 	return a.server.Shutdown(ctx)
 }
+
+// skipPrefix применяет middleware ко всем запросам, кроме тех, путь которых начинается с prefix
+func skipPrefix(prefix string, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
@@ -9,11 +9,83 @@ import (
 )
 
 type Config struct {
-	Env            string         `yaml:"env" env-default:"local"`
-	Database       DatabaseConfig `yaml:"database" env-required:"true"`
-	HTTP           HTTPConfig     `yaml:"http"`
-	S3             S3Config       `yaml:"s3"`
-	MigrationsPath string
+	Env            string           `yaml:"env" env-default:"local"`
+	Database       DatabaseConfig   `yaml:"database" env-required:"true"`
+	HTTP           HTTPConfig       `yaml:"http"`
+	S3             S3Config         `yaml:"s3"`
+	MigrationsPath string           `yaml:"migrations_path" env:"MIGRATIONS_PATH" env-default:"./migrations"`
+	AutoMigrate    bool             `yaml:"auto_migrate" env:"AUTO_MIGRATE" env-default:"true"`
+	Views          ViewsConfig      `yaml:"views"`
+	Trending       TrendingConfig   `yaml:"trending"`
+	Webhooks       WebhooksConfig   `yaml:"webhooks"`
+	Jobs           JobsConfig       `yaml:"jobs"`
+	Reports        ReportsConfig    `yaml:"reports"`
+	TextPolicy     TextPolicyConfig `yaml:"text_policy"`
+	Share          ShareConfig      `yaml:"share"`
+	Stream         StreamConfig     `yaml:"stream"`
+}
+
+type ViewsConfig struct {
+	DedupWindow     time.Duration `yaml:"dedup_window" env-default:"30m"`
+	FingerprintSalt string        `env:"VIEW_FINGERPRINT_SALT"`
+	BatchSize       int           `yaml:"batch_size" env-default:"100"`
+	FlushInterval   time.Duration `yaml:"flush_interval" env-default:"5s"`
+	QueueSize       int           `yaml:"queue_size" env-default:"10000"`
+}
+
+type TrendingConfig struct {
+	RefreshInterval time.Duration `yaml:"refresh_interval" env-default:"5m"`
+	LikeWeight      float64       `yaml:"like_weight" env-default:"3"`
+	ViewWeight      float64       `yaml:"view_weight" env-default:"1"`
+}
+
+type WebhooksConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"2s"`
+	BatchSize    int           `yaml:"batch_size" env-default:"50"`
+	Timeout      time.Duration `yaml:"timeout" env-default:"10s"`
+	MaxAttempts  int           `yaml:"max_attempts" env-default:"8"`
+	RetryBase    time.Duration `yaml:"retry_base" env-default:"10s"`
+	RetryMax     time.Duration `yaml:"retry_max" env-default:"1h"`
+}
+
+type JobsConfig struct {
+	Workers      int           `yaml:"workers" env-default:"4"`
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"1s"`
+	Timeout      time.Duration `yaml:"timeout" env-default:"5m"`
+	RetryBase    time.Duration `yaml:"retry_base" env-default:"5s"`
+	RetryMax     time.Duration `yaml:"retry_max" env-default:"30m"`
+	DrainTimeout time.Duration `yaml:"drain_timeout" env-default:"30s"`
+}
+
+type ReportsConfig struct {
+	AutoHideThreshold int `yaml:"auto_hide_threshold" env-default:"5"`
+}
+
+// TextPolicyConfig - действия при нарушениях в тексте: allow, mask, moderate или reject
+type TextPolicyConfig struct {
+	Profanity      string         `yaml:"profanity" env-default:"mask"`
+	StopWords      string         `yaml:"stop_words" env-default:"moderate"`
+	Links          string         `yaml:"links" env-default:"moderate"`
+	Phones         string         `yaml:"phones" env-default:"moderate"`
+	ExtraProfanity []string       `yaml:"extra_profanity"`
+	StopWordList   []string       `yaml:"stop_word_list"`
+	MaxLength      map[string]int `yaml:"max_length"`
+}
+
+type ShareConfig struct {
+	// Внешний адрес API, от которого строятся ссылки для доступа
+	BaseUrl string `yaml:"base_url" env:"SHARE_BASE_URL" env-default:"http://localhost:50151"`
+}
+
+// StreamConfig - доступ к потоку обновлений из браузера
+type StreamConfig struct {
+	// Ключ подписи токенов потока. Если не задан, генерируется при запуске,
+	// и токены действуют только на этой реплике
+	TokenSecret string        `env:"STREAM_TOKEN_SECRET"`
+	TokenTTL    time.Duration `yaml:"token_ttl" env-default:"1m"`
+	// Адреса сайтов, с которых можно открыть WebSocket, например https://example.com.
+	// Сайт на том же адресе, что и API, разрешен всегда
+	AllowedOrigins []string `yaml:"allowed_origins"`
 }
 
 type S3Config struct {
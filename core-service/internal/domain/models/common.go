@@ -1,18 +1,74 @@
 package models
 
 import (
+	"encoding/json"
+	"time"
+
 	"github.com/google/uuid"
 )
 
 type Photo struct {
-	Coords      string
-	Description string
-	Id          uuid.UUID
-	ImgUrl      string
-	Place       string
-	RegionId    uuid.UUID
-	TripId      uuid.UUID
-	UserId      uuid.UUID
+	Coords       string
+	Description  string
+	Id           uuid.UUID
+	ImgUrl       string
+	ThumbnailUrl string
+	Place        string
+	PlaceId      uuid.NullUUID
+	RegionId     uuid.UUID
+	TripId       uuid.UUID
+	UserId       uuid.UUID
+	Likes        int
+	LikedByMe    bool
+	Views        int
+	Comments     int
+	CreatedAt    time.Time
+	// Статус модерации; всем видны только одобренные фото
+	ModerationStatus string
+	// Причина решения модератора, видна автору фото
+	ModerationReason string
+	// Кому видно фото: public, followers, trip-members или private
+	Visibility string
+	// Время съемки из метаданных загрузки. EXIF файла сервер не читает: время должен передать
+	// клиент. Если оно не указано, в хронологии поездки используется время загрузки
+	TakenAt *time.Time
+}
+
+// Уровни видимости фото
+const (
+	VisibilityPublic = "public"
+	// Автор и его подписчики
+	VisibilityFollowers = "followers"
+	// Автор и участники поездки, к которой относится фото
+	VisibilityTripMembers = "trip-members"
+	// Только автор
+	VisibilityPrivate = "private"
+)
+
+var Visibilities = []string{VisibilityPublic, VisibilityFollowers, VisibilityTripMembers, VisibilityPrivate}
+
+// Статусы модерации фото
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+	ModerationHidden   = "hidden"
+)
+
+// ModerationDecision - решение модератора по фото
+type ModerationDecision struct {
+	Status      string
+	Reason      string
+	ModeratorId uuid.UUID
+}
+
+// TrustedUser - пользователь, фото которого в регионе одобряются без очереди модерации
+type TrustedUser struct {
+	RegionId  uuid.UUID
+	UserId    uuid.UUID
+	UserName  string
+	GrantedBy uuid.NullUUID
+	CreatedAt time.Time
 }
 
 type Region struct {
@@ -20,6 +76,35 @@ type Region struct {
 	Name      string
 	Country   string
 	ObjectKey string
+	ImgUrl    string
+	Tag       string
+	Kind      string
+	ParentId  uuid.NullUUID
+}
+
+// Уровни иерархии регионов: страна → федеральный округ → регион
+const (
+	RegionKindCountry         = "country"
+	RegionKindFederalDistrict = "federal_district"
+	RegionKindRegion          = "region"
+)
+
+// RegionKindDepth задает глубину уровня в иерархии, дочерний регион всегда глубже родителя
+var RegionKindDepth = map[string]int{
+	RegionKindCountry:         0,
+	RegionKindFederalDistrict: 1,
+	RegionKindRegion:          2,
+}
+
+// RegionBoundary - граница региона в GeoJSON вместе с ограничивающим прямоугольником
+type RegionBoundary struct {
+	RegionId uuid.UUID
+	Kind     string
+	GeoJSON  []byte
+	MinLat   float64
+	MinLon   float64
+	MaxLat   float64
+	MaxLon   float64
 }
 
 type Trip struct {
@@ -29,11 +114,30 @@ type Trip struct {
 	UserId      uuid.UUID
 	RegionId    uuid.UUID
 	Place       string
+	PlaceId     uuid.NullUUID
+	Comments    int
+	CreatedAt   time.Time
+	// Поездка скрыта по жалобам или модератором
+	Hidden bool
+	// Даты начала и окончания в формате YYYY-MM-DD; пустые, если не заданы
+	StartDate string
+	EndDate   string
+}
+
+type Place struct {
+	Id          uuid.UUID
+	ObjectKey   string
+	Name        string
+	Description string
+	RegionId    uuid.UUID
+	Latitude    *float64
+	Longitude   *float64
 }
 
 type Tag struct {
-	Id   uuid.UUID
-	Name string
+	Id        uuid.UUID
+	ObjectKey string
+	Name      string
 }
 
 type User struct {
@@ -54,13 +158,584 @@ type UserShort struct {
 }
 
 type Like struct {
-	Id      uuid.UUID
+	PhotoId   uuid.UUID
+	UserId    uuid.UUID
+	UserName  string
+	AvatarUrl string
+	CreatedAt time.Time
+}
+
+// View - просмотр фото. Для анонимного просмотра UserId пуст, а повтор определяется
+// по хешу отпечатка клиента в пределах окна, начинающегося с WindowStart
+type View struct {
+	PhotoId     uuid.UUID
+	UserId      uuid.NullUUID
+	Fingerprint string
+	WindowStart time.Time
+	ViewedAt    time.Time
+}
+
+// TrendingWindow - окно рейтинга популярных фото. Вклад лайка или просмотра
+// уменьшается вдвое каждые HalfLife
+type TrendingWindow struct {
+	Name     string
+	Period   time.Duration
+	HalfLife time.Duration
+}
+
+// TrendingWindows - поддерживаемые окна рейтинга, первое используется по умолчанию
+var TrendingWindows = []TrendingWindow{
+	{Name: "24h", Period: 24 * time.Hour, HalfLife: 6 * time.Hour},
+	{Name: "7d", Period: 7 * 24 * time.Hour, HalfLife: 2 * 24 * time.Hour},
+	{Name: "30d", Period: 30 * 24 * time.Hour, HalfLife: 7 * 24 * time.Hour},
+}
+
+// TrendingPhoto - фото в ленте популярного вместе с его рейтингом
+type TrendingPhoto struct {
+	Photo
+	Score float64
+}
+
+// FeedCursor - позиция в ленте: последний отданный рейтинг и фото
+type FeedCursor struct {
+	Score   float64
 	PhotoId uuid.UUID
+}
+
+// FeedPage - страница ленты. NextCursor пуст, если страница последняя
+type FeedPage struct {
+	Photos     []TrendingPhoto
+	NextCursor string
+}
+
+// Follow - подписка: пользователь-подписчик или пользователь, на которого подписаны
+type Follow struct {
+	UserId     uuid.UUID
+	UserName   string
+	AvatarUrl  string
+	FollowedAt time.Time
+}
+
+// UserRestriction - заблокированный или скрытый пользователь
+type UserRestriction struct {
+	UserId    uuid.UUID
+	UserName  string
+	AvatarUrl string
+	CreatedAt time.Time
+}
+
+// Типы записей домашней ленты
+const (
+	FeedItemPhoto = "photo"
+	FeedItemTrip  = "trip"
+)
+
+// FeedItem - запись домашней ленты: фото или поездка
+type FeedItem struct {
+	Kind      string
+	CreatedAt time.Time
+	Photo     *Photo
+	Trip      *Trip
+}
+
+// TimeCursor - позиция в ленте, упорядоченной по времени создания и id
+type TimeCursor struct {
+	At time.Time
+	Id uuid.UUID
+}
+
+// HomeFeedPage - страница домашней ленты. NextCursor пуст, если страница последняя
+type HomeFeedPage struct {
+	Items      []FeedItem
+	NextCursor string
+}
+
+// Объекты, к которым можно оставлять комментарии
+const (
+	CommentTargetPhoto = "photo"
+	CommentTargetTrip  = "trip"
+)
+
+// CommentTarget - фото или поездка, к которой относится комментарий
+type CommentTarget struct {
+	Kind string
+	Id   uuid.UUID
+}
+
+// Comment - комментарий или ответ на него. У удаленного комментария Body пуст,
+// а Deleted == true, чтобы ответы на него оставались в ветке
+type Comment struct {
+	Id        uuid.UUID
+	Target    CommentTarget
+	ParentId  uuid.NullUUID
+	UserId    uuid.UUID
+	UserName  string
+	Body      string
+	Mentions  []uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+	Deleted   bool
+	// Комментарий скрыт по жалобам или модератором, текст не показывается
+	Hidden  bool
+	Replies []Comment
+}
+
+// CommentRevision - прежняя версия текста комментария
+type CommentRevision struct {
+	CommentId uuid.UUID
+	Body      string
+	EditedAt  time.Time
+}
+
+// Типы уведомлений
+const (
+	NotificationPhotoLike    = "photo_like"
+	NotificationPhotoComment = "photo_comment"
+	NotificationTripComment  = "trip_comment"
+	NotificationCommentReply = "comment_reply"
+	NotificationMention      = "mention"
+	NotificationFollow       = "follow"
+)
+
+// NotificationTypes - все типы уведомлений, которые можно настроить
+var NotificationTypes = []string{
+	NotificationPhotoLike,
+	NotificationPhotoComment,
+	NotificationTripComment,
+	NotificationCommentReply,
+	NotificationMention,
+	NotificationFollow,
+}
+
+// NotificationEvent - событие, из которого создается или дополняется уведомление.
+// SubjectId - фото, поездка, комментарий или пользователь в зависимости от Type
+type NotificationEvent struct {
+	UserId    uuid.UUID
+	Type      string
+	SubjectId uuid.UUID
+	ActorId   uuid.UUID
+}
+
+// Notification - уведомление, в которое сложены однотипные события об одном объекте
+type Notification struct {
+	Id            uuid.UUID
+	Type          string
+	SubjectId     uuid.UUID
+	ActorCount    int
+	LastActorId   uuid.UUID
+	LastActorName string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Read          bool
+}
+
+// NotificationInbox - список уведомлений пользователя и число непрочитанных
+type NotificationInbox struct {
+	Items       []Notification
+	UnreadCount int
+}
+
+type NotificationPreference struct {
+	Type    string
+	Enabled bool
+}
+
+// Типы событий потока обновлений
+const (
+	StreamPhotoCreated = "photo.created"
+	StreamPhotoLikes   = "photo.likes"
+	StreamNotification = "notification"
+)
+
+// StreamEvent - событие потока обновлений. Для событий фото UserId - автор,
+// для уведомлений - получатель
+type StreamEvent struct {
+	Type           string
+	PhotoId        uuid.UUID
+	RegionId       uuid.UUID
+	TripId         uuid.UUID
+	UserId         uuid.UUID
+	Likes          int
+	NotificationId uuid.UUID
+	UnreadCount    int
+}
+
+// StreamFilter - на что подписан клиент потока. Уведомления приходят только
+// пользователю UserId, события фото - по регионам, поездкам, фото и автору
+type StreamFilter struct {
 	UserId  uuid.UUID
+	Regions []uuid.UUID
+	Trips   []uuid.UUID
+	Photos  []uuid.UUID
+}
+
+// StreamToken - короткоживущий токен для подключения к потоку клиентов,
+// которые не могут передать заголовок User-Id
+type StreamToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// Типы доменных событий для партнеров. challenge.completed входит в контракт, но пока
+// не отправляется: вызовы еще не реализованы (хендлеры отвечают 501). Завершение вызова
+// должно отправлять его через emitEvent в той же транзакции, что и смену статуса
+const (
+	EventPhotoCreated       = "photo.created"
+	EventPhotoUpdated       = "photo.updated"
+	EventPhotoDeleted       = "photo.deleted"
+	EventTripCreated        = "trip.created"
+	EventTripUpdated        = "trip.updated"
+	EventTripDeleted        = "trip.deleted"
+	EventCommentCreated     = "comment.created"
+	EventChallengeCompleted = "challenge.completed"
+)
+
+// EventTypes - все типы событий, на которые можно подписать вебхук
+var EventTypes = []string{
+	EventPhotoCreated,
+	EventPhotoUpdated,
+	EventPhotoDeleted,
+	EventTripCreated,
+	EventTripUpdated,
+	EventTripDeleted,
+	EventCommentCreated,
+	EventChallengeCompleted,
+}
+
+// OutboxEvent - доменное событие, ожидающее доставки партнерам. Payload - JSON с данными события
+type OutboxEvent struct {
+	Id        uuid.UUID
+	Type      string
+	Payload   json.RawMessage
+	CreatedAt time.Time
+}
+
+// Webhook - адрес партнера, на который отправляются события. Secret заполняется
+// только в ответе на регистрацию. Пустой EventTypes означает все типы
+type Webhook struct {
+	Id         uuid.UUID
+	Url        string
+	Secret     string
+	EventTypes []string
+	Active     bool
+	CreatedAt  time.Time
+}
+
+// Состояния доставки события
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery - доставка события одному вебхуку. Url и Secret заполняются
+// только для диспетчера
+type WebhookDelivery struct {
+	Id            uuid.UUID
+	WebhookId     uuid.UUID
+	Url           string
+	Secret        string
+	Event         OutboxEvent
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	DeliveredAt   *time.Time
+}
+
+// WebhookAttempt - запись журнала о попытке доставки
+type WebhookAttempt struct {
+	DeliveryId  uuid.UUID
+	AttemptedAt time.Time
+	StatusCode  int
+	Response    string
+	Error       string
+	Duration    time.Duration
+}
+
+// Состояния фоновой задачи. Dead - задача исчерпала попытки или упала с
+// неисправимой ошибкой и ждет ручного разбора
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobDead    = "dead"
+)
+
+// Job - фоновая задача из очереди. Payload - JSON с параметрами, его формат задает Kind
+type Job struct {
+	Id          uuid.UUID
+	Kind        string
+	Payload     json.RawMessage
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LastError   string
+	CreatedAt   time.Time
+	FinishedAt  *time.Time
 }
 
 type PhotoFiltersDTO struct {
 	RegionId uuid.UUID
 	TripId   uuid.UUID
 	TagKey   string
+	// Пользователь, для которого заполняется Photo.LikedByMe
+	ViewerId uuid.UUID
+}
+
+// Типы справочников, поддерживаемые массовым импортом
+const (
+	CatalogRegions = "regions"
+	CatalogPlaces  = "places"
+	CatalogTags    = "tags"
+)
+
+// CatalogImport - содержимое файла импорта справочников.
+// Связи задаются ключами (object_key), а не идентификаторами
+type CatalogImport struct {
+	Regions []ImportRegion
+	Places  []ImportPlace
+	Tags    []Tag
+}
+
+type ImportRegion struct {
+	Region
+	ParentKey string
+	Boundary  *RegionBoundary
+}
+
+type ImportPlace struct {
+	Place
+	RegionKey string
+}
+
+// Действия над строкой справочника в отчете импорта
+const (
+	ImportCreate    = "create"
+	ImportUpdate    = "update"
+	ImportUnchanged = "unchanged"
+	ImportError     = "error"
+)
+
+type ImportItem struct {
+	Catalog   string
+	ObjectKey string
+	Action    string
+	Error     string
+}
+
+// ImportReport - разница между файлом и БД. При DryRun изменения не применяются
+type ImportReport struct {
+	DryRun    bool
+	Created   int
+	Updated   int
+	Unchanged int
+	Failed    int
+	Items     []ImportItem
+}
+
+// Add добавляет строку в отчет и обновляет счетчики
+func (r *ImportReport) Add(item ImportItem) {
+	switch item.Action {
+	case ImportCreate:
+		r.Created++
+	case ImportUpdate:
+		r.Updated++
+	case ImportUnchanged:
+		r.Unchanged++
+	case ImportError:
+		r.Failed++
+	}
+	r.Items = append(r.Items, item)
+}
+
+// DanglingReference - строки таблицы, которые ссылаются на несуществующие записи
+type DanglingReference struct {
+	Table      string
+	Column     string
+	References string
+	Count      int
+	Sample     []string
+}
+
+// Объекты, на которые можно пожаловаться
+const (
+	ReportTargetPhoto   = "photo"
+	ReportTargetComment = "comment"
+	ReportTargetTrip    = "trip"
+	ReportTargetUser    = "user"
+)
+
+// Категории причин жалобы
+const (
+	ReportReasonSpam       = "spam"
+	ReportReasonHarassment = "harassment"
+	ReportReasonHate       = "hate"
+	ReportReasonNudity     = "nudity"
+	ReportReasonViolence   = "violence"
+	ReportReasonCopyright  = "copyright"
+	ReportReasonOther      = "other"
+)
+
+// ReportReasons - все категории причин жалобы
+var ReportReasons = []string{
+	ReportReasonSpam,
+	ReportReasonHarassment,
+	ReportReasonHate,
+	ReportReasonNudity,
+	ReportReasonViolence,
+	ReportReasonCopyright,
+	ReportReasonOther,
+}
+
+// Статусы жалобы
+const (
+	ReportOpen      = "open"
+	ReportResolved  = "resolved"
+	ReportDismissed = "dismissed"
+)
+
+// Действия модератора по жалобам на объект
+const (
+	// Жалобы необоснованны: объект снова виден всем
+	ReportActionDismiss = "dismiss"
+	// Объект нарушает правила и скрывается
+	ReportActionHide = "hide"
+	// Жалобы закрываются без изменения объекта, например после предупреждения автору
+	ReportActionResolve = "resolve"
+)
+
+// Report - жалоба пользователя на объект
+type Report struct {
+	Id         uuid.UUID
+	TargetType string
+	TargetId   uuid.UUID
+	// Пустой у жалоб, поданных системой при проверке текста
+	ReporterId uuid.UUID
+	Reason     string
+	Details    string
+	// Текст объекта на момент жалобы: описание фото, текст комментария и т.п.
+	Content        string
+	Status         string
+	ResolutionNote string
+	ResolvedBy     uuid.NullUUID
+	ResolvedAt     *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// ReportSummary - открытые жалобы на один объект для панели модератора
+type ReportSummary struct {
+	TargetType string
+	TargetId   uuid.UUID
+	Reports    int
+	// Число жалоб по категориям причин
+	Reasons         map[string]int
+	FirstReportedAt time.Time
+	LastReportedAt  time.Time
+}
+
+// ReportResolution - решение модератора по открытым жалобам на объект
+type ReportResolution struct {
+	Action      string
+	Note        string
+	ModeratorId uuid.UUID
+}
+
+// Объекты, к которым можно открыть доступ по ссылке
+const (
+	ShareTargetPhoto = "photo"
+	ShareTargetTrip  = "trip"
+)
+
+// ShareLink - ссылка, дающая доступ на чтение к фото или поездке любому, кто ее знает
+type ShareLink struct {
+	Id         uuid.UUID
+	TargetType string
+	TargetId   uuid.UUID
+	CreatedBy  uuid.UUID
+	// Время, после которого ссылка не действует; nil - бессрочная
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+	// Токен и адрес ссылки; заполняются только при создании
+	Token string
+	Url   string
+}
+
+// SharedContent - содержимое, открытое по ссылке: фото или поездка с ее фото
+type SharedContent struct {
+	TargetType string
+	Photo      *Photo
+	Trip       *Trip
+	Photos     []Photo
+	ExpiresAt  *time.Time
+}
+
+// Album - подборка фото пользователя из разных поездок и от разных авторов
+type Album struct {
+	Id          uuid.UUID
+	UserId      uuid.UUID
+	Title       string
+	Description string
+	// Кому виден альбом: public, followers или private
+	Visibility string
+	// Обложка, выбранная владельцем
+	CoverPhotoId uuid.NullUUID
+	// Миниатюра обложки: выбранное фото или первое фото альбома, если выбранное недоступно
+	CoverUrl  string
+	Photos    int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Уровни видимости, допустимые для альбома
+var AlbumVisibilities = []string{VisibilityPublic, VisibilityFollowers, VisibilityPrivate}
+
+// TripStop - остановка маршрута поездки
+type TripStop struct {
+	Id       uuid.UUID
+	Position int
+	Place    string
+	PlaceId  uuid.NullUUID
+	// Время прибытия и отъезда; у первой и последней остановки одно из них может быть не задано
+	ArrivalAt   *time.Time
+	DepartureAt *time.Time
+	Notes       string
+}
+
+// Itinerary - даты и маршрут поездки
+type Itinerary struct {
+	TripId    uuid.UUID
+	StartDate string
+	EndDate   string
+	Stops     []TripStop
+}
+
+// TimelineDay - фото поездки за один день, разбитые по остановкам
+type TimelineDay struct {
+	// День в формате YYYY-MM-DD
+	Date  string
+	Stops []TimelineStop
+}
+
+// TimelineStop - фото, снятые за день на остановке. Фото, снятые вне остановок, попадают
+// в группу с пустым StopId
+type TimelineStop struct {
+	StopId uuid.NullUUID
+	Place  string
+	Photos []Photo
+}
+
+// Timeline - хронология поездки по дням
+type Timeline struct {
+	Trip  Trip
+	Stops []TripStop
+	Days  []TimelineDay
+}
+
+// CalendarFeed - адрес ленты календаря поездок пользователя. Токен возвращается только при создании
+type CalendarFeed struct {
+	Url       string
+	Token     string
+	CreatedAt time.Time
 }
@@ -0,0 +1,241 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+type albumRequest struct {
+	Title       string
+	Description string
+	// public, followers или private; при изменении пустое значение оставляет текущее
+	Visibility string
+	// Обложка из фото альбома; null сбрасывает ее к первому фото
+	CoverPhotoId uuid.NullUUID
+}
+
+type albumPhotoRequest struct {
+	PhotoId uuid.UUID
+}
+
+type albumOrderRequest struct {
+	// Все фото альбома в новом порядке
+	PhotoIds []uuid.UUID
+}
+
+// CreateAlbum создает альбом пользователя из заголовка User-Id. Тело: {Title, Description, Visibility}
+func (h *CoreHandler) CreateAlbum(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	var req albumRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	album, err := h.service.CreateAlbum(r.Context(), models.Album{
+		UserId:      userId,
+		Title:       req.Title,
+		Description: req.Description,
+		Visibility:  req.Visibility,
+	})
+	if err != nil {
+		h.handleError(w, r, "failed to create album", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(album)
+}
+
+func (h *CoreHandler) GetAlbum(w http.ResponseWriter, r *http.Request) {
+	albumId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid album ID")
+		return
+	}
+
+	album, err := h.service.GetAlbum(r.Context(), albumId, viewerId(r))
+	if err != nil {
+		h.handleError(w, r, "failed to get album", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(album)
+}
+
+// GetAlbums возвращает альбомы пользователя из параметра user, а без него - пользователя из заголовка User-Id
+func (h *CoreHandler) GetAlbums(w http.ResponseWriter, r *http.Request) {
+	ownerId := viewerId(r)
+	if user := r.URL.Query().Get("user"); user != "" {
+		var err error
+		if ownerId, err = uuid.Parse(user); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+			return
+		}
+	}
+	if ownerId == uuid.Nil {
+		writeProblem(w, r, http.StatusBadRequest, "user parameter or User-Id header is required")
+		return
+	}
+
+	albums, err := h.service.GetAlbums(r.Context(), ownerId, viewerId(r))
+	if err != nil {
+		h.handleError(w, r, "failed to get albums", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(albums)
+}
+
+// UpdateAlbum меняет альбом; менять его может только владелец
+func (h *CoreHandler) UpdateAlbum(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	albumId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid album ID")
+		return
+	}
+	var req albumRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	album, err := h.service.UpdateAlbum(r.Context(), albumId, userId, models.Album{
+		Title:        req.Title,
+		Description:  req.Description,
+		Visibility:   req.Visibility,
+		CoverPhotoId: req.CoverPhotoId,
+	})
+	if err != nil {
+		h.handleError(w, r, "failed to update album", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(album)
+}
+
+func (h *CoreHandler) DeleteAlbum(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	albumId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid album ID")
+		return
+	}
+
+	if err := h.service.DeleteAlbum(r.Context(), albumId, userId); err != nil {
+		h.handleError(w, r, "failed to delete album", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAlbumPhotos возвращает фото альбома в порядке альбома
+func (h *CoreHandler) GetAlbumPhotos(w http.ResponseWriter, r *http.Request) {
+	albumId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid album ID")
+		return
+	}
+
+	photos, err := h.service.GetAlbumPhotos(r.Context(), albumId, viewerId(r))
+	if err != nil {
+		h.handleError(w, r, "failed to get album photos", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(photos)
+}
+
+// AddAlbumPhoto добавляет фото в конец альбома. Тело: {PhotoId}
+func (h *CoreHandler) AddAlbumPhoto(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	albumId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid album ID")
+		return
+	}
+	var req albumPhotoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PhotoId == uuid.Nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.service.AddAlbumPhoto(r.Context(), albumId, userId, req.PhotoId); err != nil {
+		h.handleError(w, r, "failed to add photo to album", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CoreHandler) RemoveAlbumPhoto(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	albumId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid album ID")
+		return
+	}
+	photoId, err := uuid.Parse(chi.URLParam(r, "photoId"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid photo ID")
+		return
+	}
+
+	if err := h.service.RemoveAlbumPhoto(r.Context(), albumId, userId, photoId); err != nil {
+		h.handleError(w, r, "failed to remove photo from album", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReorderAlbumPhotos задает порядок фото альбома одним запросом. Тело: {PhotoIds}
+func (h *CoreHandler) ReorderAlbumPhotos(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	albumId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid album ID")
+		return
+	}
+	var req albumOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.service.ReorderAlbumPhotos(r.Context(), albumId, userId, req.PhotoIds); err != nil {
+		h.handleError(w, r, "failed to reorder album photos", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Блокировки и скрытие действуют от имени пользователя из заголовка User-Id
+// и применяются к пользователю из URL
+
+func (h *CoreHandler) BlockUser(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	blockedId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.service.BlockUser(r.Context(), userId, blockedId); err != nil {
+		h.handleError(w, r, "failed to block user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CoreHandler) UnblockUser(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	blockedId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.service.UnblockUser(r.Context(), userId, blockedId); err != nil {
+		h.handleError(w, r, "failed to unblock user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CoreHandler) MuteUser(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	mutedId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.service.MuteUser(r.Context(), userId, mutedId); err != nil {
+		h.handleError(w, r, "failed to mute user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CoreHandler) UnmuteUser(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	mutedId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.service.UnmuteUser(r.Context(), userId, mutedId); err != nil {
+		h.handleError(w, r, "failed to unmute user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetBlockedUsers возвращает пользователей, заблокированных пользователем из заголовка User-Id
+func (h *CoreHandler) GetBlockedUsers(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	users, err := h.service.GetBlockedUsers(r.Context(), userId)
+	if err != nil {
+		h.handleError(w, r, "failed to get blocked users", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
+// GetMutedUsers возвращает пользователей, скрытых пользователем из заголовка User-Id
+func (h *CoreHandler) GetMutedUsers(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	users, err := h.service.GetMutedUsers(r.Context(), userId)
+	if err != nil {
+		h.handleError(w, r, "failed to get muted users", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
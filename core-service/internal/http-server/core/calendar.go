@@ -0,0 +1,104 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/lib/ical"
+)
+
+// GetTripCalendar отдает даты и остановки поездки в формате iCalendar
+func (h *CoreHandler) GetTripCalendar(w http.ResponseWriter, r *http.Request) {
+	tripId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid trip ID")
+		return
+	}
+
+	calendar, err := h.service.GetTripCalendar(r.Context(), tripId)
+	if err != nil {
+		h.handleError(w, r, "failed to get trip calendar", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Write(calendar)
+}
+
+// GetUserTripsCalendar отдает ленту календаря с поездками пользователя. Календарные клиенты
+// не передают заголовки, поэтому доступ проверяется по параметру token
+func (h *CoreHandler) GetUserTripsCalendar(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		writeProblem(w, r, http.StatusUnauthorized, "token parameter is required")
+		return
+	}
+
+	calendar, err := h.service.GetUserTripsCalendar(r.Context(), userId, token)
+	if err != nil {
+		h.handleError(w, r, "failed to get trips calendar", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Write(calendar)
+}
+
+// CreateCalendarToken выдает новый адрес ленты календаря. Получить его может только сам
+// пользователь; прежний адрес перестает работать
+func (h *CoreHandler) CreateCalendarToken(w http.ResponseWriter, r *http.Request) {
+	userId, ok := h.calendarOwner(w, r)
+	if !ok {
+		return
+	}
+
+	feed, err := h.service.CreateCalendarToken(r.Context(), userId)
+	if err != nil {
+		h.handleError(w, r, "failed to create calendar token", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(feed)
+}
+
+// RevokeCalendarToken отключает ленту календаря пользователя
+func (h *CoreHandler) RevokeCalendarToken(w http.ResponseWriter, r *http.Request) {
+	userId, ok := h.calendarOwner(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RevokeCalendarToken(r.Context(), userId); err != nil {
+		h.handleError(w, r, "failed to revoke calendar token", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// calendarOwner возвращает пользователя из URL, если он совпадает с пользователем из заголовка User-Id
+func (h *CoreHandler) calendarOwner(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return uuid.Nil, false
+	}
+	ownerId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+		return uuid.Nil, false
+	}
+	if ownerId != userId {
+		writeProblem(w, r, http.StatusForbidden, "calendar feed belongs to another user")
+		return uuid.Nil, false
+	}
+	return userId, true
+}
@@ -0,0 +1,190 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+// Комментарии к фото и поездкам обрабатываются общими функциями, kind задает тип объекта из URL
+
+type commentRequest struct {
+	Body     string
+	ParentId uuid.NullUUID
+}
+
+func (h *CoreHandler) GetPhotoComments(w http.ResponseWriter, r *http.Request) {
+	h.getComments(w, r, models.CommentTargetPhoto)
+}
+
+func (h *CoreHandler) CreatePhotoComment(w http.ResponseWriter, r *http.Request) {
+	h.createComment(w, r, models.CommentTargetPhoto)
+}
+
+func (h *CoreHandler) UpdatePhotoComment(w http.ResponseWriter, r *http.Request) {
+	h.updateComment(w, r, models.CommentTargetPhoto)
+}
+
+func (h *CoreHandler) DeletePhotoComment(w http.ResponseWriter, r *http.Request) {
+	h.deleteComment(w, r, models.CommentTargetPhoto)
+}
+
+func (h *CoreHandler) GetPhotoCommentHistory(w http.ResponseWriter, r *http.Request) {
+	h.getCommentHistory(w, r, models.CommentTargetPhoto)
+}
+
+func (h *CoreHandler) GetTripComments(w http.ResponseWriter, r *http.Request) {
+	h.getComments(w, r, models.CommentTargetTrip)
+}
+
+func (h *CoreHandler) CreateTripComment(w http.ResponseWriter, r *http.Request) {
+	h.createComment(w, r, models.CommentTargetTrip)
+}
+
+func (h *CoreHandler) UpdateTripComment(w http.ResponseWriter, r *http.Request) {
+	h.updateComment(w, r, models.CommentTargetTrip)
+}
+
+func (h *CoreHandler) DeleteTripComment(w http.ResponseWriter, r *http.Request) {
+	h.deleteComment(w, r, models.CommentTargetTrip)
+}
+
+func (h *CoreHandler) GetTripCommentHistory(w http.ResponseWriter, r *http.Request) {
+	h.getCommentHistory(w, r, models.CommentTargetTrip)
+}
+
+func (h *CoreHandler) getComments(w http.ResponseWriter, r *http.Request, kind string) {
+	target, ok := commentTarget(w, r, kind)
+	if !ok {
+		return
+	}
+
+	comments, err := h.service.GetComments(r.Context(), target, viewerId(r))
+	if err != nil {
+		h.handleError(w, r, "failed to get comments", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comments)
+}
+
+// createComment добавляет комментарий от пользователя из заголовка User-Id.
+// Для ответа в теле передается ParentId
+func (h *CoreHandler) createComment(w http.ResponseWriter, r *http.Request, kind string) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	target, ok := commentTarget(w, r, kind)
+	if !ok {
+		return
+	}
+	var req commentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	comment, err := h.service.CreateComment(r.Context(), models.Comment{
+		Target:   target,
+		ParentId: req.ParentId,
+		UserId:   userId,
+		Body:     req.Body,
+	})
+	if err != nil {
+		h.handleError(w, r, "failed to create comment", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+func (h *CoreHandler) updateComment(w http.ResponseWriter, r *http.Request, kind string) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	target, ok := commentTarget(w, r, kind)
+	if !ok {
+		return
+	}
+	commentId, err := uuid.Parse(chi.URLParam(r, "commentId"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid comment ID")
+		return
+	}
+	var req commentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	comment, err := h.service.UpdateComment(r.Context(), target, commentId, userId, req.Body)
+	if err != nil {
+		h.handleError(w, r, "failed to update comment", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+func (h *CoreHandler) deleteComment(w http.ResponseWriter, r *http.Request, kind string) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	target, ok := commentTarget(w, r, kind)
+	if !ok {
+		return
+	}
+	commentId, err := uuid.Parse(chi.URLParam(r, "commentId"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid comment ID")
+		return
+	}
+
+	if err := h.service.DeleteComment(r.Context(), target, commentId, userId); err != nil {
+		h.handleError(w, r, "failed to delete comment", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getCommentHistory возвращает прежние версии текста комментария
+func (h *CoreHandler) getCommentHistory(w http.ResponseWriter, r *http.Request, kind string) {
+	target, ok := commentTarget(w, r, kind)
+	if !ok {
+		return
+	}
+	commentId, err := uuid.Parse(chi.URLParam(r, "commentId"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid comment ID")
+		return
+	}
+
+	revisions, err := h.service.GetCommentHistory(r.Context(), target, commentId, viewerId(r))
+	if err != nil {
+		h.handleError(w, r, "failed to get comment history", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+func commentTarget(w http.ResponseWriter, r *http.Request, kind string) (models.CommentTarget, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid "+kind+" ID")
+		return models.CommentTarget{}, false
+	}
+	return models.CommentTarget{Kind: kind, Id: id}, true
+}
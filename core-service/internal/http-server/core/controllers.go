@@ -4,31 +4,36 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"time"
 
-	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/lib/catalog"
 	"github.com/shameoff/more-than-trip/core/internal/lib/logger/sl"
 )
 
 type CoreService interface {
 	UploadPhoto(ctx context.Context, file multipart.File, fileHeader *multipart.FileHeader, metadata models.Photo) error
 	// Работа с фотографиями
-	GetPhoto(ctx context.Context, photoId uuid.UUID) (models.Photo, error)
+	GetPhoto(ctx context.Context, photoId uuid.UUID, viewerId uuid.UUID) (models.Photo, error)
 	GetPhotos(ctx context.Context, filters models.PhotoFiltersDTO) ([]models.Photo, error)
-	UpdatePhoto(ctx context.Context, photoId uuid.UUID, data models.Photo) error
-	DeletePhoto(ctx context.Context, photoId uuid.UUID) error
-	GetPhotosByTripId(ctx context.Context, tripId uuid.UUID) ([]models.Photo, error)
-	GetPhotosByUserId(ctx context.Context, userId uuid.UUID) ([]models.Photo, error)
-	GetPhotosByRegionId(ctx context.Context, regionId uuid.UUID) ([]models.Photo, error)
+	UpdatePhoto(ctx context.Context, userId uuid.UUID, photoId uuid.UUID, data models.Photo) error
+	DeletePhoto(ctx context.Context, userId uuid.UUID, photoId uuid.UUID) error
+	GetPhotosByTripId(ctx context.Context, tripId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error)
+	GetPhotosByUserId(ctx context.Context, userId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error)
+	GetPhotosByRegionId(ctx context.Context, regionId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error)
 
 	// Работа с лайками
 	LikePhoto(ctx context.Context, photoId uuid.UUID, userId uuid.UUID) error
 	DislikePhoto(ctx context.Context, photoId uuid.UUID, userId uuid.UUID) error
+	GetPhotoLikes(ctx context.Context, photoId uuid.UUID, viewerId uuid.UUID) ([]models.Like, error)
+	RecordView(ctx context.Context, photoId uuid.UUID, viewerId uuid.UUID, fingerprint string) error
 
 	// Работа с тегами
 	CreateTag(ctx context.Context, tag models.Tag) error
@@ -40,8 +45,113 @@ type CoreService interface {
 	CreateRegion(ctx context.Context, region models.Region) error
 	DeleteRegion(ctx context.Context, regionId uuid.UUID) error
 	GetRegionById(ctx context.Context, regionId uuid.UUID) (models.Region, error)
+	GetRegionByKey(ctx context.Context, regionKey string) (models.Region, error)
 	GetRegions(ctx context.Context) ([]models.Region, error)
+	GetRegionChildren(ctx context.Context, parentId uuid.UUID) ([]models.Region, error)
+	SetRegionBoundary(ctx context.Context, callerId uuid.UUID, regionId uuid.UUID, geoJSON []byte) error
+	ReassignPhotoRegions(ctx context.Context, callerId uuid.UUID) (int, error)
+	CheckReferences(ctx context.Context) ([]models.DanglingReference, error)
+	GetHomeFeed(ctx context.Context, userId uuid.UUID, cursor string, limit int) (models.HomeFeedPage, error)
+	GetTrendingFeed(ctx context.Context, window string, filters models.PhotoFiltersDTO, cursor string, limit int) (models.FeedPage, error)
+	ImportCatalogAs(ctx context.Context, callerId uuid.UUID, catalogName string, format string, r io.Reader, apply bool) (models.ImportReport, error)
 	UpdateRegion(ctx context.Context, regionId uuid.UUID, data models.Region) error
+
+	// Работа с местами
+	CreatePlace(ctx context.Context, place models.Place) error
+	DeletePlace(ctx context.Context, placeId uuid.UUID) error
+	GetPlaceById(ctx context.Context, placeId uuid.UUID) (models.Place, error)
+	GetPlaces(ctx context.Context, regionId uuid.UUID) ([]models.Place, error)
+	UpdatePlace(ctx context.Context, placeId uuid.UUID, data models.Place) error
+
+	// Подписки
+	FollowUser(ctx context.Context, followerId uuid.UUID, followeeId uuid.UUID) error
+	UnfollowUser(ctx context.Context, followerId uuid.UUID, followeeId uuid.UUID) error
+	GetFollowers(ctx context.Context, userId uuid.UUID) ([]models.Follow, error)
+	GetFollowing(ctx context.Context, userId uuid.UUID) ([]models.Follow, error)
+	FollowRegion(ctx context.Context, userId uuid.UUID, regionId uuid.UUID) error
+	UnfollowRegion(ctx context.Context, userId uuid.UUID, regionId uuid.UUID) error
+	GetFollowedRegions(ctx context.Context, userId uuid.UUID) ([]models.Region, error)
+
+	// Блокировки и скрытие пользователей
+	BlockUser(ctx context.Context, blockerId uuid.UUID, blockedId uuid.UUID) error
+	UnblockUser(ctx context.Context, blockerId uuid.UUID, blockedId uuid.UUID) error
+	GetBlockedUsers(ctx context.Context, userId uuid.UUID) ([]models.UserRestriction, error)
+	MuteUser(ctx context.Context, userId uuid.UUID, mutedId uuid.UUID) error
+	UnmuteUser(ctx context.Context, userId uuid.UUID, mutedId uuid.UUID) error
+	GetMutedUsers(ctx context.Context, userId uuid.UUID) ([]models.UserRestriction, error)
+
+	// Альбомы
+	CreateAlbum(ctx context.Context, album models.Album) (models.Album, error)
+	GetAlbum(ctx context.Context, albumId uuid.UUID, viewerId uuid.UUID) (models.Album, error)
+	GetAlbums(ctx context.Context, userId uuid.UUID, viewerId uuid.UUID) ([]models.Album, error)
+	UpdateAlbum(ctx context.Context, albumId uuid.UUID, userId uuid.UUID, data models.Album) (models.Album, error)
+	DeleteAlbum(ctx context.Context, albumId uuid.UUID, userId uuid.UUID) error
+	GetAlbumPhotos(ctx context.Context, albumId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error)
+	AddAlbumPhoto(ctx context.Context, albumId uuid.UUID, userId uuid.UUID, photoId uuid.UUID) error
+	RemoveAlbumPhoto(ctx context.Context, albumId uuid.UUID, userId uuid.UUID, photoId uuid.UUID) error
+	ReorderAlbumPhotos(ctx context.Context, albumId uuid.UUID, userId uuid.UUID, photoIds []uuid.UUID) error
+
+	// Маршрут и хронология поездки
+	GetItinerary(ctx context.Context, tripId uuid.UUID) (models.Itinerary, error)
+	SetItinerary(ctx context.Context, userId uuid.UUID, itinerary models.Itinerary) (models.Itinerary, error)
+	GetTripTimeline(ctx context.Context, tripId uuid.UUID, viewerId uuid.UUID, loc *time.Location) (models.Timeline, error)
+
+	// Календарь поездок
+	GetTripCalendar(ctx context.Context, tripId uuid.UUID) ([]byte, error)
+	GetUserTripsCalendar(ctx context.Context, userId uuid.UUID, token string) ([]byte, error)
+	CreateCalendarToken(ctx context.Context, userId uuid.UUID) (models.CalendarFeed, error)
+	RevokeCalendarToken(ctx context.Context, userId uuid.UUID) error
+
+	// Комментарии
+	CreateComment(ctx context.Context, comment models.Comment) (models.Comment, error)
+	GetComments(ctx context.Context, target models.CommentTarget, viewerId uuid.UUID) ([]models.Comment, error)
+	UpdateComment(ctx context.Context, target models.CommentTarget, commentId uuid.UUID, userId uuid.UUID, body string) (models.Comment, error)
+	DeleteComment(ctx context.Context, target models.CommentTarget, commentId uuid.UUID, userId uuid.UUID) error
+	GetCommentHistory(ctx context.Context, target models.CommentTarget, commentId uuid.UUID, viewerId uuid.UUID) ([]models.CommentRevision, error)
+
+	// Уведомления
+	GetNotifications(ctx context.Context, userId uuid.UUID, unreadOnly bool, limit int) (models.NotificationInbox, error)
+	MarkNotificationRead(ctx context.Context, userId uuid.UUID, notificationId uuid.UUID) error
+	MarkAllNotificationsRead(ctx context.Context, userId uuid.UUID) (int, error)
+	GetNotificationPreferences(ctx context.Context, userId uuid.UUID) ([]models.NotificationPreference, error)
+	SetNotificationPreferences(ctx context.Context, userId uuid.UUID, preferences []models.NotificationPreference) ([]models.NotificationPreference, error)
+
+	// Поток обновлений
+	SubscribeStream(ctx context.Context, filter models.StreamFilter) (<-chan models.StreamEvent, func(), error)
+	CreateStreamToken(ctx context.Context, userId uuid.UUID) (models.StreamToken, error)
+	StreamTokenUser(token string) (uuid.UUID, error)
+	IsStreamOriginAllowed(origin string) bool
+
+	// Вебхуки партнеров
+	CreateWebhook(ctx context.Context, callerId uuid.UUID, data models.Webhook) (models.Webhook, error)
+	GetWebhooks(ctx context.Context, callerId uuid.UUID) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, callerId uuid.UUID, webhookId uuid.UUID) error
+	GetWebhookDeliveries(ctx context.Context, callerId uuid.UUID, webhookId uuid.UUID) ([]models.WebhookDelivery, error)
+	GetWebhookAttempts(ctx context.Context, callerId uuid.UUID, deliveryId uuid.UUID) ([]models.WebhookAttempt, error)
+
+	// Модерация фото
+	GetModerationQueue(ctx context.Context, moderatorId uuid.UUID, regionId uuid.UUID, limit int) ([]models.Photo, error)
+	ModeratePhoto(ctx context.Context, moderatorId uuid.UUID, photoId uuid.UUID, status string, reason string) (models.Photo, error)
+	GetTrustedUsers(ctx context.Context, moderatorId uuid.UUID, regionId uuid.UUID) ([]models.TrustedUser, error)
+	TrustUser(ctx context.Context, moderatorId uuid.UUID, regionId uuid.UUID, userId uuid.UUID) error
+	DistrustUser(ctx context.Context, moderatorId uuid.UUID, regionId uuid.UUID, userId uuid.UUID) error
+	SetModerator(ctx context.Context, callerId uuid.UUID, userId uuid.UUID, moderator bool) error
+
+	// Жалобы пользователей
+	CreateReport(ctx context.Context, report models.Report) (models.Report, bool, error)
+	GetReportSummaries(ctx context.Context, moderatorId uuid.UUID, targetType string, limit int) ([]models.ReportSummary, error)
+	GetReports(ctx context.Context, moderatorId uuid.UUID, targetType string, targetId uuid.UUID) ([]models.Report, error)
+	ResolveReports(ctx context.Context, targetType string, targetId uuid.UUID, resolution models.ReportResolution) ([]models.Report, error)
+
+	// Ссылки для доступа к фото и поездкам
+	CreateShareLink(ctx context.Context, link models.ShareLink) (models.ShareLink, error)
+	GetSharedContent(ctx context.Context, token string) (models.SharedContent, error)
+	GetShareLinks(ctx context.Context, userId uuid.UUID) ([]models.ShareLink, error)
+	RevokeShareLink(ctx context.Context, userId uuid.UUID, linkId uuid.UUID) error
+
+	// Фоновые задачи
+	GetJobs(ctx context.Context, callerId uuid.UUID, status string) ([]models.Job, error)
+	RetryJob(ctx context.Context, callerId uuid.UUID, jobId uuid.UUID) error
 }
 
 type CoreHandler struct {
@@ -56,8 +166,14 @@ func NewCoreHandler(service CoreService, logger *slog.Logger) *CoreHandler {
 	}
 }
 
-// UploadPhoto - HTTP handler для загрузки фото и метаданных
+// UploadPhoto - HTTP handler для загрузки фото и метаданных.
+// Автор фото - пользователь из заголовка User-Id, UserId из метаданных не используется
 func (h *CoreHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), time.Second*30)
 	defer cancel()
 
@@ -65,7 +181,7 @@ func (h *CoreHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
 	file, fileHeader, err := r.FormFile("file")
 	if err != nil {
 		h.logger.Error("failed to get file from request", sl.Err(err))
-		http.Error(w, "invalid file", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid file")
 		return
 	}
 	defer file.Close()
@@ -74,7 +190,7 @@ func (h *CoreHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
 	metadataStr := r.FormValue("metadata")
 	if metadataStr == "" {
 		h.logger.Error("missing metadata")
-		http.Error(w, "missing metadata", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "missing metadata")
 		return
 	}
 
@@ -82,15 +198,15 @@ func (h *CoreHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
 	metadata := models.Photo{}
 	if err := json.Unmarshal([]byte(metadataStr), &metadata); err != nil {
 		h.logger.Error("failed to parse metadata", sl.Err(err))
-		http.Error(w, "invalid metadata format", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid metadata format")
 		return
 	}
+	metadata.UserId = userId
 
 	// Передача файла и метаданных на уровень бизнес-логики
 	err = h.service.UploadPhoto(ctx, file, fileHeader, metadata)
 	if err != nil {
-		h.logger.Error("failed to upload file via service", sl.Err(err))
-		http.Error(w, "file upload failed", http.StatusInternalServerError)
+		h.handleError(w, r, "file upload failed", err)
 		return
 	}
 
@@ -98,19 +214,24 @@ func (h *CoreHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// DeletePhoto удаляет фото; удалить его может только автор из заголовка User-Id
 func (h *CoreHandler) DeletePhoto(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
 	// Получаем photoId из URL параметров
-	photoIdStr := chi.URLParam(r, "photoId")
+	photoIdStr := chi.URLParam(r, "UUID")
 	photoId, err := uuid.Parse(photoIdStr)
 	if err != nil {
-		http.Error(w, "invalid photo ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid photo ID")
 		return
 	}
 
 	// Вызываем бизнес-логику для удаления фотографии
-	err = h.service.DeletePhoto(r.Context(), photoId)
+	err = h.service.DeletePhoto(r.Context(), userId, photoId)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to delete photo: %v", err), http.StatusInternalServerError)
+		h.handleError(w, r, "failed to delete photo", err)
 		return
 	}
 
@@ -118,16 +239,16 @@ func (h *CoreHandler) DeletePhoto(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Photo deleted successfully"))
 }
 func (h *CoreHandler) GetPhoto(w http.ResponseWriter, r *http.Request) {
-	photoIdStr := chi.URLParam(r, "photoId")
+	photoIdStr := chi.URLParam(r, "UUID")
 	photoId, err := uuid.Parse(photoIdStr)
 	if err != nil {
-		http.Error(w, "invalid photo ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid photo ID")
 		return
 	}
 
-	photo, err := h.service.GetPhoto(r.Context(), photoId)
+	photo, err := h.service.GetPhoto(r.Context(), photoId, viewerId(r))
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to get photo: %v", err), http.StatusInternalServerError)
+		h.handleError(w, r, "failed to get photo", err)
 		return
 	}
 
@@ -135,42 +256,44 @@ func (h *CoreHandler) GetPhoto(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(photo)
 }
 func (h *CoreHandler) GetPhotos(w http.ResponseWriter, r *http.Request) {
-	var filters models.PhotoFiltersDTO
-
-	// Пример получения фильтров из запроса
-	if regionId := r.URL.Query().Get("region"); regionId != "" {
-		filters.RegionId, _ = uuid.Parse(regionId)
-	}
-	if tag := r.URL.Query().Get("tag"); tag != "" {
-		filters.TagKey = tag
+	filters, err := photoFiltersFromQuery(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	photos, err := h.service.GetPhotos(r.Context(), filters)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to get photos: %v", err), http.StatusInternalServerError)
+		h.handleError(w, r, "failed to get photos", err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(photos)
 }
+
+// UpdatePhoto меняет фото; менять его может только автор из заголовка User-Id
 func (h *CoreHandler) UpdatePhoto(w http.ResponseWriter, r *http.Request) {
-	photoIdStr := chi.URLParam(r, "photoId")
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	photoIdStr := chi.URLParam(r, "UUID")
 	photoId, err := uuid.Parse(photoIdStr)
 	if err != nil {
-		http.Error(w, "invalid photo ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid photo ID")
 		return
 	}
 
 	var updatedPhoto models.Photo
 	if err := json.NewDecoder(r.Body).Decode(&updatedPhoto); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	err = h.service.UpdatePhoto(r.Context(), photoId, updatedPhoto)
+	err = h.service.UpdatePhoto(r.Context(), userId, photoId, updatedPhoto)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to update photo: %v", err), http.StatusInternalServerError)
+		h.handleError(w, r, "failed to update photo", err)
 		return
 	}
 
@@ -179,23 +302,20 @@ func (h *CoreHandler) UpdatePhoto(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CoreHandler) LikePhoto(w http.ResponseWriter, r *http.Request) {
-	photoIdStr := chi.URLParam(r, "photoId")
-	photoId, err := uuid.Parse(photoIdStr)
-	if err != nil {
-		http.Error(w, "invalid photo ID", http.StatusBadRequest)
+	userId, ok := currentUser(w, r)
+	if !ok {
 		return
 	}
-
-	userIdStr := r.Header.Get("User-Id") // Предположим, что ID пользователя передается в заголовке
-	userId, err := uuid.Parse(userIdStr)
+	photoIdStr := chi.URLParam(r, "UUID")
+	photoId, err := uuid.Parse(photoIdStr)
 	if err != nil {
-		http.Error(w, "invalid user ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid photo ID")
 		return
 	}
 
 	err = h.service.LikePhoto(r.Context(), photoId, userId)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to like photo: %v", err), http.StatusInternalServerError)
+		h.handleError(w, r, "failed to like photo", err)
 		return
 	}
 
@@ -203,23 +323,20 @@ func (h *CoreHandler) LikePhoto(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Photo liked successfully"))
 }
 func (h *CoreHandler) DislikePhoto(w http.ResponseWriter, r *http.Request) {
-	photoIdStr := chi.URLParam(r, "photoId")
-	photoId, err := uuid.Parse(photoIdStr)
-	if err != nil {
-		http.Error(w, "invalid photo ID", http.StatusBadRequest)
+	userId, ok := currentUser(w, r)
+	if !ok {
 		return
 	}
-
-	userIdStr := r.Header.Get("User-Id")
-	userId, err := uuid.Parse(userIdStr)
+	photoIdStr := chi.URLParam(r, "UUID")
+	photoId, err := uuid.Parse(photoIdStr)
 	if err != nil {
-		http.Error(w, "invalid user ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid photo ID")
 		return
 	}
 
 	err = h.service.DislikePhoto(r.Context(), photoId, userId)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to dislike photo: %v", err), http.StatusInternalServerError)
+		h.handleError(w, r, "failed to dislike photo", err)
 		return
 	}
 
@@ -227,16 +344,95 @@ func (h *CoreHandler) DislikePhoto(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Photo disliked successfully"))
 }
 
+// GetPhotoLikes отдает список пользователей, лайкнувших фото
+func (h *CoreHandler) GetPhotoLikes(w http.ResponseWriter, r *http.Request) {
+	photoIdStr := chi.URLParam(r, "UUID")
+	photoId, err := uuid.Parse(photoIdStr)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid photo ID")
+		return
+	}
+
+	likes, err := h.service.GetPhotoLikes(r.Context(), photoId, viewerId(r))
+	if err != nil {
+		h.handleError(w, r, "failed to get photo likes", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(likes)
+}
+
+// RecordView - beacon просмотра фото. Фото, которое пользователь не может видеть, дает 404.
+// Просмотр записывается асинхронно, поэтому ответ 202 не означает, что он уже сохранен
+func (h *CoreHandler) RecordView(w http.ResponseWriter, r *http.Request) {
+	photoIdStr := chi.URLParam(r, "UUID")
+	photoId, err := uuid.Parse(photoIdStr)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid photo ID")
+		return
+	}
+
+	err = h.service.RecordView(r.Context(), photoId, viewerId(r), clientFingerprint(r))
+	if err != nil {
+		h.handleError(w, r, "failed to record view", err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// clientFingerprint собирает отпечаток анонимного клиента из адреса и User-Agent.
+// За nginx адрес клиента передается в X-Real-IP
+func clientFingerprint(r *http.Request) string {
+	addr := r.Header.Get("X-Real-IP")
+	if addr == "" {
+		addr, _, _ = net.SplitHostPort(r.RemoteAddr)
+	}
+	return addr + "|" + r.UserAgent()
+}
+
+// photoFiltersFromQuery читает фильтры фото из параметров region, trip и tag
+func photoFiltersFromQuery(r *http.Request) (models.PhotoFiltersDTO, error) {
+	filters := models.PhotoFiltersDTO{
+		TagKey:   r.URL.Query().Get("tag"),
+		ViewerId: viewerId(r),
+	}
+	if regionId := r.URL.Query().Get("region"); regionId != "" {
+		id, err := uuid.Parse(regionId)
+		if err != nil {
+			return filters, fmt.Errorf("invalid region ID")
+		}
+		filters.RegionId = id
+	}
+	if tripId := r.URL.Query().Get("trip"); tripId != "" {
+		id, err := uuid.Parse(tripId)
+		if err != nil {
+			return filters, fmt.Errorf("invalid trip ID")
+		}
+		filters.TripId = id
+	}
+	return filters, nil
+}
+
+// viewerId возвращает пользователя из заголовка User-Id или uuid.Nil для анонимного запроса
+func viewerId(r *http.Request) uuid.UUID {
+	userId, err := uuid.Parse(r.Header.Get("User-Id"))
+	if err != nil {
+		return uuid.Nil
+	}
+	return userId
+}
+
 func (h *CoreHandler) CreateRegion(w http.ResponseWriter, r *http.Request) {
 	var region models.Region
 	if err := json.NewDecoder(r.Body).Decode(&region); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	err := h.service.CreateRegion(r.Context(), region)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to create region: %v", err), http.StatusInternalServerError)
+		h.handleError(w, r, "failed to create region", err)
 		return
 	}
 
@@ -244,16 +440,16 @@ func (h *CoreHandler) CreateRegion(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Region created successfully"))
 }
 func (h *CoreHandler) DeleteRegion(w http.ResponseWriter, r *http.Request) {
-	regionIdStr := chi.URLParam(r, "regionId")
+	regionIdStr := chi.URLParam(r, "UUID")
 	regionId, err := uuid.Parse(regionIdStr)
 	if err != nil {
-		http.Error(w, "invalid region ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid region ID")
 		return
 	}
 
 	err = h.service.DeleteRegion(r.Context(), regionId)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to delete region: %v", err), http.StatusInternalServerError)
+		h.handleError(w, r, "failed to delete region", err)
 		return
 	}
 
@@ -261,26 +457,61 @@ func (h *CoreHandler) DeleteRegion(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Region deleted successfully"))
 }
 func (h *CoreHandler) GetRegion(w http.ResponseWriter, r *http.Request) {
-	regionIdStr := chi.URLParam(r, "regionId")
+	regionIdStr := chi.URLParam(r, "UUID")
 	regionId, err := uuid.Parse(regionIdStr)
 	if err != nil {
-		http.Error(w, "invalid region ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid region ID")
 		return
 	}
 
 	region, err := h.service.GetRegionById(r.Context(), regionId)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to get region: %v", err), http.StatusInternalServerError)
+		h.handleError(w, r, "failed to get region", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(region)
+}
+
+// GetRegionByKey отдает регион по его короткому уникальному ключу (object_key)
+func (h *CoreHandler) GetRegionByKey(w http.ResponseWriter, r *http.Request) {
+	regionKey := chi.URLParam(r, "key")
+	if regionKey == "" {
+		writeProblem(w, r, http.StatusBadRequest, "invalid region key")
+		return
+	}
+
+	region, err := h.service.GetRegionByKey(r.Context(), regionKey)
+	if err != nil {
+		h.handleError(w, r, "failed to get region", err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(region)
 }
+func (h *CoreHandler) GetRegionChildren(w http.ResponseWriter, r *http.Request) {
+	regionIdStr := chi.URLParam(r, "UUID")
+	regionId, err := uuid.Parse(regionIdStr)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid region ID")
+		return
+	}
+
+	regions, err := h.service.GetRegionChildren(r.Context(), regionId)
+	if err != nil {
+		h.handleError(w, r, "failed to get region children", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(regions)
+}
 func (h *CoreHandler) GetRegions(w http.ResponseWriter, r *http.Request) {
 	regions, err := h.service.GetRegions(r.Context())
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to get regions: %v", err), http.StatusInternalServerError)
+		h.handleError(w, r, "failed to get regions", err)
 		return
 	}
 
@@ -288,22 +519,22 @@ func (h *CoreHandler) GetRegions(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(regions)
 }
 func (h *CoreHandler) UpdateRegion(w http.ResponseWriter, r *http.Request) {
-	regionIdStr := chi.URLParam(r, "regionId")
+	regionIdStr := chi.URLParam(r, "UUID")
 	regionId, err := uuid.Parse(regionIdStr)
 	if err != nil {
-		http.Error(w, "invalid region ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid region ID")
 		return
 	}
 
 	var updatedRegion models.Region
 	if err := json.NewDecoder(r.Body).Decode(&updatedRegion); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	err = h.service.UpdateRegion(r.Context(), regionId, updatedRegion)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to update region: %v", err), http.StatusInternalServerError)
+		h.handleError(w, r, "failed to update region", err)
 		return
 	}
 
@@ -311,16 +542,109 @@ func (h *CoreHandler) UpdateRegion(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Region updated successfully"))
 }
 
+// SetRegionBoundary принимает границу региона в формате GeoJSON. Доступно только модераторам
+func (h *CoreHandler) SetRegionBoundary(w http.ResponseWriter, r *http.Request) {
+	callerId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	regionIdStr := chi.URLParam(r, "UUID")
+	regionId, err := uuid.Parse(regionIdStr)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid region ID")
+		return
+	}
+
+	geoJSON, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	err = h.service.SetRegionBoundary(r.Context(), callerId, regionId, geoJSON)
+	if err != nil {
+		h.handleError(w, r, "failed to set region boundary", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Region boundary updated successfully"))
+}
+
+// ReassignPhotoRegions заново определяет регионы существующих фото по координатам.
+// Доступно только модераторам
+func (h *CoreHandler) ReassignPhotoRegions(w http.ResponseWriter, r *http.Request) {
+	callerId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	updated, err := h.service.ReassignPhotoRegions(r.Context(), callerId)
+	if err != nil {
+		h.handleError(w, r, "failed to reassign photo regions", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"Updated": updated})
+}
+
+// ImportCatalog импортирует справочник из CSV или GeoJSON.
+// Параметры: catalog (regions, places, tags), format (csv, geojson), apply=true для применения.
+// Файл передается полем "file" multipart-формы или телом запроса. Доступно только модераторам
+func (h *CoreHandler) ImportCatalog(w http.ResponseWriter, r *http.Request) {
+	callerId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	catalogName := r.URL.Query().Get("catalog")
+	format := r.URL.Query().Get("format")
+	apply := r.URL.Query().Get("apply") == "true"
+
+	var body io.Reader = r.Body
+	if file, fileHeader, err := r.FormFile("file"); err == nil {
+		defer file.Close()
+		body = file
+		if format == "" {
+			format = catalog.FormatFromFileName(fileHeader.Filename)
+		}
+	}
+	if format == "" {
+		writeProblem(w, r, http.StatusBadRequest, "missing catalog format")
+		return
+	}
+
+	report, err := h.service.ImportCatalogAs(r.Context(), callerId, catalogName, format, body, apply)
+	if err != nil {
+		h.handleError(w, r, "failed to import catalog", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// CheckReferences отдает отчет о висячих ссылках между таблицами
+func (h *CoreHandler) CheckReferences(w http.ResponseWriter, r *http.Request) {
+	dangling, err := h.service.CheckReferences(r.Context())
+	if err != nil {
+		h.handleError(w, r, "failed to check references", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dangling)
+}
+
 func (h *CoreHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
 	var tag models.Tag
 	if err := json.NewDecoder(r.Body).Decode(&tag); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	err := h.service.CreateTag(r.Context(), tag)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to create tag: %v", err), http.StatusInternalServerError)
+		h.handleError(w, r, "failed to create tag", err)
 		return
 	}
 
@@ -330,7 +654,7 @@ func (h *CoreHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
 func (h *CoreHandler) GetTags(w http.ResponseWriter, r *http.Request) {
 	tags, err := h.service.GetTags(r.Context())
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to get tags: %v", err), http.StatusInternalServerError)
+		h.handleError(w, r, "failed to get tags", err)
 		return
 	}
 
@@ -338,11 +662,11 @@ func (h *CoreHandler) GetTags(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(tags)
 }
 func (h *CoreHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
-	tagId := chi.URLParam(r, "tagId")
+	tagId := chi.URLParam(r, "UUID")
 
 	err := h.service.DeleteTag(r.Context(), tagId)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to delete tag: %v", err), http.StatusInternalServerError)
+		h.handleError(w, r, "failed to delete tag", err)
 		return
 	}
 
@@ -366,6 +690,8 @@ func (h *CoreHandler) DeleteChallenge(w http.ResponseWriter, r *http.Request) {
 	notImplemented(w)
 }
 
+// UpdateChallenge пока не реализован. Перевод вызова в статус completed должен
+// отправлять партнерам событие models.EventChallengeCompleted
 func (h *CoreHandler) UpdateChallenge(w http.ResponseWriter, r *http.Request) {
 	notImplemented(w)
 }
@@ -0,0 +1,72 @@
+// Ответы об ошибках в формате RFC 7807 (application/problem+json)
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/lib/logger/sl"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// writeProblem отдает ошибку клиенту в формате application/problem+json
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	})
+}
+
+// currentUser возвращает пользователя из заголовка User-Id. Если заголовка нет,
+// отвечает 401 и возвращает false
+func currentUser(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userId := viewerId(r)
+	if userId == uuid.Nil {
+		writeProblem(w, r, http.StatusUnauthorized, "missing or invalid User-Id header")
+		return uuid.Nil, false
+	}
+	return userId, true
+}
+
+// domainErrors задает HTTP-статус для каждой доменной ошибки. Клиенту в detail уходит
+// только текст самой доменной ошибки: цепочка может содержать ключи, SQL и имена таблиц
+var domainErrors = []struct {
+	err    error
+	status int
+}{
+	{storage.ErrNotFound, http.StatusNotFound},
+	{storage.ErrConflict, http.StatusConflict},
+	{storage.ErrValidation, http.StatusUnprocessableEntity},
+	{storage.ErrForbidden, http.StatusForbidden},
+}
+
+// handleError переводит доменную ошибку в HTTP-статус. Полная цепочка ошибки
+// клиенту не отдается, она только пишется в лог
+func (h *CoreHandler) handleError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	for _, d := range domainErrors {
+		if errors.Is(err, d.err) {
+			h.logger.Info(msg, slog.Int("status", d.status), sl.Err(err))
+			writeProblem(w, r, d.status, msg+": "+d.err.Error())
+			return
+		}
+	}
+
+	h.logger.Error(msg, sl.Err(err))
+	writeProblem(w, r, http.StatusInternalServerError, msg)
+}
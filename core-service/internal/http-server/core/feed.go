@@ -0,0 +1,35 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// GetTrendingFeed отдает ленту популярных фото.
+// Параметры: window (24h, 7d, 30d), region, trip, tag, cursor, limit
+func (h *CoreHandler) GetTrendingFeed(w http.ResponseWriter, r *http.Request) {
+	filters, err := photoFiltersFromQuery(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var limit int
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	page, err := h.service.GetTrendingFeed(r.Context(), r.URL.Query().Get("window"), filters, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		h.handleError(w, r, "failed to get trending feed", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
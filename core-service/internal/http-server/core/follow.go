@@ -0,0 +1,167 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// FollowUser подписывает пользователя из заголовка User-Id на пользователя из URL
+func (h *CoreHandler) FollowUser(w http.ResponseWriter, r *http.Request) {
+	followerId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	followeeId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.service.FollowUser(r.Context(), followerId, followeeId); err != nil {
+		h.handleError(w, r, "failed to follow user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CoreHandler) UnfollowUser(w http.ResponseWriter, r *http.Request) {
+	followerId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	followeeId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.service.UnfollowUser(r.Context(), followerId, followeeId); err != nil {
+		h.handleError(w, r, "failed to unfollow user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CoreHandler) GetFollowers(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	followers, err := h.service.GetFollowers(r.Context(), userId)
+	if err != nil {
+		h.handleError(w, r, "failed to get followers", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(followers)
+}
+
+func (h *CoreHandler) GetFollowing(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	following, err := h.service.GetFollowing(r.Context(), userId)
+	if err != nil {
+		h.handleError(w, r, "failed to get following", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(following)
+}
+
+// FollowRegion подписывает пользователя из заголовка User-Id на регион
+func (h *CoreHandler) FollowRegion(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	regionId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid region ID")
+		return
+	}
+
+	if err := h.service.FollowRegion(r.Context(), userId, regionId); err != nil {
+		h.handleError(w, r, "failed to follow region", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CoreHandler) UnfollowRegion(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	regionId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid region ID")
+		return
+	}
+
+	if err := h.service.UnfollowRegion(r.Context(), userId, regionId); err != nil {
+		h.handleError(w, r, "failed to unfollow region", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CoreHandler) GetFollowedRegions(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	regions, err := h.service.GetFollowedRegions(r.Context(), userId)
+	if err != nil {
+		h.handleError(w, r, "failed to get followed regions", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(regions)
+}
+
+// GetHomeFeed отдает домашнюю ленту пользователя из заголовка User-Id.
+// Параметры: cursor, limit
+func (h *CoreHandler) GetHomeFeed(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var limit int
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	page, err := h.service.GetHomeFeed(r.Context(), userId, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		h.handleError(w, r, "failed to get home feed", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
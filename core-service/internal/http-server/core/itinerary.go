@@ -0,0 +1,83 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+func (h *CoreHandler) GetItinerary(w http.ResponseWriter, r *http.Request) {
+	tripId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid trip ID")
+		return
+	}
+
+	itinerary, err := h.service.GetItinerary(r.Context(), tripId)
+	if err != nil {
+		h.handleError(w, r, "failed to get itinerary", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(itinerary)
+}
+
+// SetItinerary заменяет даты и маршрут поездки от имени участника из заголовка User-Id.
+// Тело: {StartDate, EndDate, Stops: [{Id, Place, PlaceId, ArrivalAt, DepartureAt, Notes}]}
+func (h *CoreHandler) SetItinerary(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	tripId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid trip ID")
+		return
+	}
+	var itinerary models.Itinerary
+	if err := json.NewDecoder(r.Body).Decode(&itinerary); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	itinerary.TripId = tripId
+
+	saved, err := h.service.SetItinerary(r.Context(), userId, itinerary)
+	if err != nil {
+		h.handleError(w, r, "failed to set itinerary", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// GetTripTimeline возвращает фото поездки по дням и остановкам. Параметр tz - часовой пояс
+// IANA, в котором считаются дни, по умолчанию UTC
+func (h *CoreHandler) GetTripTimeline(w http.ResponseWriter, r *http.Request) {
+	tripId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid trip ID")
+		return
+	}
+	loc := time.UTC
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		if loc, err = time.LoadLocation(tz); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid tz")
+			return
+		}
+	}
+
+	timeline, err := h.service.GetTripTimeline(r.Context(), tripId, viewerId(r), loc)
+	if err != nil {
+		h.handleError(w, r, "failed to get trip timeline", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timeline)
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// GetJobs возвращает фоновые задачи в состоянии ?status= (по умолчанию dead). Доступно только модераторам
+func (h *CoreHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	callerId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	jobs, err := h.service.GetJobs(r.Context(), callerId, r.URL.Query().Get("status"))
+	if err != nil {
+		h.handleError(w, r, "failed to get jobs", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// RetryJob возвращает упавшую окончательно задачу в очередь. Доступно только модераторам
+func (h *CoreHandler) RetryJob(w http.ResponseWriter, r *http.Request) {
+	callerId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	jobId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid job ID")
+		return
+	}
+
+	if err := h.service.RetryJob(r.Context(), callerId, jobId); err != nil {
+		h.handleError(w, r, "failed to retry job", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,206 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+type moderationRequest struct {
+	Reason string
+}
+
+// GetModerationQueue возвращает фото, ожидающие модерации. Параметры: region, limit
+func (h *CoreHandler) GetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	moderatorId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	var regionId uuid.UUID
+	if regionStr := r.URL.Query().Get("region"); regionStr != "" {
+		regionId, err = uuid.Parse(regionStr)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid region ID")
+			return
+		}
+	}
+	var limit int
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	photos, err := h.service.GetModerationQueue(r.Context(), moderatorId, regionId, limit)
+	if err != nil {
+		h.handleError(w, r, "failed to get moderation queue", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(photos)
+}
+
+// ApprovePhoto одобряет фото и публикует его. Тело запроса необязательно: {Reason}
+func (h *CoreHandler) ApprovePhoto(w http.ResponseWriter, r *http.Request) {
+	h.moderatePhoto(w, r, models.ModerationApproved)
+}
+
+// RejectPhoto отклоняет фото. Тело запроса: {Reason}, причина обязательна
+func (h *CoreHandler) RejectPhoto(w http.ResponseWriter, r *http.Request) {
+	h.moderatePhoto(w, r, models.ModerationRejected)
+}
+
+// HidePhoto скрывает опубликованное фото. Тело запроса: {Reason}, причина обязательна
+func (h *CoreHandler) HidePhoto(w http.ResponseWriter, r *http.Request) {
+	h.moderatePhoto(w, r, models.ModerationHidden)
+}
+
+// moderatePhoto переводит фото из URL в статус status от имени модератора из заголовка User-Id
+func (h *CoreHandler) moderatePhoto(w http.ResponseWriter, r *http.Request, status string) {
+	moderatorId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	photoId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid photo ID")
+		return
+	}
+	var req moderationRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	photo, err := h.service.ModeratePhoto(r.Context(), moderatorId, photoId, status, req.Reason)
+	if err != nil {
+		h.handleError(w, r, "failed to moderate photo", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(photo)
+}
+
+// GetTrustedUsers возвращает пользователей, фото которых в регионе одобряются без очереди
+func (h *CoreHandler) GetTrustedUsers(w http.ResponseWriter, r *http.Request) {
+	moderatorId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	regionId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid region ID")
+		return
+	}
+
+	users, err := h.service.GetTrustedUsers(r.Context(), moderatorId, regionId)
+	if err != nil {
+		h.handleError(w, r, "failed to get trusted users", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
+func (h *CoreHandler) TrustUser(w http.ResponseWriter, r *http.Request) {
+	moderatorId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	regionId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid region ID")
+		return
+	}
+	userId, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.service.TrustUser(r.Context(), moderatorId, regionId, userId); err != nil {
+		h.handleError(w, r, "failed to trust user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CoreHandler) DistrustUser(w http.ResponseWriter, r *http.Request) {
+	moderatorId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	regionId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid region ID")
+		return
+	}
+	userId, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.service.DistrustUser(r.Context(), moderatorId, regionId, userId); err != nil {
+		h.handleError(w, r, "failed to distrust user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GrantModerator выдает роль модератора пользователю из URL.
+// Вызывать его может только модератор из заголовка User-Id
+func (h *CoreHandler) GrantModerator(w http.ResponseWriter, r *http.Request) {
+	callerId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	userId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.service.SetModerator(r.Context(), callerId, userId, true); err != nil {
+		h.handleError(w, r, "failed to grant moderator", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeModerator отзывает роль модератора у пользователя из URL.
+// Вызывать его может только модератор из заголовка User-Id
+func (h *CoreHandler) RevokeModerator(w http.ResponseWriter, r *http.Request) {
+	callerId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	userId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.service.SetModerator(r.Context(), callerId, userId, false); err != nil {
+		h.handleError(w, r, "failed to revoke moderator", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
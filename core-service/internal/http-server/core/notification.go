@@ -0,0 +1,120 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+// GetNotifications возвращает уведомления пользователя из заголовка User-Id.
+// Параметры: unread=true - только непрочитанные, limit
+func (h *CoreHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	var unreadOnly bool
+	if unreadStr := r.URL.Query().Get("unread"); unreadStr != "" {
+		unreadOnly, err = strconv.ParseBool(unreadStr)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid unread flag")
+			return
+		}
+	}
+	var limit int
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	inbox, err := h.service.GetNotifications(r.Context(), userId, unreadOnly, limit)
+	if err != nil {
+		h.handleError(w, r, "failed to get notifications", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(inbox)
+}
+
+func (h *CoreHandler) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	notificationId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid notification ID")
+		return
+	}
+
+	if err := h.service.MarkNotificationRead(r.Context(), userId, notificationId); err != nil {
+		h.handleError(w, r, "failed to mark notification read", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CoreHandler) MarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	marked, err := h.service.MarkAllNotificationsRead(r.Context(), userId)
+	if err != nil {
+		h.handleError(w, r, "failed to mark notifications read", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"Marked": marked})
+}
+
+func (h *CoreHandler) GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	preferences, err := h.service.GetNotificationPreferences(r.Context(), userId)
+	if err != nil {
+		h.handleError(w, r, "failed to get notification preferences", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preferences)
+}
+
+// SetNotificationPreferences принимает список {Type, Enabled}; не переданные типы не меняются
+func (h *CoreHandler) SetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	var preferences []models.NotificationPreference
+	if err := json.NewDecoder(r.Body).Decode(&preferences); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	updated, err := h.service.SetNotificationPreferences(r.Context(), userId, preferences)
+	if err != nil {
+		h.handleError(w, r, "failed to set notification preferences", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+func (h *CoreHandler) CreatePlace(w http.ResponseWriter, r *http.Request) {
+	var place models.Place
+	if err := json.NewDecoder(r.Body).Decode(&place); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	err := h.service.CreatePlace(r.Context(), place)
+	if err != nil {
+		h.handleError(w, r, "failed to create place", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte("Place created successfully"))
+}
+
+func (h *CoreHandler) DeletePlace(w http.ResponseWriter, r *http.Request) {
+	placeIdStr := chi.URLParam(r, "UUID")
+	placeId, err := uuid.Parse(placeIdStr)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid place ID")
+		return
+	}
+
+	err = h.service.DeletePlace(r.Context(), placeId)
+	if err != nil {
+		h.handleError(w, r, "failed to delete place", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Place deleted successfully"))
+}
+
+func (h *CoreHandler) GetPlace(w http.ResponseWriter, r *http.Request) {
+	placeIdStr := chi.URLParam(r, "UUID")
+	placeId, err := uuid.Parse(placeIdStr)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid place ID")
+		return
+	}
+
+	place, err := h.service.GetPlaceById(r.Context(), placeId)
+	if err != nil {
+		h.handleError(w, r, "failed to get place", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(place)
+}
+
+// GetPlaces отдает справочник мест, опционально отфильтрованный по региону (?region=)
+func (h *CoreHandler) GetPlaces(w http.ResponseWriter, r *http.Request) {
+	var regionId uuid.UUID
+	if regionIdStr := r.URL.Query().Get("region"); regionIdStr != "" {
+		var err error
+		regionId, err = uuid.Parse(regionIdStr)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid region ID")
+			return
+		}
+	}
+
+	places, err := h.service.GetPlaces(r.Context(), regionId)
+	if err != nil {
+		h.handleError(w, r, "failed to get places", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(places)
+}
+
+func (h *CoreHandler) UpdatePlace(w http.ResponseWriter, r *http.Request) {
+	placeIdStr := chi.URLParam(r, "UUID")
+	placeId, err := uuid.Parse(placeIdStr)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid place ID")
+		return
+	}
+
+	var updatedPlace models.Place
+	if err := json.NewDecoder(r.Body).Decode(&updatedPlace); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	err = h.service.UpdatePlace(r.Context(), placeId, updatedPlace)
+	if err != nil {
+		h.handleError(w, r, "failed to update place", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Place updated successfully"))
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+type reportRequest struct {
+	TargetType string
+	TargetId   uuid.UUID
+	Reason     string
+	Details    string
+}
+
+type resolveReportsRequest struct {
+	Action string
+	Note   string
+}
+
+// CreateReport сохраняет жалобу пользователя из заголовка User-Id:
+// {TargetType: photo|comment|trip|user, TargetId, Reason, Details}.
+// Повторная жалоба на тот же объект обновляет открытую и возвращает 200
+func (h *CoreHandler) CreateReport(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	var req reportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	report, created, err := h.service.CreateReport(r.Context(), models.Report{
+		TargetType: req.TargetType,
+		TargetId:   req.TargetId,
+		ReporterId: userId,
+		Reason:     req.Reason,
+		Details:    req.Details,
+	})
+	if err != nil {
+		h.handleError(w, r, "failed to create report", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetReportSummaries возвращает объекты с открытыми жалобами. Параметры: type, limit
+func (h *CoreHandler) GetReportSummaries(w http.ResponseWriter, r *http.Request) {
+	moderatorId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	var limit int
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	summaries, err := h.service.GetReportSummaries(r.Context(), moderatorId, r.URL.Query().Get("type"), limit)
+	if err != nil {
+		h.handleError(w, r, "failed to get report summaries", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summaries)
+}
+
+// GetReports возвращает все жалобы на объект {type}/{UUID}
+func (h *CoreHandler) GetReports(w http.ResponseWriter, r *http.Request) {
+	moderatorId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	targetId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid target ID")
+		return
+	}
+
+	reports, err := h.service.GetReports(r.Context(), moderatorId, chi.URLParam(r, "type"), targetId)
+	if err != nil {
+		h.handleError(w, r, "failed to get reports", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
+
+// ResolveReports закрывает открытые жалобы на объект: {Action: dismiss|hide|resolve, Note}
+func (h *CoreHandler) ResolveReports(w http.ResponseWriter, r *http.Request) {
+	moderatorId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	targetId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid target ID")
+		return
+	}
+	var req resolveReportsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	reports, err := h.service.ResolveReports(r.Context(), chi.URLParam(r, "type"), targetId, models.ReportResolution{
+		Action:      req.Action,
+		Note:        req.Note,
+		ModeratorId: moderatorId,
+	})
+	if err != nil {
+		h.handleError(w, r, "failed to resolve reports", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
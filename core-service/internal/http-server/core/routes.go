@@ -15,13 +15,83 @@ func RegisterRoutes(router *chi.Mux, coreHandler *CoreHandler) {
 	router.Put("/api/photo/{UUID}", coreHandler.UpdatePhoto)
 	router.Post("/api/photo/{UUID}/like", coreHandler.LikePhoto)
 	router.Post("/api/photo/{UUID}/dislike", coreHandler.DislikePhoto)
+	router.Get("/api/photo/{UUID}/likes", coreHandler.GetPhotoLikes)
+	router.Post("/api/photo/{UUID}/view", coreHandler.RecordView)
+	router.Post("/api/photo/{UUID}/share", coreHandler.CreatePhotoShareLink)
+	router.Get("/api/photo/{UUID}/comments", coreHandler.GetPhotoComments)
+	router.Post("/api/photo/{UUID}/comments", coreHandler.CreatePhotoComment)
+	router.Put("/api/photo/{UUID}/comments/{commentId}", coreHandler.UpdatePhotoComment)
+	router.Delete("/api/photo/{UUID}/comments/{commentId}", coreHandler.DeletePhotoComment)
+	router.Get("/api/photo/{UUID}/comments/{commentId}/history", coreHandler.GetPhotoCommentHistory)
+
+	// Ссылки для доступа к фото и поездкам по токену
+	router.Get("/api/share/{token}", coreHandler.GetSharedContent)
+	router.Get("/api/share-links", coreHandler.GetShareLinks)
+	router.Delete("/api/share-links/{UUID}", coreHandler.RevokeShareLink)
+
+	// Ленты
+	router.Get("/api/feed/trending", coreHandler.GetTrendingFeed)
+	router.Get("/api/feed/home", coreHandler.GetHomeFeed)
+
+	// Поток обновлений: новые фото, лайки и уведомления
+	router.Get("/api/stream", coreHandler.Stream)
+	router.Get("/api/stream/ws", coreHandler.StreamWebSocket)
+	router.Post("/api/stream/token", coreHandler.CreateStreamToken)
+
+	// Уведомления пользователя из заголовка User-Id
+	router.Get("/api/notifications", coreHandler.GetNotifications)
+	router.Post("/api/notifications/read-all", coreHandler.MarkAllNotificationsRead)
+	router.Post("/api/notifications/{UUID}/read", coreHandler.MarkNotificationRead)
+	router.Get("/api/notifications/preferences", coreHandler.GetNotificationPreferences)
+	router.Put("/api/notifications/preferences", coreHandler.SetNotificationPreferences)
+
+	// Модерация фото; доступна пользователям с ролью модератора
+	router.Get("/api/moderation/queue", coreHandler.GetModerationQueue)
+	router.Post("/api/moderation/photos/{UUID}/approve", coreHandler.ApprovePhoto)
+	router.Post("/api/moderation/photos/{UUID}/reject", coreHandler.RejectPhoto)
+	router.Post("/api/moderation/photos/{UUID}/hide", coreHandler.HidePhoto)
+	router.Get("/api/moderation/regions/{UUID}/trusted-users", coreHandler.GetTrustedUsers)
+	router.Put("/api/moderation/regions/{UUID}/trusted-users/{userId}", coreHandler.TrustUser)
+	router.Delete("/api/moderation/regions/{UUID}/trusted-users/{userId}", coreHandler.DistrustUser)
+	router.Get("/api/moderation/reports", coreHandler.GetReportSummaries)
+	router.Get("/api/moderation/reports/{type}/{UUID}", coreHandler.GetReports)
+	router.Post("/api/moderation/reports/{type}/{UUID}/resolve", coreHandler.ResolveReports)
+
+	// Жалобы на фото, комментарии, поездки и пользователей
+	router.Post("/api/report", coreHandler.CreateReport)
 
 	// Маршруты для работы с регионами
 	router.Post("/api/region", coreHandler.CreateRegion)
 	router.Delete("/api/region/{UUID}", coreHandler.DeleteRegion)
 	router.Get("/api/region/{UUID}", coreHandler.GetRegion)
+	router.Get("/api/region/by-key/{key}", coreHandler.GetRegionByKey)
+	router.Get("/api/region/{UUID}/children", coreHandler.GetRegionChildren)
 	router.Get("/api/regions", coreHandler.GetRegions)
 	router.Put("/api/region/{UUID}", coreHandler.UpdateRegion)
+	router.Put("/api/region/{UUID}/boundary", coreHandler.SetRegionBoundary)
+	router.Post("/api/region/{UUID}/follow", coreHandler.FollowRegion)
+	router.Delete("/api/region/{UUID}/follow", coreHandler.UnfollowRegion)
+
+	// Административные маршруты
+	router.Post("/api/admin/photos/assign-regions", coreHandler.ReassignPhotoRegions)
+	router.Post("/api/admin/import", coreHandler.ImportCatalog)
+	router.Get("/api/admin/integrity", coreHandler.CheckReferences)
+	router.Post("/api/admin/webhooks", coreHandler.CreateWebhook)
+	router.Get("/api/admin/webhooks", coreHandler.GetWebhooks)
+	router.Delete("/api/admin/webhooks/{UUID}", coreHandler.DeleteWebhook)
+	router.Get("/api/admin/webhooks/{UUID}/deliveries", coreHandler.GetWebhookDeliveries)
+	router.Get("/api/admin/webhooks/deliveries/{UUID}/attempts", coreHandler.GetWebhookAttempts)
+	router.Put("/api/admin/moderators/{UUID}", coreHandler.GrantModerator)
+	router.Delete("/api/admin/moderators/{UUID}", coreHandler.RevokeModerator)
+	router.Get("/api/admin/jobs", coreHandler.GetJobs)
+	router.Post("/api/admin/jobs/{UUID}/retry", coreHandler.RetryJob)
+
+	// Маршруты для работы с местами
+	router.Post("/api/place", coreHandler.CreatePlace)
+	router.Delete("/api/place/{UUID}", coreHandler.DeletePlace)
+	router.Get("/api/place/{UUID}", coreHandler.GetPlace)
+	router.Get("/api/places", coreHandler.GetPlaces)
+	router.Put("/api/place/{UUID}", coreHandler.UpdatePlace)
 
 	// Маршруты для работы с тегами
 	router.Post("/api/tag", coreHandler.CreateTag)
@@ -42,6 +112,24 @@ func RegisterRoutes(router *chi.Mux, coreHandler *CoreHandler) {
 	router.Put("/api/user/{UUID}", coreHandler.UpdateUser)
 	router.Get("/api/user/{UUID}", coreHandler.GetUser)
 	router.Get("/api/users", coreHandler.GetUsers)
+	router.Post("/api/user/{UUID}/follow", coreHandler.FollowUser)
+	router.Delete("/api/user/{UUID}/follow", coreHandler.UnfollowUser)
+	router.Get("/api/user/{UUID}/followers", coreHandler.GetFollowers)
+	router.Get("/api/user/{UUID}/following", coreHandler.GetFollowing)
+	router.Get("/api/user/{UUID}/following/regions", coreHandler.GetFollowedRegions)
+
+	// Лента календаря с поездками пользователя; адрес с токеном выдается самому пользователю
+	router.Get("/api/user/{UUID}/trips.ics", coreHandler.GetUserTripsCalendar)
+	router.Post("/api/user/{UUID}/calendar-token", coreHandler.CreateCalendarToken)
+	router.Delete("/api/user/{UUID}/calendar-token", coreHandler.RevokeCalendarToken)
+
+	// Блокировки и скрытие пользователей; действуют от имени пользователя из заголовка User-Id
+	router.Post("/api/user/{UUID}/block", coreHandler.BlockUser)
+	router.Delete("/api/user/{UUID}/block", coreHandler.UnblockUser)
+	router.Post("/api/user/{UUID}/mute", coreHandler.MuteUser)
+	router.Delete("/api/user/{UUID}/mute", coreHandler.UnmuteUser)
+	router.Get("/api/blocks", coreHandler.GetBlockedUsers)
+	router.Get("/api/mutes", coreHandler.GetMutedUsers)
 
 	// Маршруты для работы с поездками
 	router.Post("/api/trip", coreHandler.CreateTrip)
@@ -49,5 +137,26 @@ func RegisterRoutes(router *chi.Mux, coreHandler *CoreHandler) {
 	router.Put("/api/trip/{UUID}", coreHandler.UpdateTrip)
 	router.Get("/api/trip/{UUID}", coreHandler.GetTrip)
 	router.Get("/api/trips", coreHandler.GetTrips)
+	router.Post("/api/trip/{UUID}/share", coreHandler.CreateTripShareLink)
+	router.Get("/api/trip/{UUID}/itinerary", coreHandler.GetItinerary)
+	router.Put("/api/trip/{UUID}/itinerary", coreHandler.SetItinerary)
+	router.Get("/api/trip/{UUID}/timeline", coreHandler.GetTripTimeline)
+	router.Get("/api/trip/{UUID}/calendar.ics", coreHandler.GetTripCalendar)
+	router.Get("/api/trip/{UUID}/comments", coreHandler.GetTripComments)
+	router.Post("/api/trip/{UUID}/comments", coreHandler.CreateTripComment)
+	router.Put("/api/trip/{UUID}/comments/{commentId}", coreHandler.UpdateTripComment)
+	router.Delete("/api/trip/{UUID}/comments/{commentId}", coreHandler.DeleteTripComment)
+	router.Get("/api/trip/{UUID}/comments/{commentId}/history", coreHandler.GetTripCommentHistory)
+
+	// Альбомы; менять альбом и его фото может только владелец из заголовка User-Id
+	router.Post("/api/album", coreHandler.CreateAlbum)
+	router.Get("/api/albums", coreHandler.GetAlbums)
+	router.Get("/api/album/{UUID}", coreHandler.GetAlbum)
+	router.Put("/api/album/{UUID}", coreHandler.UpdateAlbum)
+	router.Delete("/api/album/{UUID}", coreHandler.DeleteAlbum)
+	router.Get("/api/album/{UUID}/photos", coreHandler.GetAlbumPhotos)
+	router.Post("/api/album/{UUID}/photos", coreHandler.AddAlbumPhoto)
+	router.Put("/api/album/{UUID}/photos/order", coreHandler.ReorderAlbumPhotos)
+	router.Delete("/api/album/{UUID}/photos/{photoId}", coreHandler.RemoveAlbumPhoto)
 
 }
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+type shareRequest struct {
+	// Необязательное время окончания действия ссылки в RFC 3339
+	ExpiresAt *time.Time
+}
+
+// CreatePhotoShareLink создает ссылку на фото от имени пользователя из заголовка User-Id.
+// Тело запроса необязательно: {ExpiresAt}. Токен и адрес ссылки возвращаются только в этом ответе
+func (h *CoreHandler) CreatePhotoShareLink(w http.ResponseWriter, r *http.Request) {
+	h.createShareLink(w, r, models.ShareTargetPhoto)
+}
+
+// CreateTripShareLink создает ссылку на поездку; тело и ответ как у CreatePhotoShareLink
+func (h *CoreHandler) CreateTripShareLink(w http.ResponseWriter, r *http.Request) {
+	h.createShareLink(w, r, models.ShareTargetTrip)
+}
+
+// createShareLink создает ссылку на объект типа kind с идентификатором из URL
+func (h *CoreHandler) createShareLink(w http.ResponseWriter, r *http.Request, kind string) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	targetId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid "+kind+" ID")
+		return
+	}
+	var req shareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	link, err := h.service.CreateShareLink(r.Context(), models.ShareLink{
+		TargetType: kind,
+		TargetId:   targetId,
+		CreatedBy:  userId,
+		ExpiresAt:  req.ExpiresAt,
+	})
+	if err != nil {
+		h.handleError(w, r, "failed to create share link", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+// GetSharedContent отдает фото или поездку по токену ссылки; заголовок User-Id не нужен
+func (h *CoreHandler) GetSharedContent(w http.ResponseWriter, r *http.Request) {
+	content, err := h.service.GetSharedContent(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		h.handleError(w, r, "failed to get shared content", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(content)
+}
+
+// GetShareLinks возвращает ссылки, созданные пользователем из заголовка User-Id
+func (h *CoreHandler) GetShareLinks(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	links, err := h.service.GetShareLinks(r.Context(), userId)
+	if err != nil {
+		h.handleError(w, r, "failed to get share links", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+// RevokeShareLink отзывает ссылку пользователя из заголовка User-Id
+func (h *CoreHandler) RevokeShareLink(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	linkId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid share link ID")
+		return
+	}
+
+	if err := h.service.RevokeShareLink(r.Context(), userId, linkId); err != nil {
+		h.handleError(w, r, "failed to revoke share link", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
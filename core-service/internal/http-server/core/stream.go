@@ -0,0 +1,187 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/lib/logger/sl"
+	"golang.org/x/net/websocket"
+)
+
+// Интервал, с которым в поток пишется пустое сообщение, чтобы прокси не закрывали соединение
+const streamKeepAlive = 15 * time.Second
+
+// Stream отдает события в формате Server-Sent Events.
+// Параметры: region, trip, photo (можно повторять) - на что подписаться,
+// token - токен из CreateStreamToken, если клиент не может передать заголовок User-Id (EventSource в браузере)
+func (h *CoreHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.streamFilterFromQuery(w, r)
+	if !ok {
+		return
+	}
+	events, unsubscribe, err := h.service.SubscribeStream(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, r, "failed to subscribe to stream", err)
+		return
+	}
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Отключает буферизацию ответа в nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// StreamWebSocket отдает те же события, что и Stream, через WebSocket: каждое событие -
+// отдельное JSON-сообщение. Параметры те же, что у Stream
+func (h *CoreHandler) StreamWebSocket(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.streamFilterFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	server := websocket.Server{
+		Handshake: h.checkStreamOrigin,
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			// Клиент ничего не присылает, чтение нужно только чтобы узнать о закрытии соединения
+			go func() {
+				io.Copy(io.Discard, ws)
+				cancel()
+			}()
+
+			events, unsubscribe, err := h.service.SubscribeStream(ctx, filter)
+			if err != nil {
+				h.logger.Error("failed to subscribe to stream", sl.Err(err))
+				return
+			}
+			defer unsubscribe()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case event, ok := <-events:
+					if !ok {
+						return
+					}
+					if err := websocket.JSON.Send(ws, event); err != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// CreateStreamToken выдает пользователю из заголовка User-Id короткоживущий токен для
+// подключения к потоку из браузера
+func (h *CoreHandler) CreateStreamToken(w http.ResponseWriter, r *http.Request) {
+	userId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	token, err := h.service.CreateStreamToken(r.Context(), userId)
+	if err != nil {
+		h.handleError(w, r, "failed to create stream token", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(token)
+}
+
+// checkStreamOrigin пускает WebSocket со страниц того же адреса, что и API, и с разрешенных
+// в настройках сайтов. Клиенты не из браузера не передают Origin, их тоже пускаем
+func (h *CoreHandler) checkStreamOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	originUrl, err := url.Parse(origin)
+	if err != nil {
+		return fmt.Errorf("invalid origin %q: %w", origin, err)
+	}
+	if originUrl.Host != r.Host && !h.service.IsStreamOriginAllowed(origin) {
+		return fmt.Errorf("origin %q is not allowed", origin)
+	}
+	config.Origin = originUrl
+	return nil
+}
+
+func (h *CoreHandler) streamFilterFromQuery(w http.ResponseWriter, r *http.Request) (models.StreamFilter, bool) {
+	filter := models.StreamFilter{UserId: viewerId(r)}
+	if token := r.URL.Query().Get("token"); filter.UserId == uuid.Nil && token != "" {
+		userId, err := h.service.StreamTokenUser(token)
+		if err != nil {
+			h.handleError(w, r, "failed to check stream token", err)
+			return filter, false
+		}
+		filter.UserId = userId
+	}
+
+	for _, param := range []struct {
+		name string
+		dest *[]uuid.UUID
+	}{
+		{"region", &filter.Regions},
+		{"trip", &filter.Trips},
+		{"photo", &filter.Photos},
+	} {
+		for _, value := range r.URL.Query()[param.name] {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				writeProblem(w, r, http.StatusBadRequest, "invalid "+param.name+" ID")
+				return filter, false
+			}
+			*param.dest = append(*param.dest, id)
+		}
+	}
+	return filter, true
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+// CreateWebhook регистрирует вебхук партнера: {Url, EventTypes, Secret}.
+// Secret необязателен и возвращается только в этом ответе. Доступно только модераторам
+func (h *CoreHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	callerId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	var data models.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	created, err := h.service.CreateWebhook(r.Context(), callerId, data)
+	if err != nil {
+		h.handleError(w, r, "failed to create webhook", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (h *CoreHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	callerId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	webhooks, err := h.service.GetWebhooks(r.Context(), callerId)
+	if err != nil {
+		h.handleError(w, r, "failed to get webhooks", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+func (h *CoreHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	callerId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	webhookId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid webhook ID")
+		return
+	}
+
+	if err := h.service.DeleteWebhook(r.Context(), callerId, webhookId); err != nil {
+		h.handleError(w, r, "failed to delete webhook", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries возвращает журнал доставок вебхука
+func (h *CoreHandler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	callerId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	webhookId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid webhook ID")
+		return
+	}
+
+	deliveries, err := h.service.GetWebhookDeliveries(r.Context(), callerId, webhookId)
+	if err != nil {
+		h.handleError(w, r, "failed to get webhook deliveries", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// GetWebhookAttempts возвращает попытки одной доставки с ответами получателя
+func (h *CoreHandler) GetWebhookAttempts(w http.ResponseWriter, r *http.Request) {
+	callerId, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	deliveryId, err := uuid.Parse(chi.URLParam(r, "UUID"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid delivery ID")
+		return
+	}
+
+	attempts, err := h.service.GetWebhookAttempts(r.Context(), callerId, deliveryId)
+	if err != nil {
+		h.handleError(w, r, "failed to get webhook attempts", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(attempts)
+}
@@ -0,0 +1,228 @@
+// Package jobs - очередь фоновых задач поверх PostgreSQL. Задачи ставятся в очередь
+// через Enqueue (можно внутри транзакции вместе с изменением), а Runner разбирает их
+// пулом воркеров, повторяет неудачные с экспоненциальной паузой и переводит
+// исчерпавшие попытки в состояние dead
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/lib/backoff"
+	"github.com/shameoff/more-than-trip/core/internal/lib/logger/sl"
+)
+
+// Enqueuer - хранилище, в которое можно поставить задачу. Им может быть и хранилище,
+// работающее внутри транзакции
+type Enqueuer interface {
+	EnqueueJob(ctx context.Context, job models.Job) error
+}
+
+// Store - хранилище очереди
+type Store interface {
+	Enqueuer
+	// ClaimJobs забирает до limit готовых задач указанных типов и блокирует их на lease
+	ClaimJobs(ctx context.Context, kinds []string, limit int, lease time.Duration) ([]models.Job, error)
+	CompleteJob(ctx context.Context, jobId uuid.UUID) error
+	RetryJob(ctx context.Context, jobId uuid.UUID, runAt time.Time, lastError string) error
+	BuryJob(ctx context.Context, jobId uuid.UUID, lastError string) error
+}
+
+// Config - настройки воркеров
+type Config struct {
+	// Число задач, выполняемых одновременно
+	Workers int
+	// Как часто свободный воркер проверяет очередь
+	PollInterval time.Duration
+	// Сколько задача может выполняться; после этого ее может забрать другой воркер
+	Timeout time.Duration
+	// Пауза перед повтором растет вдвое с каждой попыткой, начиная с RetryBase, но не больше RetryMax
+	RetryBase time.Duration
+	RetryMax  time.Duration
+}
+
+// permanentError - ошибка, после которой повторять задачу бессмысленно
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку обработчика как неисправимую: задача сразу уходит в dead
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+type handlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+// Runner выполняет задачи зарегистрированных типов
+type Runner struct {
+	store    Store
+	log      *slog.Logger
+	cfg      Config
+	handlers map[string]handlerFunc
+
+	// stop закрывается при остановке: воркеры перестают брать новые задачи
+	stop chan struct{}
+	// cancel прерывает выполняющиеся задачи, если они не успели завершиться при остановке
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+func NewRunner(store Store, log *slog.Logger, cfg Config) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		store:    store,
+		log:      log.With(slog.String("component", "jobs")),
+		cfg:      cfg,
+		handlers: make(map[string]handlerFunc),
+		stop:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Handle регистрирует обработчик задач типа kind. Параметры задачи разбираются из JSON в T;
+// если это не удалось, задача сразу уходит в dead. Регистрировать обработчики нужно до Start
+func Handle[T any](r *Runner, kind string, handle func(ctx context.Context, payload T) error) {
+	r.handlers[kind] = func(ctx context.Context, raw json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return Permanent(fmt.Errorf("failed to decode %s payload: %w", kind, err))
+		}
+		return handle(ctx, payload)
+	}
+}
+
+// Enqueue ставит задачу типа kind в очередь через q. maxAttempts == 0 означает
+// значение по умолчанию из схемы БД (5 попыток)
+func Enqueue(ctx context.Context, q Enqueuer, kind string, payload any, maxAttempts int) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %w", kind, err)
+	}
+	return q.EnqueueJob(ctx, models.Job{
+		Id:          uuid.New(),
+		Kind:        kind,
+		Payload:     data,
+		MaxAttempts: maxAttempts,
+	})
+}
+
+// Start запускает воркеры. Они работают, пока не вызван Stop
+func (r *Runner) Start() {
+	kinds := make([]string, 0, len(r.handlers))
+	for kind := range r.handlers {
+		kinds = append(kinds, kind)
+	}
+	if len(kinds) == 0 {
+		return
+	}
+
+	for range r.cfg.Workers {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.work(kinds)
+		}()
+	}
+	r.log.Info("job workers started", slog.Int("workers", r.cfg.Workers), slog.Any("kinds", kinds))
+}
+
+// Stop перестает брать новые задачи и ждет завершения выполняющихся. Если ctx
+// истекает раньше, выполняющиеся задачи прерываются и будут повторены позже
+func (r *Runner) Stop(ctx context.Context) error {
+	r.once.Do(func() { close(r.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		r.cancel()
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		<-done
+		return fmt.Errorf("job workers did not finish in time: %w", ctx.Err())
+	}
+}
+
+func (r *Runner) work(kinds []string) {
+	for {
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+
+		claimed, err := r.store.ClaimJobs(r.ctx, kinds, 1, r.cfg.Timeout)
+		if err != nil {
+			r.log.Error("failed to claim job", sl.Err(err))
+		}
+		if len(claimed) == 0 {
+			select {
+			case <-r.stop:
+				return
+			case <-time.After(r.cfg.PollInterval):
+			}
+			continue
+		}
+		r.run(claimed[0])
+	}
+}
+
+func (r *Runner) run(job models.Job) {
+	log := r.log.With(slog.String("job_id", job.Id.String()), slog.String("kind", job.Kind), slog.Int("attempt", job.Attempts))
+
+	ctx, cancel := context.WithTimeout(r.ctx, r.cfg.Timeout)
+	err := r.call(ctx, job)
+	cancel()
+
+	// Результат записывается и после прерывания, иначе задача останется заблокированной до конца Timeout
+	saveCtx := context.WithoutCancel(r.ctx)
+	var saveErr error
+	var permanent permanentError
+	switch {
+	case err == nil:
+		saveErr = r.store.CompleteJob(saveCtx, job.Id)
+	case errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts:
+		log.Error("job failed permanently", sl.Err(err))
+		saveErr = r.store.BuryJob(saveCtx, job.Id, err.Error())
+	default:
+		delay := backoff.Exponential(r.cfg.RetryBase, r.cfg.RetryMax, job.Attempts)
+		log.Warn("job failed, will retry", slog.Duration("retry_in", delay), sl.Err(err))
+		saveErr = r.store.RetryJob(saveCtx, job.Id, time.Now().Add(delay), err.Error())
+	}
+	if saveErr != nil {
+		log.Error("failed to save job result", sl.Err(saveErr))
+	}
+}
+
+// call выполняет обработчик, превращая панику в ошибку, чтобы она не уронила воркер
+func (r *Runner) call(ctx context.Context, job models.Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job handler panicked: %v", p)
+		}
+	}()
+
+	handle, ok := r.handlers[job.Kind]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for job kind %q", job.Kind))
+	}
+	return handle(ctx, job.Payload)
+}
@@ -0,0 +1,356 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+// memStore - очередь в памяти, которая ведет себя как job в PostgreSQL: ClaimJobs
+// переводит задачу в running и увеличивает счетчик попыток
+type memStore struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*models.Job
+}
+
+func newMemStore() *memStore {
+	return &memStore{jobs: make(map[uuid.UUID]*models.Job)}
+}
+
+func (s *memStore) EnqueueJob(_ context.Context, job models.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job.MaxAttempts == 0 {
+		job.MaxAttempts = 5
+	}
+	job.Status = models.JobQueued
+	job.RunAt = time.Now()
+	s.jobs[job.Id] = &job
+	return nil
+}
+
+func (s *memStore) ClaimJobs(_ context.Context, kinds []string, limit int, _ time.Duration) ([]models.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var claimed []models.Job
+	for _, job := range s.jobs {
+		if len(claimed) == limit {
+			break
+		}
+		if job.Status != models.JobQueued || job.RunAt.After(time.Now()) || !contains(kinds, job.Kind) {
+			continue
+		}
+		job.Status = models.JobRunning
+		job.Attempts++
+		claimed = append(claimed, *job)
+	}
+	return claimed, nil
+}
+
+func (s *memStore) CompleteJob(_ context.Context, jobId uuid.UUID) error {
+	return s.update(jobId, func(job *models.Job) { job.Status = models.JobDone })
+}
+
+func (s *memStore) RetryJob(_ context.Context, jobId uuid.UUID, runAt time.Time, lastError string) error {
+	return s.update(jobId, func(job *models.Job) {
+		job.Status, job.RunAt, job.LastError = models.JobQueued, runAt, lastError
+	})
+}
+
+func (s *memStore) BuryJob(_ context.Context, jobId uuid.UUID, lastError string) error {
+	return s.update(jobId, func(job *models.Job) {
+		job.Status, job.LastError = models.JobDead, lastError
+	})
+}
+
+func (s *memStore) update(jobId uuid.UUID, fn func(job *models.Job)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[jobId]
+	if !ok {
+		return errors.New("job not found")
+	}
+	fn(job)
+	return nil
+}
+
+func (s *memStore) get(jobId uuid.UUID) models.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.jobs[jobId]
+}
+
+func (s *memStore) only(t *testing.T) models.Job {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.jobs) != 1 {
+		t.Fatalf("got %d jobs, want 1", len(s.jobs))
+	}
+	for _, job := range s.jobs {
+		return *job
+	}
+	return models.Job{}
+}
+
+func contains(kinds []string, kind string) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+var testConfig = Config{
+	Workers:      2,
+	PollInterval: 5 * time.Millisecond,
+	Timeout:      time.Second,
+	RetryBase:    time.Minute,
+	RetryMax:     time.Hour,
+}
+
+func newTestRunner(store Store) *Runner {
+	return NewRunner(store, slog.New(slog.NewTextHandler(io.Discard, nil)), testConfig)
+}
+
+type testPayload struct {
+	Value string
+}
+
+// claim ставит задачу в очередь и забирает ее, как это делает воркер, attempts раз подряд
+func claim(t *testing.T, store *memStore, kind string, payload string, maxAttempts int, attempts int) models.Job {
+	t.Helper()
+	ctx := context.Background()
+	if err := store.EnqueueJob(ctx, models.Job{Id: uuid.New(), Kind: kind, Payload: json.RawMessage(payload), MaxAttempts: maxAttempts}); err != nil {
+		t.Fatalf("EnqueueJob: %v", err)
+	}
+	var job models.Job
+	for i := 0; i < attempts; i++ {
+		claimed, err := store.ClaimJobs(ctx, []string{kind}, 1, time.Second)
+		if err != nil || len(claimed) != 1 {
+			t.Fatalf("ClaimJobs = %v, %v", claimed, err)
+		}
+		job = claimed[0]
+		if i < attempts-1 {
+			store.RetryJob(ctx, job.Id, time.Now(), "")
+		}
+	}
+	return job
+}
+
+func TestRunOutcome(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name        string
+		handle      func(ctx context.Context, payload testPayload) error
+		kind        string
+		payload     string
+		maxAttempts int
+		attempts    int
+		wantStatus  string
+		wantError   string
+	}{
+		{
+			name:        "success",
+			handle:      func(ctx context.Context, payload testPayload) error { return nil },
+			payload:     `{"Value":"ok"}`,
+			maxAttempts: 3,
+			attempts:    1,
+			wantStatus:  models.JobDone,
+		},
+		{
+			name:        "error is retried",
+			handle:      func(ctx context.Context, payload testPayload) error { return errBoom },
+			payload:     `{}`,
+			maxAttempts: 3,
+			attempts:    1,
+			wantStatus:  models.JobQueued,
+			wantError:   "boom",
+		},
+		{
+			name:        "last attempt is buried",
+			handle:      func(ctx context.Context, payload testPayload) error { return errBoom },
+			payload:     `{}`,
+			maxAttempts: 3,
+			attempts:    3,
+			wantStatus:  models.JobDead,
+			wantError:   "boom",
+		},
+		{
+			name:        "permanent error is buried on first attempt",
+			handle:      func(ctx context.Context, payload testPayload) error { return Permanent(errBoom) },
+			payload:     `{}`,
+			maxAttempts: 3,
+			attempts:    1,
+			wantStatus:  models.JobDead,
+			wantError:   "boom",
+		},
+		{
+			name:        "invalid payload is buried",
+			handle:      func(ctx context.Context, payload testPayload) error { return nil },
+			payload:     `{"Value":1}`,
+			maxAttempts: 3,
+			attempts:    1,
+			wantStatus:  models.JobDead,
+			wantError:   "failed to decode test payload",
+		},
+		{
+			name:        "unknown kind is buried",
+			handle:      func(ctx context.Context, payload testPayload) error { return nil },
+			kind:        "other",
+			payload:     `{}`,
+			maxAttempts: 3,
+			attempts:    1,
+			wantStatus:  models.JobDead,
+			wantError:   `no handler for job kind "other"`,
+		},
+		{
+			name:        "panic is retried",
+			handle:      func(ctx context.Context, payload testPayload) error { panic("nil map") },
+			payload:     `{}`,
+			maxAttempts: 3,
+			attempts:    1,
+			wantStatus:  models.JobQueued,
+			wantError:   "job handler panicked: nil map",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemStore()
+			runner := newTestRunner(store)
+			Handle(runner, "test", tt.handle)
+
+			kind := tt.kind
+			if kind == "" {
+				kind = "test"
+			}
+			job := claim(t, store, kind, tt.payload, tt.maxAttempts, tt.attempts)
+			runner.run(job)
+
+			got := store.get(job.Id)
+			if got.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", got.Status, tt.wantStatus)
+			}
+			if !strings.Contains(got.LastError, tt.wantError) {
+				t.Errorf("last error = %q, want %q", got.LastError, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestRunRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{10, time.Hour},
+	}
+	for _, tt := range tests {
+		store := newMemStore()
+		runner := newTestRunner(store)
+		Handle(runner, "test", func(ctx context.Context, payload testPayload) error { return errors.New("boom") })
+
+		job := claim(t, store, "test", `{}`, 20, tt.attempts)
+		before := time.Now()
+		runner.run(job)
+
+		delay := store.get(job.Id).RunAt.Sub(before)
+		if delay < tt.want || delay > tt.want+time.Second {
+			t.Errorf("attempt %d: retry in %v, want %v", tt.attempts, delay, tt.want)
+		}
+	}
+}
+
+func TestStopDrainsRunningJobs(t *testing.T) {
+	store := newMemStore()
+	runner := newTestRunner(store)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	Handle(runner, "test", func(ctx context.Context, payload testPayload) error {
+		close(started)
+		<-release
+		return nil
+	})
+	if err := Enqueue(context.Background(), store, "test", testPayload{Value: "ok"}, 0); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	runner.Start()
+	<-started
+
+	stopped := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stopped <- runner.Stop(ctx)
+	}()
+
+	select {
+	case err := <-stopped:
+		t.Fatalf("Stop returned before the job finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-stopped; err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if job := store.only(t); job.Status != models.JobDone {
+		t.Errorf("status = %q, want %q", job.Status, models.JobDone)
+	}
+
+	// После остановки новые задачи не берутся
+	if err := Enqueue(context.Background(), store, "test", testPayload{}, 0); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	time.Sleep(5 * testConfig.PollInterval)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for _, job := range store.jobs {
+		if job.Status == models.JobRunning {
+			t.Errorf("job %s was claimed after Stop", job.Id)
+		}
+	}
+}
+
+func TestStopCancelsJobsOnTimeout(t *testing.T) {
+	store := newMemStore()
+	runner := newTestRunner(store)
+	started := make(chan struct{})
+	Handle(runner, "test", func(ctx context.Context, payload testPayload) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err := Enqueue(context.Background(), store, "test", testPayload{}, 0); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	runner.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := runner.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop error = %v, want deadline exceeded", err)
+	}
+
+	// Прерванная задача возвращается в очередь и будет повторена после перезапуска
+	job := store.only(t)
+	if job.Status != models.JobQueued || !strings.Contains(job.LastError, context.Canceled.Error()) {
+		t.Errorf("job = %s / %q, want queued with %q", job.Status, job.LastError, context.Canceled)
+	}
+}
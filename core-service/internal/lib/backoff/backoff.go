@@ -0,0 +1,14 @@
+// Package backoff считает паузы между повторными попытками
+package backoff
+
+import "time"
+
+// Exponential возвращает паузу перед следующей попыткой после attempts неудачных:
+// base после первой, дальше пауза удваивается, но не превышает max
+func Exponential(base time.Duration, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	return min(delay, max)
+}
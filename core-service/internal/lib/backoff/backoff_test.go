@@ -0,0 +1,30 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponential(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     time.Duration
+		max      time.Duration
+		attempts int
+		want     time.Duration
+	}{
+		{"first attempt", time.Second, time.Minute, 1, time.Second},
+		{"no attempts yet", time.Second, time.Minute, 0, time.Second},
+		{"doubles", time.Second, time.Minute, 4, 8 * time.Second},
+		{"capped", time.Second, time.Minute, 10, time.Minute},
+		{"base above max", time.Hour, time.Minute, 1, time.Minute},
+		{"many attempts do not overflow", time.Second, time.Hour, 1000, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Exponential(tt.base, tt.max, tt.attempts); got != tt.want {
+				t.Errorf("Exponential(%v, %v, %d) = %v, want %v", tt.base, tt.max, tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
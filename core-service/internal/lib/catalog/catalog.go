@@ -0,0 +1,252 @@
+// Package catalog разбирает файлы массового импорта справочников (регионы, места, теги)
+// в форматах CSV и GeoJSON. Первая строка CSV - заголовок с именами колонок,
+// в GeoJSON те же имена используются как properties объектов FeatureCollection
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/lib/geo"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatGeoJSON = "geojson"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported catalog format")
+
+// Колонки CSV и properties GeoJSON:
+//
+//	regions: object_key, name, country, kind, parent_key, img_url, tag
+//	places:  object_key, name, region_key, description, latitude, longitude
+//	tags:    object_key, name
+type record map[string]string
+
+// FormatFromFileName определяет формат по расширению файла
+func FormatFromFileName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV
+	case ".geojson", ".json":
+		return FormatGeoJSON
+	}
+	return ""
+}
+
+// Parse читает справочник catalog (models.CatalogRegions, models.CatalogPlaces, models.CatalogTags)
+func Parse(catalog string, format string, r io.Reader) (models.CatalogImport, error) {
+	var result models.CatalogImport
+
+	switch format {
+	case FormatCSV:
+		records, err := readCSV(r)
+		if err != nil {
+			return result, err
+		}
+		for i, rec := range records {
+			if err := appendRecord(&result, catalog, rec, nil); err != nil {
+				return result, fmt.Errorf("line %d: %w", i+2, err)
+			}
+		}
+	case FormatGeoJSON:
+		features, err := readGeoJSON(r)
+		if err != nil {
+			return result, err
+		}
+		for i, f := range features {
+			if err := appendRecord(&result, catalog, f.properties, f.geometry); err != nil {
+				return result, fmt.Errorf("feature %d: %w", i, err)
+			}
+		}
+	default:
+		return result, ErrUnsupportedFormat
+	}
+
+	return result, nil
+}
+
+func appendRecord(result *models.CatalogImport, catalog string, rec record, geometry json.RawMessage) error {
+	objectKey := rec["object_key"]
+	if objectKey == "" {
+		return fmt.Errorf("object_key is required")
+	}
+	name := rec["name"]
+	if name == "" {
+		return fmt.Errorf("name is required for %q", objectKey)
+	}
+
+	switch catalog {
+	case models.CatalogRegions:
+		region := models.ImportRegion{
+			Region: models.Region{
+				Id:        uuid.New(),
+				ObjectKey: objectKey,
+				Name:      name,
+				Country:   rec["country"],
+				Kind:      rec["kind"],
+				ImgUrl:    rec["img_url"],
+				Tag:       rec["tag"],
+			},
+			ParentKey: rec["parent_key"],
+		}
+		if region.Kind == "" {
+			region.Kind = models.RegionKindRegion
+		}
+		if _, ok := models.RegionKindDepth[region.Kind]; !ok {
+			return fmt.Errorf("unknown region kind %q for %q", region.Kind, objectKey)
+		}
+		if region.Country == "" {
+			return fmt.Errorf("country is required for %q", objectKey)
+		}
+		if len(geometry) > 0 && string(geometry) != "null" {
+			boundary, err := parseBoundary(geometry)
+			if err != nil {
+				return fmt.Errorf("invalid boundary for %q: %w", objectKey, err)
+			}
+			region.Boundary = &boundary
+		}
+		result.Regions = append(result.Regions, region)
+
+	case models.CatalogPlaces:
+		place := models.ImportPlace{
+			Place: models.Place{
+				ObjectKey:   objectKey,
+				Name:        name,
+				Description: rec["description"],
+			},
+			RegionKey: rec["region_key"],
+		}
+		if place.RegionKey == "" {
+			return fmt.Errorf("region_key is required for %q", objectKey)
+		}
+		var err error
+		if place.Latitude, err = parseOptionalFloat(rec["latitude"]); err != nil {
+			return fmt.Errorf("invalid latitude for %q: %w", objectKey, err)
+		}
+		if place.Longitude, err = parseOptionalFloat(rec["longitude"]); err != nil {
+			return fmt.Errorf("invalid longitude for %q: %w", objectKey, err)
+		}
+		result.Places = append(result.Places, place)
+
+	case models.CatalogTags:
+		result.Tags = append(result.Tags, models.Tag{Id: uuid.New(), ObjectKey: objectKey, Name: name})
+
+	default:
+		return fmt.Errorf("unknown catalog %q", catalog)
+	}
+
+	return nil
+}
+
+func readCSV(r io.Reader) ([]record, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("csv header is missing")
+	}
+
+	header := rows[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")))
+	}
+
+	records := make([]record, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		rec := make(record, len(header))
+		for i, value := range row {
+			rec[header[i]] = strings.TrimSpace(value)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+type feature struct {
+	properties record
+	geometry   json.RawMessage
+}
+
+func readGeoJSON(r io.Reader) ([]feature, error) {
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Properties map[string]any  `json:"properties"`
+			Geometry   json.RawMessage `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("invalid geojson: %w", err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("geojson must be a FeatureCollection")
+	}
+
+	features := make([]feature, 0, len(collection.Features))
+	for _, f := range collection.Features {
+		rec := make(record, len(f.Properties))
+		for key, value := range f.Properties {
+			if value != nil {
+				rec[strings.ToLower(key)] = strings.TrimSpace(fmt.Sprint(value))
+			}
+		}
+
+		// Для мест координаты берутся из геометрии Point, если не заданы в properties
+		var point struct {
+			Type        string    `json:"type"`
+			Coordinates []float64 `json:"coordinates"`
+		}
+		if json.Unmarshal(f.Geometry, &point) == nil && point.Type == "Point" && len(point.Coordinates) >= 2 {
+			if rec["latitude"] == "" {
+				rec["latitude"] = strconv.FormatFloat(point.Coordinates[1], 'f', -1, 64)
+			}
+			if rec["longitude"] == "" {
+				rec["longitude"] = strconv.FormatFloat(point.Coordinates[0], 'f', -1, 64)
+			}
+			f.Geometry = nil
+		}
+
+		features = append(features, feature{properties: rec, geometry: f.Geometry})
+	}
+	return features, nil
+}
+
+func parseBoundary(geometry json.RawMessage) (models.RegionBoundary, error) {
+	shape, err := geo.ParseGeoJSON(geometry)
+	if err != nil {
+		return models.RegionBoundary{}, err
+	}
+	box := shape.BBox()
+	return models.RegionBoundary{
+		GeoJSON: geometry,
+		MinLat:  box.MinLat,
+		MinLon:  box.MinLon,
+		MaxLat:  box.MaxLat,
+		MaxLon:  box.MaxLon,
+	}, nil
+}
+
+func parseOptionalFloat(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
@@ -0,0 +1,196 @@
+package catalog
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+func TestFormatFromFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"regions.csv", FormatCSV},
+		{"REGIONS.CSV", FormatCSV},
+		{"places.geojson", FormatGeoJSON},
+		{"places.json", FormatGeoJSON},
+		{"regions.xlsx", ""},
+		{"regions", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatFromFileName(tt.name); got != tt.want {
+				t.Errorf("FormatFromFileName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCSVHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{"plain", "object_key,name,country,kind,parent_key\nmsk,Москва,RU,region,ru\n"},
+		{"column order and case", "Name,KIND,Object_Key,Parent_Key,Country\nМосква,region,msk,ru,RU\n"},
+		{"byte order mark and spaces", "\ufeffobject_key, name ,country, kind,parent_key\nmsk, Москва ,RU, region,ru\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Parse(models.CatalogRegions, FormatCSV, strings.NewReader(tt.csv))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if len(data.Regions) != 1 {
+				t.Fatalf("got %d regions, want 1", len(data.Regions))
+			}
+			region := data.Regions[0]
+			if region.ObjectKey != "msk" || region.Name != "Москва" || region.Country != "RU" ||
+				region.Kind != models.RegionKindRegion || region.ParentKey != "ru" {
+				t.Errorf("region = %+v", region)
+			}
+		})
+	}
+}
+
+func TestParseCSVRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		catalog string
+		csv     string
+		wantErr string
+	}{
+		{"region kind defaults to region", models.CatalogRegions, "object_key,name,country\nural,Урал,RU\n", ""},
+		{"place without coordinates", models.CatalogPlaces, "object_key,name,region_key\nkremlin,Кремль,msk\n", ""},
+		{"tag", models.CatalogTags, "object_key,name\nnature,Природа\n", ""},
+		{"missing object key", models.CatalogTags, "object_key,name\n,Природа\n", "line 2: object_key is required"},
+		{"missing name", models.CatalogTags, "object_key,name\nnature,\n", `line 2: name is required for "nature"`},
+		{"unknown region kind", models.CatalogRegions, "object_key,name,country,kind\nmsk,Москва,RU,town\n", `unknown region kind "town"`},
+		{"region without country", models.CatalogRegions, "object_key,name\nmsk,Москва\n", `country is required for "msk"`},
+		{"place without region", models.CatalogPlaces, "object_key,name\nkremlin,Кремль\n", `region_key is required for "kremlin"`},
+		{"invalid latitude", models.CatalogPlaces, "object_key,name,region_key,latitude\nkremlin,Кремль,msk,north\n", `invalid latitude for "kremlin"`},
+		{"error reports line number", models.CatalogTags, "object_key,name\nnature,Природа\nsea,\n", "line 3:"},
+		{"wrong number of fields", models.CatalogTags, "object_key,name\nnature,Природа,extra\n", "invalid csv"},
+		{"empty file", models.CatalogTags, "", "csv header is missing"},
+		{"unknown catalog", "users", "object_key,name\nivan,Иван\n", `unknown catalog "users"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.catalog, FormatCSV, strings.NewReader(tt.csv))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseCSVPlace(t *testing.T) {
+	csv := "object_key,name,region_key,description,latitude,longitude\nkremlin,Кремль,msk,Крепость,55.752,37.6175\n"
+	data, err := Parse(models.CatalogPlaces, FormatCSV, strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	place := data.Places[0]
+	if place.RegionKey != "msk" || place.Description != "Крепость" {
+		t.Errorf("place = %+v", place)
+	}
+	if place.Latitude == nil || *place.Latitude != 55.752 || place.Longitude == nil || *place.Longitude != 37.6175 {
+		t.Errorf("coordinates = %v, %v, want 55.752, 37.6175", place.Latitude, place.Longitude)
+	}
+}
+
+func TestParseGeoJSON(t *testing.T) {
+	const polygon = `{"type":"Polygon","coordinates":[[[37,55],[38,55],[38,56],[37,56],[37,55]]]}`
+
+	t.Run("region boundary", func(t *testing.T) {
+		geojson := `{"type":"FeatureCollection","features":[
+			{"type":"Feature","properties":{"object_key":"msk","name":"Москва","country":"RU","kind":"region"},"geometry":` + polygon + `}]}`
+		data, err := Parse(models.CatalogRegions, FormatGeoJSON, strings.NewReader(geojson))
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		boundary := data.Regions[0].Boundary
+		if boundary == nil {
+			t.Fatal("boundary is nil")
+		}
+		if boundary.MinLat != 55 || boundary.MaxLat != 56 || boundary.MinLon != 37 || boundary.MaxLon != 38 {
+			t.Errorf("bbox = %+v, want lat 55..56, lon 37..38", boundary)
+		}
+	})
+
+	t.Run("region without geometry", func(t *testing.T) {
+		geojson := `{"type":"FeatureCollection","features":[
+			{"type":"Feature","properties":{"object_key":"ru","name":"Россия","country":"RU","kind":"country"},"geometry":null}]}`
+		data, err := Parse(models.CatalogRegions, FormatGeoJSON, strings.NewReader(geojson))
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		if data.Regions[0].Boundary != nil {
+			t.Errorf("boundary = %+v, want nil", data.Regions[0].Boundary)
+		}
+	})
+
+	t.Run("place coordinates from point", func(t *testing.T) {
+		geojson := `{"type":"FeatureCollection","features":[
+			{"type":"Feature","properties":{"Object_Key":"kremlin","Name":"Кремль","Region_Key":"msk"},"geometry":{"type":"Point","coordinates":[37.6175,55.752]}}]}`
+		data, err := Parse(models.CatalogPlaces, FormatGeoJSON, strings.NewReader(geojson))
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		place := data.Places[0]
+		if place.Latitude == nil || *place.Latitude != 55.752 || place.Longitude == nil || *place.Longitude != 37.6175 {
+			t.Errorf("coordinates = %v, %v, want 55.752, 37.6175", place.Latitude, place.Longitude)
+		}
+	})
+
+	t.Run("properties override point", func(t *testing.T) {
+		geojson := `{"type":"FeatureCollection","features":[
+			{"type":"Feature","properties":{"object_key":"kremlin","name":"Кремль","region_key":"msk","latitude":55.7},"geometry":{"type":"Point","coordinates":[37.6175,55.752]}}]}`
+		data, err := Parse(models.CatalogPlaces, FormatGeoJSON, strings.NewReader(geojson))
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		if got := *data.Places[0].Latitude; got != 55.7 {
+			t.Errorf("latitude = %v, want 55.7", got)
+		}
+	})
+
+	errTests := []struct {
+		name    string
+		geojson string
+		wantErr string
+	}{
+		{"not a collection", `{"type":"Feature","properties":{}}`, "must be a FeatureCollection"},
+		{"invalid json", `{"type":`, "invalid geojson"},
+		{"invalid boundary", `{"type":"FeatureCollection","features":[
+			{"type":"Feature","properties":{"object_key":"msk","name":"Москва","country":"RU"},"geometry":{"type":"LineString","coordinates":[[37,55],[38,56]]}}]}`,
+			`feature 0: invalid boundary for "msk"`},
+		{"feature index", `{"type":"FeatureCollection","features":[
+			{"type":"Feature","properties":{"object_key":"ru","name":"Россия","country":"RU"}},
+			{"type":"Feature","properties":{"name":"Москва","country":"RU"}}]}`,
+			"feature 1: object_key is required"},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(models.CatalogRegions, FormatGeoJSON, strings.NewReader(tt.geojson))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseUnsupportedFormat(t *testing.T) {
+	if _, err := Parse(models.CatalogTags, "xlsx", strings.NewReader("")); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Parse error = %v, want ErrUnsupportedFormat", err)
+	}
+}
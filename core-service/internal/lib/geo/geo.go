@@ -0,0 +1,203 @@
+// Package geo содержит минимальную работу с координатами и полигонами GeoJSON,
+// достаточную для определения региона по точке без PostGIS
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrNoPolygons = errors.New("geojson contains no polygons")
+
+// Point - точка в градусах
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// Ring - замкнутый контур полигона
+type Ring []Point
+
+// Polygon - внешний контур (первый) и дырки (остальные)
+type Polygon []Ring
+
+// MultiPolygon - граница региона, может состоять из нескольких частей (острова, эксклавы)
+type MultiPolygon []Polygon
+
+// BBox - ограничивающий прямоугольник, используется для предварительной фильтрации в БД
+type BBox struct {
+	MinLat float64
+	MinLon float64
+	MaxLat float64
+	MaxLon float64
+}
+
+// ParseCoords разбирает координаты фото в формате "lat, lon"
+func ParseCoords(coords string) (Point, error) {
+	parts := strings.Split(coords, ",")
+	if len(parts) != 2 {
+		return Point{}, fmt.Errorf("invalid coords %q", coords)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid latitude in %q: %w", coords, err)
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid longitude in %q: %w", coords, err)
+	}
+	if math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+		return Point{}, fmt.Errorf("coords %q are out of range", coords)
+	}
+	return Point{Lat: lat, Lon: lon}, nil
+}
+
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSON        `json:"geometry"`
+	Geometries  []geoJSON       `json:"geometries"`
+	Features    []geoJSON       `json:"features"`
+}
+
+// ParseGeoJSON собирает все полигоны из Geometry, Feature или FeatureCollection.
+// Координаты GeoJSON идут в порядке [lon, lat]
+func ParseGeoJSON(data []byte) (MultiPolygon, error) {
+	var obj geoJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("invalid geojson: %w", err)
+	}
+
+	var result MultiPolygon
+	if err := collectPolygons(obj, &result); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, ErrNoPolygons
+	}
+	return result, nil
+}
+
+func collectPolygons(obj geoJSON, result *MultiPolygon) error {
+	switch obj.Type {
+	case "FeatureCollection":
+		for _, feature := range obj.Features {
+			if err := collectPolygons(feature, result); err != nil {
+				return err
+			}
+		}
+	case "Feature":
+		if obj.Geometry != nil {
+			return collectPolygons(*obj.Geometry, result)
+		}
+	case "GeometryCollection":
+		for _, geometry := range obj.Geometries {
+			if err := collectPolygons(geometry, result); err != nil {
+				return err
+			}
+		}
+	case "Polygon":
+		var coords [][][]float64
+		if err := json.Unmarshal(obj.Coordinates, &coords); err != nil {
+			return fmt.Errorf("invalid polygon coordinates: %w", err)
+		}
+		polygon, err := toPolygon(coords)
+		if err != nil {
+			return err
+		}
+		*result = append(*result, polygon)
+	case "MultiPolygon":
+		var coords [][][][]float64
+		if err := json.Unmarshal(obj.Coordinates, &coords); err != nil {
+			return fmt.Errorf("invalid multipolygon coordinates: %w", err)
+		}
+		for _, c := range coords {
+			polygon, err := toPolygon(c)
+			if err != nil {
+				return err
+			}
+			*result = append(*result, polygon)
+		}
+	}
+	return nil
+}
+
+func toPolygon(coords [][][]float64) (Polygon, error) {
+	polygon := make(Polygon, 0, len(coords))
+	for _, ringCoords := range coords {
+		if len(ringCoords) < 4 {
+			return nil, fmt.Errorf("polygon ring must have at least 4 positions")
+		}
+		ring := make(Ring, 0, len(ringCoords))
+		for _, position := range ringCoords {
+			if len(position) < 2 {
+				return nil, fmt.Errorf("invalid position in polygon ring")
+			}
+			ring = append(ring, Point{Lat: position[1], Lon: position[0]})
+		}
+		polygon = append(polygon, ring)
+	}
+	return polygon, nil
+}
+
+// BBox вычисляет ограничивающий прямоугольник по внешним контурам
+func (m MultiPolygon) BBox() BBox {
+	box := BBox{MinLat: math.Inf(1), MinLon: math.Inf(1), MaxLat: math.Inf(-1), MaxLon: math.Inf(-1)}
+	for _, polygon := range m {
+		if len(polygon) == 0 {
+			continue
+		}
+		for _, p := range polygon[0] {
+			box.MinLat = math.Min(box.MinLat, p.Lat)
+			box.MinLon = math.Min(box.MinLon, p.Lon)
+			box.MaxLat = math.Max(box.MaxLat, p.Lat)
+			box.MaxLon = math.Max(box.MaxLon, p.Lon)
+		}
+	}
+	return box
+}
+
+// Contains проверяет, попадает ли точка в одну из частей мультиполигона.
+// Меридианы 180 и -180 совпадают: граница, пересекающая антимеридиан, по RFC 7946
+// разрезана на части по нему, и точка на разрезе проверяется с обеих сторон
+func (m MultiPolygon) Contains(p Point) bool {
+	for _, polygon := range m {
+		if polygon.Contains(p) {
+			return true
+		}
+		if math.Abs(p.Lon) == 180 && polygon.Contains(Point{Lat: p.Lat, Lon: -p.Lon}) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains проверяет, что точка внутри внешнего контура и вне всех дырок
+func (pg Polygon) Contains(p Point) bool {
+	if len(pg) == 0 || !pg[0].contains(p) {
+		return false
+	}
+	for _, hole := range pg[1:] {
+		if hole.contains(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// contains - классический ray casting по чётности пересечений
+func (r Ring) contains(p Point) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		a, b := r[i], r[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lon < (b.Lon-a.Lon)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,147 @@
+package geo
+
+import (
+	"errors"
+	"testing"
+)
+
+const (
+	moscow = `{"type":"Polygon","coordinates":[[[37,55],[38,55],[38,56],[37,56],[37,55]]]}`
+	// Кольцо с дыркой в центре
+	ring = `{"type":"Polygon","coordinates":[
+		[[0,0],[10,0],[10,10],[0,10],[0,0]],
+		[[4,4],[6,4],[6,6],[4,6],[4,4]]]}`
+	// Граница, пересекающая антимеридиан, по RFC 7946 разрезана на две части по 180-му меридиану
+	chukotka = `{"type":"MultiPolygon","coordinates":[
+		[[[170,64],[180,64],[180,70],[170,70],[170,64]]],
+		[[[-180,64],[-170,64],[-170,70],[-180,70],[-180,64]]]]}`
+)
+
+func TestParseGeoJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		geojson  string
+		polygons int
+		wantErr  bool
+	}{
+		{"polygon", moscow, 1, false},
+		{"polygon with hole", ring, 1, false},
+		{"multipolygon", chukotka, 2, false},
+		{"feature", `{"type":"Feature","properties":{},"geometry":` + moscow + `}`, 1, false},
+		{"feature collection", `{"type":"FeatureCollection","features":[
+			{"type":"Feature","geometry":` + moscow + `},
+			{"type":"Feature","geometry":` + chukotka + `}]}`, 3, false},
+		{"geometry collection", `{"type":"GeometryCollection","geometries":[` + moscow + `,` + ring + `]}`, 2, false},
+		{"invalid json", `{"type":`, 0, true},
+		{"ring too short", `{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,0]]]}`, 0, true},
+		{"position without latitude", `{"type":"Polygon","coordinates":[[[0,0],[1],[1,1],[0,0]]]}`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shape, err := ParseGeoJSON([]byte(tt.geojson))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseGeoJSON succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseGeoJSON: %v", err)
+			}
+			if len(shape) != tt.polygons {
+				t.Errorf("got %d polygons, want %d", len(shape), tt.polygons)
+			}
+		})
+	}
+}
+
+func TestParseGeoJSONWithoutPolygons(t *testing.T) {
+	for _, geojson := range []string{
+		`{"type":"Point","coordinates":[37.6,55.7]}`,
+		`{"type":"Feature","properties":{},"geometry":null}`,
+		`{"type":"FeatureCollection","features":[]}`,
+	} {
+		if _, err := ParseGeoJSON([]byte(geojson)); !errors.Is(err, ErrNoPolygons) {
+			t.Errorf("ParseGeoJSON(%s) error = %v, want ErrNoPolygons", geojson, err)
+		}
+	}
+}
+
+func TestParseGeoJSONCoordinateOrder(t *testing.T) {
+	shape, err := ParseGeoJSON([]byte(moscow))
+	if err != nil {
+		t.Fatalf("ParseGeoJSON: %v", err)
+	}
+	box := shape.BBox()
+	want := BBox{MinLat: 55, MinLon: 37, MaxLat: 56, MaxLon: 38}
+	if box != want {
+		t.Errorf("BBox = %+v, want %+v", box, want)
+	}
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		name    string
+		geojson string
+		point   Point
+		want    bool
+	}{
+		{"inside", moscow, Point{Lat: 55.75, Lon: 37.62}, true},
+		{"outside", moscow, Point{Lat: 59.93, Lon: 30.31}, false},
+		{"swapped coordinates are outside", moscow, Point{Lat: 37.62, Lon: 55.75}, false},
+		{"inside ring", ring, Point{Lat: 2, Lon: 2}, true},
+		{"inside hole", ring, Point{Lat: 5, Lon: 5}, false},
+		{"outside ring", ring, Point{Lat: 11, Lon: 5}, false},
+		{"east of antimeridian", chukotka, Point{Lat: 66, Lon: 177}, true},
+		{"west of antimeridian", chukotka, Point{Lat: 66, Lon: -175}, true},
+		{"opposite side of the globe", chukotka, Point{Lat: 66, Lon: 0}, false},
+		{"on antimeridian", chukotka, Point{Lat: 66, Lon: 180}, true},
+		{"on antimeridian from the west", chukotka, Point{Lat: 66, Lon: -180}, true},
+		{"vertex", moscow, Point{Lat: 55, Lon: 37}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shape, err := ParseGeoJSON([]byte(tt.geojson))
+			if err != nil {
+				t.Fatalf("ParseGeoJSON: %v", err)
+			}
+			if got := shape.Contains(tt.point); got != tt.want {
+				t.Errorf("Contains(%+v) = %v, want %v", tt.point, got, tt.want)
+			}
+		})
+	}
+}
+
+// Точка на общей границе соседних регионов должна попасть ровно в один из них,
+// иначе фото либо останется без региона, либо регион будет зависеть от порядка обхода
+func TestContainsSharedEdge(t *testing.T) {
+	west, err := ParseGeoJSON([]byte(`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1],[0,0]]]}`))
+	if err != nil {
+		t.Fatalf("ParseGeoJSON: %v", err)
+	}
+	east, err := ParseGeoJSON([]byte(`{"type":"Polygon","coordinates":[[[1,0],[2,0],[2,1],[1,1],[1,0]]]}`))
+	if err != nil {
+		t.Fatalf("ParseGeoJSON: %v", err)
+	}
+	north, err := ParseGeoJSON([]byte(`{"type":"Polygon","coordinates":[[[0,1],[1,1],[1,2],[0,2],[0,1]]]}`))
+	if err != nil {
+		t.Fatalf("ParseGeoJSON: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		a, b  MultiPolygon
+		point Point
+	}{
+		{"vertical edge", west, east, Point{Lat: 0.5, Lon: 1}},
+		{"horizontal edge", west, north, Point{Lat: 1, Lon: 0.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inA, inB := tt.a.Contains(tt.point), tt.b.Contains(tt.point)
+			if inA == inB {
+				t.Errorf("point %+v: in first = %v, in second = %v, want exactly one", tt.point, inA, inB)
+			}
+		})
+	}
+}
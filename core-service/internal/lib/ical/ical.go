@@ -0,0 +1,116 @@
+// Package ical формирует календари в формате iCalendar (RFC 5545). Поддерживаются только
+// события VEVENT: на весь день или с временем начала и окончания
+package ical
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType - тип содержимого календаря для ответа HTTP
+const ContentType = "text/calendar; charset=utf-8"
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+	// Наибольшая длина строки в октетах без учета CRLF (RFC 5545, 3.1)
+	maxLineOctets = 75
+)
+
+// Event - событие календаря. Клиенты сопоставляют события по UID, поэтому он должен
+// оставаться прежним при каждой выгрузке, иначе события будут дублироваться
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	// Для события на весь день важны только даты Start и End; End не входит в событие
+	AllDay bool
+	Start  time.Time
+	// Нулевое End - событие без продолжительности
+	End time.Time
+}
+
+// Calendar - календарь с событиями
+type Calendar struct {
+	// Идентификатор программы, создавшей календарь, например "-//Example//Calendar//RU"
+	ProdId string
+	// Название календаря для клиентов, которые его показывают
+	Name   string
+	Events []Event
+}
+
+// Encode возвращает календарь в формате iCalendar. stamp - время создания выгрузки (DTSTAMP)
+func (c Calendar) Encode(stamp time.Time) []byte {
+	var b strings.Builder
+	line := func(name string, value string) {
+		writeLine(&b, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", c.ProdId)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escapeText(c.Name))
+	}
+	for _, event := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", stamp.UTC().Format(dateTimeLayout))
+		if event.AllDay {
+			line("DTSTART;VALUE=DATE", event.Start.Format(dateLayout))
+			if !event.End.IsZero() {
+				line("DTEND;VALUE=DATE", event.End.Format(dateLayout))
+			}
+		} else {
+			line("DTSTART", event.Start.UTC().Format(dateTimeLayout))
+			if !event.End.IsZero() {
+				line("DTEND", event.End.UTC().Format(dateTimeLayout))
+			}
+		}
+		line("SUMMARY", escapeText(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escapeText(event.Description))
+		}
+		if event.Location != "" {
+			line("LOCATION", escapeText(event.Location))
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return []byte(b.String())
+}
+
+// escapeText экранирует значение типа TEXT (RFC 5545, 3.3.11)
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+// writeLine записывает строку, перенося ее после каждых 75 октетов: продолжение
+// начинается с пробела (RFC 5545, 3.1). Многобайтные символы не разрываются
+func writeLine(b *strings.Builder, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// Пробел в начале продолжения тоже занимает октет
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
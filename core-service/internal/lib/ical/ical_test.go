@@ -0,0 +1,185 @@
+package ical
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var update = flag.Bool("update", false, "перезаписать эталонные файлы в testdata")
+
+var stamp = time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+
+func testCalendar() Calendar {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	return Calendar{
+		ProdId: "-//More Than Trip//Calendar//RU",
+		Name:   "Поездки; Иван, 2024",
+		Events: []Event{
+			{
+				UID:         "trip-7f1c@example.com",
+				Summary:     "Байкал",
+				Description: "Лёд, нерпы\nи омуль",
+				AllDay:      true,
+				Start:       time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC),
+				End:         time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC),
+			},
+			{
+				UID:      "trip-stop-9a2e@example.com",
+				Summary:  "Листвянка",
+				Location: `Иркутская область\Слюдянка`,
+				Start:    time.Date(2024, 2, 11, 12, 0, 0, 0, moscow),
+				End:      time.Date(2024, 2, 11, 18, 0, 0, 0, moscow),
+			},
+			{
+				UID:     "trip-stop-b3d4@example.com",
+				Summary: "Большая кругобайкальская железная дорога: тоннели, мосты и галереи вдоль южного берега",
+				Start:   time.Date(2024, 2, 12, 6, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+}
+
+func TestEncodeGolden(t *testing.T) {
+	got := testCalendar().Encode(stamp)
+
+	golden := filepath.Join("testdata", "calendar.ics")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Encode() mismatch, run go test -update to inspect\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestEncodeLinesAreFolded(t *testing.T) {
+	got := string(testCalendar().Encode(stamp))
+	if !strings.HasSuffix(got, "\r\n") {
+		t.Fatalf("calendar does not end with CRLF")
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(got, "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line has %d octets, want at most %d: %q", len(line), maxLineOctets, line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line splits a multibyte character: %q", line)
+		}
+	}
+}
+
+func TestEncodeKeepsUIDs(t *testing.T) {
+	calendar := testCalendar()
+	first := string(calendar.Encode(stamp))
+	second := string(calendar.Encode(stamp.Add(24 * time.Hour)))
+
+	if first == second {
+		t.Fatalf("DTSTAMP did not change between exports")
+	}
+	if got, want := uids(second), uids(first); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("UIDs changed between exports: %v, want %v", got, want)
+	}
+	if got := len(uids(first)); got != len(calendar.Events) {
+		t.Errorf("got %d UIDs, want %d", got, len(calendar.Events))
+	}
+}
+
+func uids(calendar string) []string {
+	var result []string
+	for _, line := range strings.Split(calendar, "\r\n") {
+		if uid, ok := strings.CutPrefix(line, "UID:"); ok {
+			result = append(result, uid)
+		}
+	}
+	return result
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Байкал", "Байкал"},
+		{"semicolon", "утро; вечер", `утро\; вечер`},
+		{"comma", "Иркутск, Листвянка", `Иркутск\, Листвянка`},
+		{"backslash", `C:\photos`, `C:\\photos`},
+		{"backslash before comma", `\,`, `\\\,`},
+		{"newline", "первый\nвторой", `первый\nвторой`},
+		{"crlf", "первый\r\nвторой", `первый\nвторой`},
+		{"carriage return", "первый\rвторой", `первый\nвторой`},
+		{"colon is not escaped", "ул. Ленина: 5", "ул. Ленина: 5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeText(tt.in); got != tt.want {
+				t.Errorf("escapeText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteLine(t *testing.T) {
+	a := func(n int) string { return strings.Repeat("a", n) }
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"short", "SUMMARY:Байкал", "SUMMARY:Байкал\r\n"},
+		{"exactly 75 octets", a(75), a(75) + "\r\n"},
+		{"76 octets", a(76), a(75) + "\r\n a\r\n"},
+		{"continuation holds 74 octets", a(75 + 74 + 1), a(75) + "\r\n " + a(74) + "\r\n a\r\n"},
+		// "ж" занимает октеты 75-76, перенос сдвигается перед ним
+		{"multibyte on first boundary", a(74) + "жa", a(74) + "\r\n жa\r\n"},
+		// На границе продолжения (74 октета после пробела) тоже
+		{"multibyte on continuation boundary", a(75) + a(73) + "жa", a(75) + "\r\n " + a(73) + "\r\n жa\r\n"},
+		{"multibyte ends at boundary", a(73) + "ж" + "a", a(73) + "ж" + "\r\n a\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			writeLine(&b, tt.in)
+			if got := b.String(); got != tt.want {
+				t.Errorf("writeLine(%q) =\n%q\nwant\n%q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteLineLongCyrillic(t *testing.T) {
+	summary := "SUMMARY:" + strings.Repeat("Путешествие по Золотому кольцу России ", 5)
+	var b strings.Builder
+	writeLine(&b, summary)
+
+	folded := strings.TrimSuffix(b.String(), "\r\n")
+	lines := strings.Split(folded, "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("line of %d octets was not folded", len(summary))
+	}
+	for i, line := range lines {
+		if len(line) > maxLineOctets {
+			t.Errorf("line %d has %d octets, want at most %d", i, len(line), maxLineOctets)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line %d splits a character: %q", i, line)
+		}
+		if i > 0 && !strings.HasPrefix(line, " ") {
+			t.Errorf("continuation line %d does not start with a space: %q", i, line)
+		}
+	}
+	// Клиент собирает строку обратно, удаляя CRLF с пробелом
+	if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != summary {
+		t.Errorf("unfolded line = %q, want %q", unfolded, summary)
+	}
+}
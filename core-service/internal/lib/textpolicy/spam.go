@@ -0,0 +1,60 @@
+package textpolicy
+
+import (
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Адреса со схемой или www и голые домены в популярных зонах, включая t.me/channel.
+// В латинских зонах домен пишется латиницей, в зоне рф - кириллицей, поэтому
+// "слово.pro" без пробела после точки ссылкой не считается
+var linkPattern = regexp.MustCompile(`(?i)(?:https?://|www\.)[^\s]+|(?:[a-z0-9][a-z0-9-]*(?:\.[a-z0-9-]+)*\.(?:ru|su|com|net|org|info|biz|io|me|xyz|online|site|shop|store|pro|club|top|ly|gg|to|cc)|[\p{Cyrillic}0-9][\p{Cyrillic}0-9-]*(?:\.[\p{Cyrillic}0-9-]+)*\.рф)(?:[/?#][^\s]*)?`)
+
+// Номера из 10-12 цифр, начинающиеся с +, 7 или 8, с пробелами, дефисами, точками и скобками
+var phonePattern = regexp.MustCompile(`(?:\+\d|\b[78])(?:[ \-().]{0,2}\d){9,11}`)
+
+type patternRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// Links - правило, находящее ссылки и доменные имена
+func Links(name string) Rule {
+	return &patternRule{name: name, pattern: linkPattern}
+}
+
+// Phones - правило, находящее телефонные номера
+func Phones(name string) Rule {
+	return &patternRule{name: name, pattern: phonePattern}
+}
+
+func (r *patternRule) Name() string {
+	return r.name
+}
+
+func (r *patternRule) Find(text string) []Match {
+	var matches []Match
+	for _, loc := range r.pattern.FindAllStringIndex(text, -1) {
+		// Совпадение должно быть отдельным словом, а не окончанием или началом другого
+		if !wordBoundary(text, loc[0], loc[1]) {
+			continue
+		}
+		matches = append(matches, Match{Start: loc[0], End: loc[1]})
+	}
+	return matches
+}
+
+func wordBoundary(text string, start int, end int) bool {
+	if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isLetterOrDigit(before) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isLetterOrDigit(after) {
+		return false
+	}
+	return true
+}
+
+func isLetterOrDigit(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,138 @@
+// Package textpolicy - проверка пользовательского текста набором правил.
+// Каждое правило находит в тексте фрагменты-нарушения, а действие, с которым правило
+// добавлено в Pipeline, определяет, что с ними делать: пропустить, замаскировать,
+// отправить текст на модерацию или отклонить
+package textpolicy
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Действия с текстом, в котором найдено нарушение, по возрастанию строгости
+const (
+	ActionAllow    = "allow"
+	ActionMask     = "mask"
+	ActionModerate = "moderate"
+	ActionReject   = "reject"
+)
+
+var severity = map[string]int{
+	ActionAllow:    0,
+	ActionMask:     1,
+	ActionModerate: 2,
+	ActionReject:   3,
+}
+
+// ValidAction сообщает, известно ли действие
+func ValidAction(action string) bool {
+	_, ok := severity[action]
+	return ok
+}
+
+// Match - найденный фрагмент, границы в байтах: text[Start:End]
+type Match struct {
+	Start int
+	End   int
+}
+
+// Rule находит в тексте нарушения
+type Rule interface {
+	Name() string
+	Find(text string) []Match
+}
+
+// Violation - нарушение, найденное правилом
+type Violation struct {
+	Rule     string
+	Action   string
+	Fragment string
+}
+
+// Result - итог проверки текста
+type Result struct {
+	// Текст, в котором замаскированы фрагменты правил с действием mask
+	Text string
+	// Самое строгое действие среди найденных нарушений; allow, если нарушений нет
+	Action     string
+	Violations []Violation
+}
+
+// Rules возвращает имена правил, нашедших нарушения с действием не слабее action
+func (r Result) Rules(action string) []string {
+	var rules []string
+	for _, violation := range r.Violations {
+		if severity[violation.Action] >= severity[action] && !slices.Contains(rules, violation.Rule) {
+			rules = append(rules, violation.Rule)
+		}
+	}
+	return rules
+}
+
+type step struct {
+	rule   Rule
+	action string
+}
+
+// Pipeline - упорядоченный набор правил с действиями
+type Pipeline struct {
+	steps []step
+}
+
+func New() *Pipeline {
+	return &Pipeline{}
+}
+
+// Add добавляет правило с действием action. Правила с действием allow не проверяются
+func (p *Pipeline) Add(rule Rule, action string) *Pipeline {
+	if action != ActionAllow {
+		p.steps = append(p.steps, step{rule: rule, action: action})
+	}
+	return p
+}
+
+// Check проверяет текст всеми правилами
+func (p *Pipeline) Check(text string) Result {
+	result := Result{Text: text, Action: ActionAllow}
+	var masked []Match
+	for _, step := range p.steps {
+		for _, match := range step.rule.Find(text) {
+			result.Violations = append(result.Violations, Violation{
+				Rule:     step.rule.Name(),
+				Action:   step.action,
+				Fragment: text[match.Start:match.End],
+			})
+			if severity[step.action] > severity[result.Action] {
+				result.Action = step.action
+			}
+			if step.action == ActionMask {
+				masked = append(masked, match)
+			}
+		}
+	}
+	if len(masked) > 0 {
+		result.Text = mask(text, masked)
+	}
+	return result
+}
+
+// mask заменяет каждый символ фрагментов на '*'
+func mask(text string, matches []Match) string {
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+
+	var b strings.Builder
+	pos := 0
+	for _, match := range matches {
+		start := max(match.Start, pos)
+		if start >= match.End {
+			continue
+		}
+		b.WriteString(text[pos:start])
+		b.WriteString(strings.Repeat("*", utf8.RuneCountInString(text[start:match.End])))
+		pos = match.End
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
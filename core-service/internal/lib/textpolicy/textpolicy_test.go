@@ -0,0 +1,188 @@
+package textpolicy
+
+import (
+	"slices"
+	"testing"
+)
+
+// fragments возвращает найденные правилом фрагменты текста
+func fragments(rule Rule, text string) []string {
+	var found []string
+	for _, match := range rule.Find(text) {
+		found = append(found, text[match.Start:match.End])
+	}
+	return found
+}
+
+func TestFoldWord(t *testing.T) {
+	tests := []struct {
+		name string
+		word string
+		want string
+	}{
+		{"lower case and yo", "ЁЖИК", "ежик"},
+		{"latin letters in cyrillic word", "xуй", "хуй"},
+		{"digits in cyrillic word", "п0ка", "пока"},
+		{"cyrillic letters in latin word", "fuсk", "fuck"},
+		{"digits and signs in latin word", "$h1t", "shit"},
+		{"at sign in latin word", "@ss", "ass"},
+		{"plain latin word", "Hello", "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := foldWord(tt.word); got != tt.want {
+				t.Errorf("foldWord(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSqueeze(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"", ""},
+		{"fuuuck", "fuck"},
+		{"ааааа", "а"},
+		{"класс", "клас"},
+		{"abab", "abab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := squeeze(tt.word); got != tt.want {
+				t.Errorf("squeeze(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWords(t *testing.T) {
+	rule := Words("test", []string{"кот*", "*ход", "*мяч*", "ass"})
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"stem matches endings", "котики и котом", []string{"котики", "котом"}},
+		{"stem does not match inside word", "скотина", nil},
+		{"suffix matches any start", "переход, вход", []string{"переход", "вход"}},
+		{"suffix does not match longer ending", "ходить", nil},
+		{"part matches inside word", "мячик и футмячный", []string{"мячик", "футмячный"}},
+		{"exact word only", "class ass", []string{"ass"}},
+		{"repeated letters", "assss котттики", []string{"assss", "котттики"}},
+		{"squeezed entry does not match a shorter word", "as", nil},
+		{"homoglyphs", "kот @ss", []string{"kот", "@ss"}},
+		{"case", "КОТ", []string{"КОТ"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fragments(rule, tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("Find(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinks(t *testing.T) {
+	rule := Links("links")
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"url with scheme", "смотри https://example.com/a?b=1 тут", []string{"https://example.com/a?b=1"}},
+		{"www", "заходи на www.example", []string{"www.example"}},
+		{"bare domain", "пиши на shop.ru", []string{"shop.ru"}},
+		{"subdomain and path", "m.example.com/page", []string{"m.example.com/page"}},
+		{"telegram channel", "канал t.me/channel", []string{"t.me/channel"}},
+		{"upper case", "EXAMPLE.COM", []string{"EXAMPLE.COM"}},
+		{"cyrillic domain", "сайт.рф", []string{"сайт.рф"}},
+		{"no space after period", "это слово.pro другое", nil},
+		{"abbreviation", "и т.д. и т.п.", nil},
+		{"version number", "версия 1.5", nil},
+		{"unknown zone", "file.txt", nil},
+		{"part of a longer word", "example.comma", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fragments(rule, tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("Find(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPhones(t *testing.T) {
+	rule := Phones("phones")
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"international with brackets", "звони +7 (912) 345-67-89", []string{"+7 (912) 345-67-89"}},
+		{"digits only", "89123456789", []string{"89123456789"}},
+		{"dashes", "8-912-345-67-89", []string{"8-912-345-67-89"}},
+		{"spaces", "8 800 555 35 35", []string{"8 800 555 35 35"}},
+		{"other country", "+375291234567", []string{"+375291234567"}},
+		{"too short", "+7 912 345", nil},
+		{"date and time", "8.12.2023 10:00", nil},
+		{"not starting with 7 or 8", "1234567890", nil},
+		{"inside a longer number", "1789123456789", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fragments(rule, tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("Find(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		matches []Match
+		want    string
+	}{
+		{"no matches", "текст", nil, "текст"},
+		{"multibyte fragment", "ну кот", []Match{{Start: 5, End: 11}}, "ну ***"},
+		{"unsorted", "ab cd ef", []Match{{Start: 6, End: 8}, {Start: 0, End: 2}}, "** cd **"},
+		{"overlapping", "abcdefgh", []Match{{Start: 0, End: 4}, {Start: 2, End: 6}}, "******gh"},
+		{"nested", "abcdefgh", []Match{{Start: 1, End: 7}, {Start: 2, End: 4}}, "a******h"},
+		{"same fragment twice", "abc", []Match{{Start: 0, End: 3}, {Start: 0, End: 3}}, "***"},
+		{"overlapping multibyte", "котлета", []Match{{Start: 0, End: 6}, {Start: 4, End: 10}}, "*****та"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mask(tt.text, tt.matches); got != tt.want {
+				t.Errorf("mask(%q, %v) = %q, want %q", tt.text, tt.matches, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipelineCheck(t *testing.T) {
+	pipeline := New().
+		Add(Words("profanity", []string{"кот*"}), ActionMask).
+		Add(Links("links"), ActionModerate).
+		Add(Phones("phones"), ActionAllow)
+
+	result := pipeline.Check("котик на example.com, звони 89123456789")
+	if want := "***** на example.com, звони 89123456789"; result.Text != want {
+		t.Errorf("Text = %q, want %q", result.Text, want)
+	}
+	if result.Action != ActionModerate {
+		t.Errorf("Action = %q, want %q", result.Action, ActionModerate)
+	}
+	if got := result.Rules(ActionModerate); !slices.Equal(got, []string{"links"}) {
+		t.Errorf("Rules(moderate) = %q, want [links]", got)
+	}
+	if got := result.Rules(ActionMask); !slices.Equal(got, []string{"profanity", "links"}) {
+		t.Errorf("Rules(mask) = %q, want [profanity links]", got)
+	}
+}
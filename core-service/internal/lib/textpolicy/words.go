@@ -0,0 +1,184 @@
+package textpolicy
+
+import (
+	"bufio"
+	_ "embed"
+	"strings"
+	"unicode"
+)
+
+//go:embed words/ru.txt
+var profanityRu string
+
+//go:embed words/en.txt
+var profanityEn string
+
+// DefaultProfanity возвращает встроенный список нецензурных слов на русском и английском
+func DefaultProfanity() []string {
+	return append(ParseList(profanityRu), ParseList(profanityEn)...)
+}
+
+// ParseList разбирает список слов: по записи в строке, пустые строки и строки с # пропускаются
+func ParseList(list string) []string {
+	var entries []string
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			entries = append(entries, line)
+		}
+	}
+	return entries
+}
+
+type wordEntry struct {
+	word   string
+	prefix bool // "основа*" - слово начинается с основы
+	suffix bool // "*конец" - слово оканчивается на конец
+}
+
+type wordsRule struct {
+	name    string
+	entries []wordEntry
+}
+
+// Words - правило по списку слов. Записи списка:
+//
+//	слово    - слово целиком
+//	основа*  - слово с любым окончанием, например "мудак*" находит "мудаки" и "мудаком"
+//	*конец   - слово с любым началом
+//	*часть*  - слово, содержащее часть
+//
+// Слова сравниваются без учета регистра и ё, латинские буквы, похожие на кириллические
+// (и наоборот), цифры вместо букв и повторы букв ("fuuuck") не мешают совпадению
+func Words(name string, list []string) Rule {
+	rule := &wordsRule{name: name}
+	for _, item := range list {
+		entry := wordEntry{
+			prefix: strings.HasSuffix(item, "*"),
+			suffix: strings.HasPrefix(item, "*"),
+		}
+		entry.word = foldWord(strings.Trim(item, "*"))
+		if entry.word != "" {
+			rule.entries = append(rule.entries, entry)
+		}
+	}
+	return rule
+}
+
+func (r *wordsRule) Name() string {
+	return r.name
+}
+
+func (r *wordsRule) Find(text string) []Match {
+	var matches []Match
+	for _, token := range tokenize(text) {
+		word := foldWord(text[token.Start:token.End])
+		squeezed := squeeze(word)
+		for _, entry := range r.entries {
+			// Сжатое слово сравнивается только если в нем были повторы букв,
+			// иначе "as" совпало бы со сжатой записью "ass"
+			if entry.match(word) || (squeezed != word && entry.squeezed().match(squeezed)) {
+				matches = append(matches, token)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+func (e wordEntry) match(word string) bool {
+	switch {
+	case e.prefix && e.suffix:
+		return strings.Contains(word, e.word)
+	case e.prefix:
+		return strings.HasPrefix(word, e.word)
+	case e.suffix:
+		return strings.HasSuffix(word, e.word)
+	}
+	return word == e.word
+}
+
+func (e wordEntry) squeezed() wordEntry {
+	e.word = squeeze(e.word)
+	return e
+}
+
+// tokenize делит текст на слова: последовательности букв, цифр и знаков, которыми заменяют буквы
+func tokenize(text string) []Match {
+	var tokens []Match
+	start := -1
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, Match{Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Match{Start: start, End: len(text)})
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '@' || r == '$'
+}
+
+// Замены для слов, написанных кириллицей и латиницей
+var (
+	toCyrillic = map[rune]rune{
+		'a': 'а', 'b': 'в', 'c': 'с', 'e': 'е', 'h': 'н', 'k': 'к', 'm': 'м', 'o': 'о',
+		'p': 'р', 't': 'т', 'x': 'х', 'y': 'у', 'u': 'и',
+		'0': 'о', '3': 'з', '4': 'ч', '6': 'б', '@': 'а',
+	}
+	toLatin = map[rune]rune{
+		'а': 'a', 'в': 'b', 'с': 'c', 'е': 'e', 'н': 'h', 'к': 'k', 'м': 'm', 'о': 'o',
+		'р': 'p', 'т': 't', 'х': 'x', 'у': 'y',
+		'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '@': 'a', '$': 's',
+	}
+)
+
+// foldWord приводит слово к нижнему регистру и к преобладающему в нем алфавиту
+func foldWord(word string) string {
+	word = strings.ReplaceAll(strings.ToLower(word), "ё", "е")
+
+	var cyrillic, latin int
+	for _, r := range word {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	replace := toLatin
+	if cyrillic > latin {
+		replace = toCyrillic
+	}
+	return strings.Map(func(r rune) rune {
+		if to, ok := replace[r]; ok {
+			return to
+		}
+		return r
+	}, word)
+}
+
+// squeeze сжимает повторы букв: "fuuuck" - "fuck"
+func squeeze(word string) string {
+	var b strings.Builder
+	var prev rune
+	for i, r := range word {
+		if i > 0 && r == prev {
+			continue
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
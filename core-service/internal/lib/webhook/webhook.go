@@ -0,0 +1,157 @@
+// Package webhook отправляет события партнерам по HTTP и подписывает их HMAC-SHA256.
+// Подпись считается от строки "<timestamp>.<тело запроса>", чтобы перехваченный
+// запрос нельзя было повторить позже с новым временем
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Заголовки запроса с событием
+const (
+	HeaderEventId   = "X-Webhook-Id"
+	HeaderEventType = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+
+	signaturePrefix = "sha256="
+	// Сколько байт ответа получателя сохраняется для журнала доставок
+	maxResponseBody = 1024
+)
+
+// ErrPrivateTarget - адрес получателя ведет во внутреннюю сеть сервиса
+var ErrPrivateTarget = errors.New("webhook target is not a public address")
+
+// Общее адресное пространство провайдеров (RFC 6598), netip не относит его к частным
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Message - событие для отправки; Body - готовое JSON-тело запроса
+type Message struct {
+	EventId   string
+	EventType string
+	Body      []byte
+}
+
+// Result - итог одной попытки доставки
+type Result struct {
+	StatusCode int
+	Response   string
+	Duration   time.Duration
+}
+
+type Client struct {
+	http *http.Client
+}
+
+func NewClient(timeout time.Duration) *Client {
+	return &Client{http: &http.Client{Timeout: timeout}}
+}
+
+// Sign возвращает значение заголовка X-Webhook-Signature
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись запроса на стороне получателя. Запросы старше maxAge отклоняются
+func Verify(secret string, header http.Header, body []byte, maxAge time.Duration) bool {
+	timestamp, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return false
+	}
+	if maxAge > 0 && time.Since(time.Unix(timestamp, 0)).Abs() > maxAge {
+		return false
+	}
+	expected := Sign(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(header.Get(HeaderSignature)))
+}
+
+// Send отправляет событие на url. Ответ не из диапазона 2xx считается ошибкой,
+// при этом Result все равно заполняется для журнала доставок
+func (c *Client) Send(ctx context.Context, url string, secret string, msg Message) (Result, error) {
+	var result Result
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(msg.Body))
+	if err != nil {
+		return result, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventId, msg.EventId)
+	req.Header.Set(HeaderEventType, msg.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, msg.Body))
+
+	started := time.Now()
+	resp, err := c.http.Do(req)
+	result.Duration = time.Since(started)
+	if err != nil {
+		return result, fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	result.StatusCode = resp.StatusCode
+	result.Response = string(response)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return result, fmt.Errorf("webhook receiver responded with status %d", resp.StatusCode)
+	}
+	return result, nil
+}
+
+// CheckTarget проверяет, что host из адреса вебхука ведет только на публичные адреса:
+// не на loopback, частные, link-local и прочие внутренние сети. Имя хоста разрешается
+// через resolver, и публичным должен быть каждый из его адресов
+func CheckTarget(ctx context.Context, resolver *net.Resolver, host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !IsPublicAddr(addr) {
+			return ErrPrivateTarget
+		}
+		return nil
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateTarget
+	}
+
+	addrs, err := resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve webhook host: %w", err)
+	}
+	for _, addr := range addrs {
+		if !IsPublicAddr(addr) {
+			return ErrPrivateTarget
+		}
+	}
+	return nil
+}
+
+// IsPublicAddr сообщает, можно ли отправлять вебхук на адрес addr
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsUnspecified() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!sharedAddressSpace.Contains(addr)
+}
@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testSecret = "partner-secret"
+
+// receiver - получатель вебхуков, который проверяет подпись так же, как это делает партнер
+type receiver struct {
+	status   int
+	verified bool
+	header   http.Header
+	body     []byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.body, _ = io.ReadAll(r.Body)
+	rc.header = r.Header.Clone()
+	rc.verified = Verify(testSecret, r.Header, rc.body, time.Minute)
+	w.WriteHeader(rc.status)
+	w.Write([]byte("ok"))
+}
+
+func TestSendSignsRequest(t *testing.T) {
+	rc := &receiver{status: http.StatusNoContent}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	msg := Message{EventId: "7f1c", EventType: "photo.created", Body: []byte(`{"Type":"photo.created"}`)}
+	result, err := NewClient(time.Second).Send(context.Background(), server.URL, testSecret, msg)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if result.StatusCode != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", result.StatusCode, http.StatusNoContent)
+	}
+	if !rc.verified {
+		t.Error("receiver could not verify signature")
+	}
+	if got := rc.header.Get(HeaderEventType); got != "photo.created" {
+		t.Errorf("%s = %q, want photo.created", HeaderEventType, got)
+	}
+	if got := rc.header.Get(HeaderEventId); got != "7f1c" {
+		t.Errorf("%s = %q, want 7f1c", HeaderEventId, got)
+	}
+	if string(rc.body) != string(msg.Body) {
+		t.Errorf("body = %s, want %s", rc.body, msg.Body)
+	}
+}
+
+func TestSendFailsOnErrorStatus(t *testing.T) {
+	rc := &receiver{status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	result, err := NewClient(time.Second).Send(context.Background(), server.URL, testSecret, Message{Body: []byte(`{}`)})
+	if err == nil {
+		t.Fatal("expected error for 503 response")
+	}
+	if result.StatusCode != http.StatusServiceUnavailable || result.Response != "ok" {
+		t.Errorf("result = %+v, want status 503 and response body", result)
+	}
+}
+
+func TestSendFailsWhenReceiverIsDown(t *testing.T) {
+	server := httptest.NewServer(&receiver{status: http.StatusOK})
+	url := server.URL
+	server.Close()
+
+	if _, err := NewClient(time.Second).Send(context.Background(), url, testSecret, Message{Body: []byte(`{}`)}); err == nil {
+		t.Fatal("expected error for closed receiver")
+	}
+}
+
+func TestVerifyRejectsTamperedRequest(t *testing.T) {
+	body := []byte(`{"Type":"trip.updated"}`)
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		valid     bool
+	}{
+		{"valid", testSecret, now, body, true},
+		{"wrong secret", "other-secret", now, body, false},
+		{"changed body", testSecret, now, []byte(`{"Type":"trip.deleted"}`), false},
+		{"stale timestamp", testSecret, now - 3600, body, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set(HeaderTimestamp, strconv.FormatInt(tt.timestamp, 10))
+			header.Set(HeaderSignature, Sign(tt.secret, tt.timestamp, tt.body))
+			if got := Verify(testSecret, header, body, time.Minute); got != tt.valid {
+				t.Errorf("Verify = %v, want %v", got, tt.valid)
+			}
+		})
+	}
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"::ffff:127.0.0.1", false},
+		{"224.0.0.1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := IsPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.public {
+				t.Errorf("IsPublicAddr(%s) = %v, want %v", tt.addr, got, tt.public)
+			}
+		})
+	}
+}
+
+func TestCheckTargetRejectsInternalHosts(t *testing.T) {
+	for _, host := range []string{"127.0.0.1", "::1", "169.254.169.254", "localhost", "api.localhost", "LOCALHOST."} {
+		t.Run(host, func(t *testing.T) {
+			if err := CheckTarget(context.Background(), net.DefaultResolver, host); !errors.Is(err, ErrPrivateTarget) {
+				t.Errorf("CheckTarget(%q) = %v, want ErrPrivateTarget", host, err)
+			}
+		})
+	}
+	if err := CheckTarget(context.Background(), net.DefaultResolver, "93.184.216.34"); err != nil {
+		t.Errorf("CheckTarget(public ip) = %v, want nil", err)
+	}
+}
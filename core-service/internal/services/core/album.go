@@ -0,0 +1,194 @@
+// Бизнес-логика альбомов: подборок фото из разных поездок и от разных авторов
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// Наибольшее число фото в альбоме
+const maxAlbumPhotos = 1000
+
+// CreateAlbum создает альбом пользователя album.UserId. По умолчанию альбом виден всем
+func (c *CoreService) CreateAlbum(ctx context.Context, album models.Album) (models.Album, error) {
+	if album.Visibility == "" {
+		album.Visibility = models.VisibilityPublic
+	}
+	if err := c.checkAlbum(&album); err != nil {
+		return models.Album{}, err
+	}
+	album.Id = uuid.New()
+	if err := c.storage.CreateAlbum(ctx, album); err != nil {
+		return models.Album{}, fmt.Errorf("failed to create album: %w", err)
+	}
+	return c.storage.GetAlbum(ctx, album.Id, album.UserId)
+}
+
+// GetAlbum возвращает альбом, если он виден пользователю viewerId
+func (c *CoreService) GetAlbum(ctx context.Context, albumId uuid.UUID, viewerId uuid.UUID) (models.Album, error) {
+	return c.storage.GetAlbum(ctx, albumId, viewerId)
+}
+
+// GetAlbums возвращает альбомы пользователя userId, видимые viewerId
+func (c *CoreService) GetAlbums(ctx context.Context, userId uuid.UUID, viewerId uuid.UUID) ([]models.Album, error) {
+	return c.storage.GetAlbums(ctx, userId, viewerId)
+}
+
+// UpdateAlbum меняет название, описание, видимость и обложку альбома. Пустая видимость
+// оставляет текущую, обложкой можно выбрать только фото из альбома
+func (c *CoreService) UpdateAlbum(ctx context.Context, albumId uuid.UUID, userId uuid.UUID, data models.Album) (models.Album, error) {
+	err := c.storage.WithTx(ctx, func(ctx context.Context) error {
+		album, err := c.authorizeAlbum(ctx, albumId, userId)
+		if err != nil {
+			return err
+		}
+		if data.Visibility == "" {
+			data.Visibility = album.Visibility
+		}
+		if err := c.checkAlbum(&data); err != nil {
+			return err
+		}
+		if data.CoverPhotoId.Valid {
+			photoIds, err := c.storage.GetAlbumPhotoIds(ctx, albumId)
+			if err != nil {
+				return err
+			}
+			if !slices.Contains(photoIds, data.CoverPhotoId.UUID) {
+				return fmt.Errorf("%w: cover photo must be in the album", storage.ErrValidation)
+			}
+		}
+		return c.storage.UpdateAlbum(ctx, albumId, data)
+	})
+	if err != nil {
+		return models.Album{}, fmt.Errorf("failed to update album: %w", err)
+	}
+	return c.storage.GetAlbum(ctx, albumId, userId)
+}
+
+func (c *CoreService) DeleteAlbum(ctx context.Context, albumId uuid.UUID, userId uuid.UUID) error {
+	return c.storage.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := c.authorizeAlbum(ctx, albumId, userId); err != nil {
+			return err
+		}
+		return c.storage.DeleteAlbum(ctx, albumId)
+	})
+}
+
+// GetAlbumPhotos возвращает фото альбома в порядке альбома. Фото, которые не видны
+// пользователю viewerId, пропускаются, даже если сам альбом ему виден
+func (c *CoreService) GetAlbumPhotos(ctx context.Context, albumId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error) {
+	if _, err := c.storage.GetAlbum(ctx, albumId, viewerId); err != nil {
+		return nil, err
+	}
+	return c.storage.GetAlbumPhotos(ctx, albumId, viewerId)
+}
+
+// AddAlbumPhoto добавляет фото в конец альбома. Добавить можно любое фото, которое видно владельцу альбома
+func (c *CoreService) AddAlbumPhoto(ctx context.Context, albumId uuid.UUID, userId uuid.UUID, photoId uuid.UUID) error {
+	if _, err := c.GetPhoto(ctx, photoId, userId); err != nil {
+		return err
+	}
+	return c.storage.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := c.authorizeAlbum(ctx, albumId, userId); err != nil {
+			return err
+		}
+		photoIds, err := c.storage.GetAlbumPhotoIds(ctx, albumId)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(photoIds, photoId) {
+			return fmt.Errorf("%w: photo is already in the album", storage.ErrConflict)
+		}
+		if len(photoIds) >= maxAlbumPhotos {
+			return fmt.Errorf("%w: album can't hold more than %d photos", storage.ErrValidation, maxAlbumPhotos)
+		}
+		return c.storage.AddAlbumPhoto(ctx, albumId, photoId)
+	})
+}
+
+func (c *CoreService) RemoveAlbumPhoto(ctx context.Context, albumId uuid.UUID, userId uuid.UUID, photoId uuid.UUID) error {
+	return c.storage.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := c.authorizeAlbum(ctx, albumId, userId); err != nil {
+			return err
+		}
+		return c.storage.RemoveAlbumPhoto(ctx, albumId, photoId)
+	})
+}
+
+// ReorderAlbumPhotos расставляет фото альбома в порядке photoIds. photoIds должен содержать
+// все фото альбома ровно по одному разу, иначе порядок не меняется
+func (c *CoreService) ReorderAlbumPhotos(ctx context.Context, albumId uuid.UUID, userId uuid.UUID, photoIds []uuid.UUID) error {
+	return c.storage.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := c.authorizeAlbum(ctx, albumId, userId); err != nil {
+			return err
+		}
+		current, err := c.storage.GetAlbumPhotoIds(ctx, albumId)
+		if err != nil {
+			return err
+		}
+		if !samePhotoSet(current, photoIds) {
+			return fmt.Errorf("%w: order must list every album photo exactly once", storage.ErrValidation)
+		}
+		return c.storage.ReorderAlbumPhotos(ctx, albumId, photoIds)
+	})
+}
+
+// authorizeAlbum возвращает альбом, если его владелец - userId
+func (c *CoreService) authorizeAlbum(ctx context.Context, albumId uuid.UUID, userId uuid.UUID) (models.Album, error) {
+	album, err := c.storage.GetAlbum(ctx, albumId, userId)
+	if err != nil {
+		return models.Album{}, err
+	}
+	if album.UserId != userId {
+		return models.Album{}, fmt.Errorf("%w: only the owner can change the album", storage.ErrForbidden)
+	}
+	return album, nil
+}
+
+// checkAlbum проверяет видимость, название и описание альбома. Альбомы не проходят модерацию,
+// поэтому текст, который политика отправила бы модератору, отклоняется
+func (c *CoreService) checkAlbum(album *models.Album) error {
+	if !slices.Contains(models.AlbumVisibilities, album.Visibility) {
+		return fmt.Errorf("%w: unknown album visibility %q, expected one of %s", storage.ErrValidation, album.Visibility,
+			strings.Join(models.AlbumVisibilities, ", "))
+	}
+	if strings.TrimSpace(album.Title) == "" {
+		return fmt.Errorf("%w: album title is required", storage.ErrValidation)
+	}
+
+	title, titleFlagged, err := c.checkText(TextAlbumTitle, album.Title)
+	if err != nil {
+		return err
+	}
+	description, descriptionFlagged, err := c.checkText(TextAlbumDescription, album.Description)
+	if err != nil {
+		return err
+	}
+	if flagged := append(titleFlagged, descriptionFlagged...); len(flagged) > 0 {
+		return fmt.Errorf("%w: album text is not allowed: %s", storage.ErrValidation, strings.Join(flagged, ", "))
+	}
+	album.Title = title
+	album.Description = description
+	return nil
+}
+
+// samePhotoSet сообщает, что order содержит ровно фото из current, каждое по одному разу
+func samePhotoSet(current []uuid.UUID, order []uuid.UUID) bool {
+	if len(current) != len(order) {
+		return false
+	}
+	seen := make(map[uuid.UUID]bool, len(order))
+	for _, photoId := range order {
+		if seen[photoId] || !slices.Contains(current, photoId) {
+			return false
+		}
+		seen[photoId] = true
+	}
+	return true
+}
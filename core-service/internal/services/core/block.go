@@ -0,0 +1,44 @@
+// Бизнес-логика блокировок и скрытия пользователей
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// BlockUser блокирует пользователя: пользователи перестают видеть контент друг друга,
+// лайкать и комментировать его, а подписки между ними удаляются
+func (c *CoreService) BlockUser(ctx context.Context, blockerId uuid.UUID, blockedId uuid.UUID) error {
+	if blockerId == blockedId {
+		return fmt.Errorf("%w: user can't block themselves", storage.ErrValidation)
+	}
+	return c.storage.BlockUser(ctx, blockerId, blockedId)
+}
+
+func (c *CoreService) UnblockUser(ctx context.Context, blockerId uuid.UUID, blockedId uuid.UUID) error {
+	return c.storage.UnblockUser(ctx, blockerId, blockedId)
+}
+
+func (c *CoreService) GetBlockedUsers(ctx context.Context, userId uuid.UUID) ([]models.UserRestriction, error) {
+	return c.storage.GetBlockedUsers(ctx, userId)
+}
+
+// MuteUser скрывает фото пользователя из лент; в остальном он остается доступен
+func (c *CoreService) MuteUser(ctx context.Context, userId uuid.UUID, mutedId uuid.UUID) error {
+	if userId == mutedId {
+		return fmt.Errorf("%w: user can't mute themselves", storage.ErrValidation)
+	}
+	return c.storage.MuteUser(ctx, userId, mutedId)
+}
+
+func (c *CoreService) UnmuteUser(ctx context.Context, userId uuid.UUID, mutedId uuid.UUID) error {
+	return c.storage.UnmuteUser(ctx, userId, mutedId)
+}
+
+func (c *CoreService) GetMutedUsers(ctx context.Context, userId uuid.UUID) ([]models.UserRestriction, error) {
+	return c.storage.GetMutedUsers(ctx, userId)
+}
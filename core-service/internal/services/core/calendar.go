@@ -0,0 +1,146 @@
+// Выгрузка дат и маршрута поездок в календарь (iCalendar)
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/lib/ical"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+const (
+	calendarProdId = "-//More Than Trip//Core//RU"
+	// Домен в UID событий. Он не зависит от адреса API, чтобы UID не менялись при переезде
+	calendarUidDomain = "more-than-trip"
+)
+
+// GetTripCalendar возвращает календарь поездки: событие на даты поездки и события остановок
+func (c *CoreService) GetTripCalendar(ctx context.Context, tripId uuid.UUID) ([]byte, error) {
+	trip, err := c.GetTripById(ctx, tripId)
+	if err != nil {
+		return nil, err
+	}
+	events, err := c.tripEvents(ctx, trip)
+	if err != nil {
+		return nil, err
+	}
+	calendar := ical.Calendar{ProdId: calendarProdId, Name: trip.Name, Events: events}
+	return calendar.Encode(time.Now()), nil
+}
+
+// GetUserTripsCalendar возвращает ленту календаря со всеми поездками пользователя.
+// Доступ к ленте дает токен, выданный CreateCalendarToken
+func (c *CoreService) GetUserTripsCalendar(ctx context.Context, userId uuid.UUID, token string) ([]byte, error) {
+	valid, err := c.storage.IsCalendarTokenValid(ctx, userId, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, fmt.Errorf("%w: invalid calendar token", storage.ErrForbidden)
+	}
+
+	user, err := c.storage.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	trips, err := c.storage.GetTripsByUserId(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user trips: %w", err)
+	}
+
+	var events []ical.Event
+	for _, trip := range trips {
+		tripEvents, err := c.tripEvents(ctx, trip)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, tripEvents...)
+	}
+	calendar := ical.Calendar{ProdId: calendarProdId, Name: "Поездки " + user.UserName, Events: events}
+	return calendar.Encode(time.Now()), nil
+}
+
+// CreateCalendarToken выдает пользователю новый токен ленты календаря; прежний перестает действовать
+func (c *CoreService) CreateCalendarToken(ctx context.Context, userId uuid.UUID) (models.CalendarFeed, error) {
+	token, err := newAccessToken()
+	if err != nil {
+		return models.CalendarFeed{}, err
+	}
+	createdAt, err := c.storage.SaveCalendarToken(ctx, userId, hashToken(token))
+	if err != nil {
+		return models.CalendarFeed{}, err
+	}
+	return models.CalendarFeed{
+		Url:       strings.TrimRight(c.share.BaseUrl, "/") + "/api/user/" + userId.String() + "/trips.ics?token=" + token,
+		Token:     token,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// RevokeCalendarToken отключает ленту календаря пользователя
+func (c *CoreService) RevokeCalendarToken(ctx context.Context, userId uuid.UUID) error {
+	return c.storage.DeleteCalendarToken(ctx, userId)
+}
+
+// tripEvents возвращает событие на даты поездки, если они заданы, и события остановок,
+// у которых есть время прибытия или отъезда
+func (c *CoreService) tripEvents(ctx context.Context, trip models.Trip) ([]ical.Event, error) {
+	stops, err := c.storage.GetTripStops(ctx, trip.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []ical.Event
+	if trip.StartDate != "" {
+		start, err := time.Parse(tripDateLayout, trip.StartDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse trip start date: %w", err)
+		}
+		end := start
+		if trip.EndDate != "" {
+			if end, err = time.Parse(tripDateLayout, trip.EndDate); err != nil {
+				return nil, fmt.Errorf("failed to parse trip end date: %w", err)
+			}
+		}
+		events = append(events, ical.Event{
+			UID:         fmt.Sprintf("trip-%s@%s", trip.Id, calendarUidDomain),
+			Summary:     trip.Name,
+			Description: trip.Description,
+			Location:    trip.Place,
+			AllDay:      true,
+			Start:       start,
+			// Дата окончания события на весь день не входит в него
+			End: end.AddDate(0, 0, 1),
+		})
+	}
+
+	for _, stop := range stops {
+		if stop.ArrivalAt == nil && stop.DepartureAt == nil {
+			continue
+		}
+		event := ical.Event{
+			UID:         fmt.Sprintf("trip-stop-%s@%s", stop.Id, calendarUidDomain),
+			Summary:     trip.Name,
+			Description: stop.Notes,
+			Location:    stop.Place,
+		}
+		if stop.Place != "" {
+			event.Summary = stop.Place + " — " + trip.Name
+		}
+		if stop.ArrivalAt != nil {
+			event.Start = *stop.ArrivalAt
+			if stop.DepartureAt != nil {
+				event.End = *stop.DepartureAt
+			}
+		} else {
+			event.Start = *stop.DepartureAt
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
@@ -0,0 +1,134 @@
+// Бизнес-логика массового импорта справочников
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/lib/catalog"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// errImportRollback откатывает транзакцию импорта в режиме dry-run или при ошибках в строках
+var errImportRollback = errors.New("catalog import rolled back")
+
+// ImportCatalogAs импортирует справочник от имени пользователя callerId; через API
+// импортировать справочники могут только модераторы
+func (c *CoreService) ImportCatalogAs(ctx context.Context, callerId uuid.UUID, catalogName string, format string, r io.Reader, apply bool) (models.ImportReport, error) {
+	if err := c.authorizeModerator(ctx, callerId); err != nil {
+		return models.ImportReport{}, err
+	}
+	return c.ImportCatalog(ctx, catalogName, format, r, apply)
+}
+
+// ImportCatalog разбирает файл справочника и применяет его одной транзакцией, если apply == true.
+// Без apply или при ошибке хотя бы в одной строке транзакция откатывается и возвращается
+// только отчет о разнице с БД (dry-run). Права не проверяются: метод вызывает команда import
+func (c *CoreService) ImportCatalog(ctx context.Context, catalogName string, format string, r io.Reader, apply bool) (models.ImportReport, error) {
+	data, err := catalog.Parse(catalogName, format, r)
+	if err != nil {
+		return models.ImportReport{}, fmt.Errorf("failed to parse %s catalog: %w: %w", catalogName, storage.ErrValidation, err)
+	}
+
+	// Родительские регионы должны быть созданы раньше дочерних
+	regions := append([]models.ImportRegion(nil), data.Regions...)
+	sort.SliceStable(regions, func(i, j int) bool {
+		return models.RegionKindDepth[regions[i].Kind] < models.RegionKindDepth[regions[j].Kind]
+	})
+
+	var report models.ImportReport
+	err = c.storage.WithTx(ctx, func(ctx context.Context) error {
+		report = models.ImportReport{DryRun: true}
+		for _, region := range regions {
+			item, err := c.importRegion(ctx, region)
+			if err != nil {
+				return err
+			}
+			report.Add(item)
+		}
+		for _, place := range data.Places {
+			action, err := c.storage.ImportPlace(ctx, place)
+			item, err := importItem(models.CatalogPlaces, place.ObjectKey, action, err)
+			if err != nil {
+				return err
+			}
+			report.Add(item)
+		}
+		for _, tag := range data.Tags {
+			action, err := c.storage.ImportTag(ctx, tag)
+			item, err := importItem(models.CatalogTags, tag.ObjectKey, action, err)
+			if err != nil {
+				return err
+			}
+			report.Add(item)
+		}
+
+		if !apply || report.Failed > 0 {
+			return errImportRollback
+		}
+		report.DryRun = false
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportRollback) {
+		return report, fmt.Errorf("failed to import %s catalog: %w", catalogName, err)
+	}
+
+	c.log.Info("catalog imported",
+		slog.String("catalog", catalogName),
+		slog.Bool("dry_run", report.DryRun),
+		slog.Int("created", report.Created),
+		slog.Int("updated", report.Updated),
+		slog.Int("unchanged", report.Unchanged),
+		slog.Int("failed", report.Failed),
+	)
+	return report, nil
+}
+
+// importRegion находит родителя по ключу и проверяет регион по тем же правилам иерархии,
+// что и при создании через API: родители из того же файла к этому моменту уже импортированы
+func (c *CoreService) importRegion(ctx context.Context, region models.ImportRegion) (models.ImportItem, error) {
+	regionId := uuid.Nil
+	existing, err := c.storage.GetRegionByKey(ctx, region.ObjectKey)
+	switch {
+	case err == nil:
+		regionId = existing.Id
+	case !errors.Is(err, storage.ErrNotFound):
+		return models.ImportItem{}, err
+	}
+
+	if region.ParentKey != "" {
+		parent, err := c.storage.GetRegionByKey(ctx, region.ParentKey)
+		if errors.Is(err, storage.ErrNotFound) {
+			err = fmt.Errorf("%w: parent region %q not found", storage.ErrValidation, region.ParentKey)
+			return importItem(models.CatalogRegions, region.ObjectKey, "", err)
+		}
+		if err != nil {
+			return models.ImportItem{}, err
+		}
+		region.ParentId = uuid.NullUUID{UUID: parent.Id, Valid: true}
+	}
+	if err := c.validateRegion(ctx, regionId, &region.Region); err != nil {
+		return importItem(models.CatalogRegions, region.ObjectKey, "", err)
+	}
+
+	action, err := c.storage.ImportRegion(ctx, region)
+	return importItem(models.CatalogRegions, region.ObjectKey, action, err)
+}
+
+// importItem строит строку отчета. Ошибки проверки и конфликты с данными в БД попадают
+// в отчет как строка с ошибкой, остальные ошибки прерывают импорт
+func importItem(catalogName string, objectKey string, action string, err error) (models.ImportItem, error) {
+	item := models.ImportItem{Catalog: catalogName, ObjectKey: objectKey, Action: action}
+	if errors.Is(err, storage.ErrValidation) || errors.Is(err, storage.ErrConflict) {
+		item.Action = models.ImportError
+		item.Error = err.Error()
+		return item, nil
+	}
+	return item, err
+}
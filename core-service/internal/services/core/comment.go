@@ -0,0 +1,253 @@
+// Бизнес-логика комментариев к фото и поездкам
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+const maxCommentLength = 2000
+
+// Упоминание - @username в начале текста или после символа, который не входит в имя
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])@([\p{L}\p{N}_.]+)`)
+
+// CreateComment добавляет комментарий от имени userId. Если задан ParentId, комментарий
+// становится ответом: отвечать можно только на комментарий верхнего уровня того же объекта
+func (c *CoreService) CreateComment(ctx context.Context, comment models.Comment) (models.Comment, error) {
+	body, flagged, err := c.normalizeCommentBody(comment.Body)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	comment.Body = body
+	comment.Id = uuid.New()
+	if err := c.checkCommentTarget(ctx, comment.Target, comment.UserId); err != nil {
+		return models.Comment{}, err
+	}
+
+	var parentAuthor uuid.UUID
+	if comment.ParentId.Valid {
+		parent, err := c.storage.GetComment(ctx, comment.ParentId.UUID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return models.Comment{}, fmt.Errorf("%w: parent comment does not exist", storage.ErrValidation)
+		}
+		if err != nil {
+			return models.Comment{}, fmt.Errorf("failed to get parent comment: %w", err)
+		}
+		if parent.Target != comment.Target {
+			return models.Comment{}, fmt.Errorf("%w: parent comment belongs to another %s", storage.ErrValidation, parent.Target.Kind)
+		}
+		if parent.ParentId.Valid {
+			return models.Comment{}, fmt.Errorf("%w: replies to replies are not allowed", storage.ErrValidation)
+		}
+		if parent.Deleted {
+			return models.Comment{}, fmt.Errorf("%w: parent comment is deleted", storage.ErrValidation)
+		}
+		parentAuthor = parent.UserId
+	}
+
+	mentions := c.resolveMentions(ctx, comment.Body, comment.UserId)
+	err = c.storage.WithTx(ctx, func(ctx context.Context) error {
+		if err := c.storage.CreateComment(ctx, comment); err != nil {
+			return err
+		}
+		if err := c.storage.SetCommentMentions(ctx, comment.Id, mentions); err != nil {
+			return err
+		}
+		if len(flagged) > 0 {
+			if err := c.holdComment(ctx, comment.Id, comment.Body, flagged); err != nil {
+				return err
+			}
+		}
+		created, err := c.storage.GetComment(ctx, comment.Id)
+		if err != nil {
+			return err
+		}
+		return c.emitEvent(ctx, models.EventCommentCreated, created)
+	})
+	if err != nil {
+		return models.Comment{}, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	created, err := c.storage.GetComment(ctx, comment.Id)
+	if err != nil {
+		return created, err
+	}
+	// Об отправленном на модерацию комментарии не уведомляют
+	if !created.Hidden {
+		c.notifyComment(ctx, created, parentAuthor)
+	}
+	return created, nil
+}
+
+// GetComments возвращает комментарии верхнего уровня к объекту с вложенными ответами
+func (c *CoreService) GetComments(ctx context.Context, target models.CommentTarget, viewerId uuid.UUID) ([]models.Comment, error) {
+	if err := c.checkCommentTarget(ctx, target, viewerId); err != nil {
+		return nil, err
+	}
+
+	comments, err := c.storage.GetComments(ctx, target, viewerId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments: %w", err)
+	}
+
+	// Комментарии отсортированы по времени, поэтому родитель всегда встречается раньше ответа
+	threads := make([]models.Comment, 0, len(comments))
+	index := make(map[uuid.UUID]int, len(comments))
+	for _, comment := range comments {
+		// Скрытый комментарий остается в ветке, чтобы не терялись ответы на него
+		if comment.Hidden {
+			comment.Body = ""
+			comment.Mentions = nil
+		}
+		if !comment.ParentId.Valid {
+			index[comment.Id] = len(threads)
+			threads = append(threads, comment)
+			continue
+		}
+		if i, ok := index[comment.ParentId.UUID]; ok {
+			threads[i].Replies = append(threads[i].Replies, comment)
+		}
+	}
+	return threads, nil
+}
+
+// UpdateComment меняет текст комментария. Править может только автор,
+// прежний текст сохраняется в истории
+func (c *CoreService) UpdateComment(ctx context.Context, target models.CommentTarget, commentId uuid.UUID, userId uuid.UUID, body string) (models.Comment, error) {
+	body, flagged, err := c.normalizeCommentBody(body)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	if _, err := c.authorizeComment(ctx, target, commentId, userId); err != nil {
+		return models.Comment{}, err
+	}
+
+	mentions := c.resolveMentions(ctx, body, userId)
+	err = c.storage.WithTx(ctx, func(ctx context.Context) error {
+		if err := c.storage.UpdateComment(ctx, commentId, body); err != nil {
+			return err
+		}
+		if len(flagged) > 0 {
+			if err := c.holdComment(ctx, commentId, body, flagged); err != nil {
+				return err
+			}
+		}
+		return c.storage.SetCommentMentions(ctx, commentId, mentions)
+	})
+	if err != nil {
+		return models.Comment{}, fmt.Errorf("failed to update comment: %w", err)
+	}
+
+	return c.storage.GetComment(ctx, commentId)
+}
+
+// DeleteComment мягко удаляет комментарий. Удалить может только автор
+func (c *CoreService) DeleteComment(ctx context.Context, target models.CommentTarget, commentId uuid.UUID, userId uuid.UUID) error {
+	if _, err := c.authorizeComment(ctx, target, commentId, userId); err != nil {
+		return err
+	}
+	if err := c.storage.DeleteComment(ctx, commentId); err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	return nil
+}
+
+// GetCommentHistory возвращает прежние версии текста комментария
+func (c *CoreService) GetCommentHistory(ctx context.Context, target models.CommentTarget, commentId uuid.UUID, viewerId uuid.UUID) ([]models.CommentRevision, error) {
+	if err := c.checkCommentTarget(ctx, target, viewerId); err != nil {
+		return nil, err
+	}
+	comment, err := c.storage.GetComment(ctx, commentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+	if comment.Target != target {
+		return nil, fmt.Errorf("comment %s: %w", commentId, storage.ErrNotFound)
+	}
+	if comment.Deleted || comment.Hidden {
+		return nil, nil
+	}
+	blocked, err := c.storage.IsBlocked(ctx, viewerId, comment.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check block: %w", err)
+	}
+	if blocked {
+		return nil, fmt.Errorf("comment %s: %w", commentId, storage.ErrNotFound)
+	}
+	return c.storage.GetCommentRevisions(ctx, commentId)
+}
+
+// authorizeComment проверяет, что комментарий относится к объекту, не удален и принадлежит userId
+func (c *CoreService) authorizeComment(ctx context.Context, target models.CommentTarget, commentId uuid.UUID, userId uuid.UUID) (models.Comment, error) {
+	comment, err := c.storage.GetComment(ctx, commentId)
+	if err != nil {
+		return comment, fmt.Errorf("failed to get comment: %w", err)
+	}
+	if comment.Target != target || comment.Deleted {
+		return comment, fmt.Errorf("comment %s: %w", commentId, storage.ErrNotFound)
+	}
+	if comment.UserId != userId {
+		return comment, fmt.Errorf("%w: only the author can change the comment", storage.ErrForbidden)
+	}
+	return comment, nil
+}
+
+// checkCommentTarget проверяет, что объект комментариев существует и виден viewerId
+func (c *CoreService) checkCommentTarget(ctx context.Context, target models.CommentTarget, viewerId uuid.UUID) error {
+	var err error
+	switch target.Kind {
+	case models.CommentTargetPhoto:
+		_, err = c.GetPhoto(ctx, target.Id, viewerId)
+	case models.CommentTargetTrip:
+		_, err = c.storage.GetTripById(ctx, target.Id)
+	default:
+		err = fmt.Errorf("%w: unknown comment target %q", storage.ErrValidation, target.Kind)
+	}
+	return err
+}
+
+// resolveMentions находит пользователей, упомянутых автором authorId через @username.
+// Несуществующие имена и пользователи, с которыми у автора блокировка, пропускаются
+func (c *CoreService) resolveMentions(ctx context.Context, body string, authorId uuid.UUID) []uuid.UUID {
+	var userIds []uuid.UUID
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		username := strings.TrimRight(match[1], ".")
+		if username == "" || seen[username] {
+			continue
+		}
+		seen[username] = true
+
+		user, err := c.storage.GetUserByUsername(ctx, username, authorId)
+		if err != nil {
+			continue
+		}
+		userIds = append(userIds, user.Id)
+	}
+	return userIds
+}
+
+// normalizeCommentBody проверяет текст комментария; вторым значением возвращаются правила
+// проверки текста, из-за которых комментарий нужно скрыть до решения модератора
+func (c *CoreService) normalizeCommentBody(body string) (string, []string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", nil, fmt.Errorf("%w: comment body is required", storage.ErrValidation)
+	}
+	return c.checkText(TextComment, body)
+}
+
+// holdComment скрывает комментарий и отправляет его модератору
+func (c *CoreService) holdComment(ctx context.Context, commentId uuid.UUID, body string, rules []string) error {
+	if err := c.storage.SetCommentHidden(ctx, commentId, true); err != nil {
+		return err
+	}
+	return c.reportText(ctx, models.ReportTargetComment, commentId, body, rules)
+}
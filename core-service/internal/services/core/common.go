@@ -10,22 +10,137 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/jobs"
+	"github.com/shameoff/more-than-trip/core/internal/lib/logger/sl"
+	"github.com/shameoff/more-than-trip/core/internal/lib/textpolicy"
+	"github.com/shameoff/more-than-trip/core/internal/lib/webhook"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
 )
 
 type CoreStorage interface {
+	// WithTx выполняет fn в одной транзакции; вызовы хранилища с ctx из fn работают в ней
+	WithTx(ctx context.Context, fn storage.TxFunc) error
+
 	// Работа с фотографиями
 	SavePhoto(ctx context.Context, data models.Photo) error
-	GetPhoto(ctx context.Context, photoId uuid.UUID) (models.Photo, error)
+	GetPhoto(ctx context.Context, photoId uuid.UUID, viewerId uuid.UUID) (models.Photo, error)
 	GetPhotos(ctx context.Context, filters models.PhotoFiltersDTO) ([]models.Photo, error)
 	UpdatePhoto(ctx context.Context, photoId uuid.UUID, data models.Photo) error
 	DeletePhoto(ctx context.Context, photoId uuid.UUID) error
-	GetPhotosByTripId(ctx context.Context, tripId uuid.UUID) ([]models.Photo, error)
-	GetPhotosByUserId(ctx context.Context, userId uuid.UUID) ([]models.Photo, error)
-	GetPhotosByRegionId(ctx context.Context, regionId uuid.UUID) ([]models.Photo, error)
+	SetPhotoThumbnail(ctx context.Context, photoId uuid.UUID, imgUrl string, thumbnailUrl string) error
+	GetPhotosByTripId(ctx context.Context, tripId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error)
+	GetPhotosByUserId(ctx context.Context, userId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error)
+	GetPhotosByRegionId(ctx context.Context, regionId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error)
+	GetTripPhotoIds(ctx context.Context, tripId uuid.UUID) ([]uuid.UUID, error)
+
+	// Видимость фото и ссылки для доступа
+	IsPhotoVisibleTo(ctx context.Context, photoId uuid.UUID, viewerId uuid.UUID) (bool, error)
+	GetSharedTripPhotos(ctx context.Context, tripId uuid.UUID, sharerId uuid.UUID) ([]models.Photo, error)
+	SaveShareLink(ctx context.Context, link models.ShareLink, tokenHash []byte) (models.ShareLink, error)
+	GetShareLinkByToken(ctx context.Context, tokenHash []byte) (models.ShareLink, error)
+	GetShareLinks(ctx context.Context, userId uuid.UUID) ([]models.ShareLink, error)
+	DeleteShareLink(ctx context.Context, linkId uuid.UUID, userId uuid.UUID) error
 
 	// Работа с лайками
-	LikePhoto(ctx context.Context, photoId uuid.UUID, userId uuid.UUID) error
+	LikePhoto(ctx context.Context, photoId uuid.UUID, userId uuid.UUID) (bool, error)
 	DislikePhoto(ctx context.Context, photoId uuid.UUID, userId uuid.UUID) error
+	GetPhotoLikes(ctx context.Context, photoId uuid.UUID, viewerId uuid.UUID) ([]models.Like, error)
+
+	// Подписка на события из БД для потока обновлений
+	Listen(ctx context.Context, channel string, handle func(payload string)) error
+
+	// Доменные события и вебхуки
+	AddOutboxEvent(ctx context.Context, event models.OutboxEvent) error
+	FanOutOutboxEvents(ctx context.Context, limit int) (int, error)
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	CompleteWebhookAttempt(ctx context.Context, attempt models.WebhookAttempt, status string, nextAttemptAt time.Time) error
+	CreateWebhook(ctx context.Context, webhook models.Webhook) error
+	GetWebhooks(ctx context.Context) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookId uuid.UUID) error
+	GetWebhookDeliveries(ctx context.Context, webhookId uuid.UUID, limit int) ([]models.WebhookDelivery, error)
+	GetWebhookAttempts(ctx context.Context, deliveryId uuid.UUID) ([]models.WebhookAttempt, error)
+
+	// Модерация фото
+	GetModerationQueue(ctx context.Context, regionId uuid.UUID, limit int) ([]models.Photo, error)
+	ModeratePhoto(ctx context.Context, photoId uuid.UUID, decision models.ModerationDecision) error
+	IsModerator(ctx context.Context, userId uuid.UUID) (bool, error)
+	SetModerator(ctx context.Context, userId uuid.UUID, moderator bool) error
+	IsTrustedInRegion(ctx context.Context, userId uuid.UUID, regionId uuid.UUID) (bool, error)
+	GetTrustedUsers(ctx context.Context, regionId uuid.UUID) ([]models.TrustedUser, error)
+	AddTrustedUser(ctx context.Context, user models.TrustedUser) error
+	RemoveTrustedUser(ctx context.Context, regionId uuid.UUID, userId uuid.UUID) error
+
+	// Жалобы пользователей
+	SaveReport(ctx context.Context, report models.Report) (models.Report, bool, error)
+	CountOpenReports(ctx context.Context, targetType string, targetId uuid.UUID) (int, error)
+	GetReportSummaries(ctx context.Context, targetType string, limit int) ([]models.ReportSummary, error)
+	GetReports(ctx context.Context, targetType string, targetId uuid.UUID) ([]models.Report, error)
+	CloseReports(ctx context.Context, targetType string, targetId uuid.UUID, status string, resolution models.ReportResolution) (int, error)
+	SetCommentHidden(ctx context.Context, commentId uuid.UUID, hidden bool) error
+	SetTripHidden(ctx context.Context, tripId uuid.UUID, hidden bool) error
+
+	// Очередь фоновых задач
+	jobs.Store
+	GetJobs(ctx context.Context, status string, limit int) ([]models.Job, error)
+	RequeueDeadJob(ctx context.Context, jobId uuid.UUID) error
+
+	// Работа с просмотрами
+	SaveViews(ctx context.Context, views []models.View) (int, error)
+
+	// Подписки и домашняя лента
+	FollowUser(ctx context.Context, followerId uuid.UUID, followeeId uuid.UUID) error
+	UnfollowUser(ctx context.Context, followerId uuid.UUID, followeeId uuid.UUID) error
+	GetFollowers(ctx context.Context, userId uuid.UUID) ([]models.Follow, error)
+	GetFollowing(ctx context.Context, userId uuid.UUID) ([]models.Follow, error)
+	FollowRegion(ctx context.Context, userId uuid.UUID, regionId uuid.UUID) error
+	UnfollowRegion(ctx context.Context, userId uuid.UUID, regionId uuid.UUID) error
+	GetFollowedRegions(ctx context.Context, userId uuid.UUID) ([]models.Region, error)
+	GetHomePhotos(ctx context.Context, userId uuid.UUID, before *models.TimeCursor, limit int) ([]models.Photo, error)
+	GetHomeTrips(ctx context.Context, userId uuid.UUID, before *models.TimeCursor, limit int) ([]models.Trip, error)
+
+	// Блокировки и скрытие пользователей
+	BlockUser(ctx context.Context, blockerId uuid.UUID, blockedId uuid.UUID) error
+	UnblockUser(ctx context.Context, blockerId uuid.UUID, blockedId uuid.UUID) error
+	IsBlocked(ctx context.Context, userId uuid.UUID, otherId uuid.UUID) (bool, error)
+	GetBlockedUsers(ctx context.Context, userId uuid.UUID) ([]models.UserRestriction, error)
+	MuteUser(ctx context.Context, userId uuid.UUID, mutedId uuid.UUID) error
+	UnmuteUser(ctx context.Context, userId uuid.UUID, mutedId uuid.UUID) error
+	GetMutedUsers(ctx context.Context, userId uuid.UUID) ([]models.UserRestriction, error)
+	GetHiddenUserIds(ctx context.Context, userId uuid.UUID) ([]uuid.UUID, error)
+
+	// Альбомы
+	CreateAlbum(ctx context.Context, album models.Album) error
+	GetAlbum(ctx context.Context, albumId uuid.UUID, viewerId uuid.UUID) (models.Album, error)
+	GetAlbums(ctx context.Context, userId uuid.UUID, viewerId uuid.UUID) ([]models.Album, error)
+	UpdateAlbum(ctx context.Context, albumId uuid.UUID, data models.Album) error
+	DeleteAlbum(ctx context.Context, albumId uuid.UUID) error
+	GetAlbumPhotos(ctx context.Context, albumId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error)
+	GetAlbumPhotoIds(ctx context.Context, albumId uuid.UUID) ([]uuid.UUID, error)
+	AddAlbumPhoto(ctx context.Context, albumId uuid.UUID, photoId uuid.UUID) error
+	RemoveAlbumPhoto(ctx context.Context, albumId uuid.UUID, photoId uuid.UUID) error
+	ReorderAlbumPhotos(ctx context.Context, albumId uuid.UUID, photoIds []uuid.UUID) error
+
+	// Комментарии
+	CreateComment(ctx context.Context, comment models.Comment) error
+	GetComment(ctx context.Context, commentId uuid.UUID) (models.Comment, error)
+	GetComments(ctx context.Context, target models.CommentTarget, viewerId uuid.UUID) ([]models.Comment, error)
+	UpdateComment(ctx context.Context, commentId uuid.UUID, body string) error
+	DeleteComment(ctx context.Context, commentId uuid.UUID) error
+	SetCommentMentions(ctx context.Context, commentId uuid.UUID, userIds []uuid.UUID) error
+	GetCommentRevisions(ctx context.Context, commentId uuid.UUID) ([]models.CommentRevision, error)
+
+	// Уведомления
+	AddNotification(ctx context.Context, event models.NotificationEvent) error
+	GetNotifications(ctx context.Context, userId uuid.UUID, unreadOnly bool, limit int) ([]models.Notification, error)
+	CountUnreadNotifications(ctx context.Context, userId uuid.UUID) (int, error)
+	MarkNotificationRead(ctx context.Context, userId uuid.UUID, notificationId uuid.UUID) error
+	MarkAllNotificationsRead(ctx context.Context, userId uuid.UUID) (int, error)
+	GetNotificationPreferences(ctx context.Context, userId uuid.UUID) ([]models.NotificationPreference, error)
+	SetNotificationPreference(ctx context.Context, userId uuid.UUID, preference models.NotificationPreference) error
+
+	// Лента популярного
+	RefreshPhotoRanking(ctx context.Context, window models.TrendingWindow, likeWeight float64, viewWeight float64) (int, error)
+	GetTrendingPhotos(ctx context.Context, window string, filters models.PhotoFiltersDTO, cursor *models.FeedCursor, limit int) ([]models.TrendingPhoto, error)
 
 	// Работа с тегами
 	CreateTag(ctx context.Context, tag models.Tag) error
@@ -39,7 +154,21 @@ type CoreStorage interface {
 	GetRegionById(ctx context.Context, regionId uuid.UUID) (models.Region, error)
 	GetRegionByKey(ctx context.Context, regionKey string) (models.Region, error)
 	GetRegions(ctx context.Context) ([]models.Region, error)
+	GetRegionChildren(ctx context.Context, parentId uuid.UUID) ([]models.Region, error)
 	UpdateRegion(ctx context.Context, regionId uuid.UUID, data models.Region) error
+	SetRegionBoundary(ctx context.Context, boundary models.RegionBoundary) error
+	GetRegionBoundaries(ctx context.Context) ([]models.RegionBoundary, error)
+	GetRegionBoundariesAt(ctx context.Context, lat float64, lon float64) ([]models.RegionBoundary, error)
+	GetPhotosWithCoords(ctx context.Context) ([]models.Photo, error)
+	UpdatePhotoRegion(ctx context.Context, photoId uuid.UUID, regionId uuid.UUID, moderationStatus string) error
+
+	// Проверка ссылочной целостности
+	CheckReferences(ctx context.Context) ([]models.DanglingReference, error)
+
+	// Массовый импорт справочников
+	ImportRegion(ctx context.Context, region models.ImportRegion) (string, error)
+	ImportPlace(ctx context.Context, place models.ImportPlace) (string, error)
+	ImportTag(ctx context.Context, tag models.Tag) (string, error)
 
 	// Работа с поездками
 	CreateTrip(ctx context.Context, trip models.Trip) error
@@ -49,26 +178,68 @@ type CoreStorage interface {
 	GetTripsByRegionId(ctx context.Context, regionId uuid.UUID) ([]models.Trip, error)
 	GetTripsByTag(ctx context.Context, tagId string) ([]models.Trip, error)
 	UpdateTrip(ctx context.Context, tripId uuid.UUID, data models.Trip) error
+	SetTripDates(ctx context.Context, tripId uuid.UUID, startDate string, endDate string) error
+	GetTripStops(ctx context.Context, tripId uuid.UUID) ([]models.TripStop, error)
+	ReplaceTripStops(ctx context.Context, tripId uuid.UUID, stops []models.TripStop) error
+
+	// Подписка на календарь поездок
+	SaveCalendarToken(ctx context.Context, userId uuid.UUID, tokenHash []byte) (time.Time, error)
+	IsCalendarTokenValid(ctx context.Context, userId uuid.UUID, tokenHash []byte) (bool, error)
+	DeleteCalendarToken(ctx context.Context, userId uuid.UUID) error
+	GetTripMembers(ctx context.Context, tripId uuid.UUID) ([]uuid.UUID, error)
+
+	// Работа с местами
+	CreatePlace(ctx context.Context, place models.Place) error
+	DeletePlace(ctx context.Context, placeId uuid.UUID) error
+	GetPlaceById(ctx context.Context, placeId uuid.UUID) (models.Place, error)
+	GetPlaces(ctx context.Context, regionId uuid.UUID) ([]models.Place, error)
+	UpdatePlace(ctx context.Context, placeId uuid.UUID, data models.Place) error
 
 	// Работа с пользователями
 	CreateUser(ctx context.Context, user models.User) error
 	DeleteUser(ctx context.Context, userId uuid.UUID) error
 	GetUserById(ctx context.Context, userId uuid.UUID) (models.User, error)
-	GetUsers(ctx context.Context) ([]models.User, error)
-	GetUserByUsername(ctx context.Context, username string) (models.User, error)
+	GetUsers(ctx context.Context, viewerId uuid.UUID) ([]models.User, error)
+	GetUserByUsername(ctx context.Context, username string, viewerId uuid.UUID) (models.User, error)
 	UpdateUser(ctx context.Context, userId uuid.UUID, data models.User) error
 }
 
 // S3PhotoService - интерфейс для работы с файлами
 type S3PhotoStorage interface {
 	UploadPhoto(ctx context.Context, file multipart.File, fileSize int64, fileName string) (string, error)
+	PutObject(ctx context.Context, data []byte, fileName string, contentType string) (string, error)
+	DownloadPhoto(ctx context.Context, fileURL string) ([]byte, error)
 }
 
 type CoreService struct {
-	uploadDir string
-	log       *slog.Logger
-	storage   CoreStorage
-	s3Storage S3PhotoStorage
+	uploadDir  string
+	log        *slog.Logger
+	storage    CoreStorage
+	s3Storage  S3PhotoStorage
+	views      ViewTracking
+	viewQueue  chan models.View
+	trending   TrendingRanking
+	stream     *streamHub
+	webhooks   WebhookDispatch
+	reports    ReportPolicy
+	text       TextPolicy
+	share      ShareLinks
+	streamAuth StreamAuth
+	// Цепочка правил проверки текста, собранная из text
+	textPipeline *textpolicy.Pipeline
+	// Клиент для отправки событий партнерам
+	webhookClient *webhook.Client
+}
+
+// Options - настройки фоновых процессов сервиса
+type Options struct {
+	Views    ViewTracking
+	Trending TrendingRanking
+	Webhooks WebhookDispatch
+	Reports  ReportPolicy
+	Text     TextPolicy
+	Share    ShareLinks
+	Stream   StreamAuth
 }
 
 // NewCoreService создает сервис для загрузки файлов в локальную файловую систему
@@ -76,16 +247,56 @@ func NewCoreService(uploadDir string,
 	log *slog.Logger,
 	storage CoreStorage,
 	s3storage S3PhotoStorage,
+	opts Options,
 ) *CoreService {
 	return &CoreService{
-		uploadDir: uploadDir,
-		log:       log,
-		storage:   storage,
-		s3Storage: s3storage,
+		uploadDir:  uploadDir,
+		log:        log,
+		storage:    storage,
+		s3Storage:  s3storage,
+		views:      opts.Views,
+		viewQueue:  make(chan models.View, opts.Views.QueueSize),
+		trending:   opts.Trending,
+		stream:     newStreamHub(),
+		webhooks:   opts.Webhooks,
+		reports:    opts.Reports,
+		text:       opts.Text,
+		share:      opts.Share,
+		streamAuth: opts.Stream,
+
+		textPipeline:  newTextPipeline(opts.Text),
+		webhookClient: webhook.NewClient(opts.Webhooks.Timeout),
 	}
 }
 
 func (s *CoreService) UploadPhoto(ctx context.Context, file multipart.File, fileHeader *multipart.FileHeader, metadata models.Photo) error {
+	// Определение региона по координатам, если они указаны
+	if metadata.Coords != "" {
+		regionId, found, err := s.ResolveRegion(ctx, metadata.Coords, metadata.RegionId)
+		if err != nil {
+			s.log.Warn("failed to resolve photo region by coords", slog.String("coords", metadata.Coords), sl.Err(err))
+		} else if found {
+			metadata.RegionId = regionId
+		}
+	}
+	if metadata.RegionId == uuid.Nil {
+		return fmt.Errorf("%w: photo region is required when it can't be resolved by coords", storage.ErrValidation)
+	}
+	if err := s.checkPlaceRegion(ctx, metadata.PlaceId, metadata.RegionId); err != nil {
+		return err
+	}
+	if metadata.Visibility == "" {
+		metadata.Visibility = models.VisibilityPublic
+	}
+	if err := validateVisibility(metadata.Visibility); err != nil {
+		return err
+	}
+	description, flagged, err := s.checkText(TextPhotoDescription, metadata.Description)
+	if err != nil {
+		return err
+	}
+	metadata.Description = description
+
 	// Генерация уникального имени файла
 	fileName := fmt.Sprintf("%d_%s", time.Now().Unix(), fileHeader.Filename)
 
@@ -96,8 +307,37 @@ func (s *CoreService) UploadPhoto(ctx context.Context, file multipart.File, file
 	}
 
 	metadata.ImgUrl = fileURL
-	// Загрузка в БД
-	err = s.storage.SavePhoto(ctx, metadata)
+	metadata.Id = uuid.New()
+	// Загрузка в БД вместе с событием для партнеров
+	err = s.storage.WithTx(ctx, func(ctx context.Context) error {
+		status, err := s.initialModerationStatus(ctx, metadata.UserId, metadata.RegionId)
+		if err != nil {
+			return err
+		}
+		metadata.ModerationStatus = status
+		if err := s.storage.SavePhoto(ctx, metadata); err != nil {
+			return err
+		}
+		// Фото с подозрительным описанием проверяет модератор, даже если автор доверенный
+		if len(flagged) > 0 {
+			if err := s.holdPhoto(ctx, metadata.Id, flagged); err != nil {
+				return err
+			}
+		}
+		photo, err := s.storage.GetPhoto(ctx, metadata.Id, uuid.Nil)
+		if err != nil {
+			return err
+		}
+		// Миниатюра строится в фоне, чтобы не задерживать ответ на загрузку
+		if err := jobs.Enqueue(ctx, s.storage, JobPhotoThumbnail, photoJob{PhotoId: photo.Id}, 0); err != nil {
+			return err
+		}
+		// Фото из очереди модерации партнеры получат после одобрения, непубличные не получат вовсе
+		if !isPublished(photo) {
+			return nil
+		}
+		return s.emitEvent(ctx, models.EventPhotoCreated, photo)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to save photo metadata: %w", err)
 	}
@@ -105,12 +345,20 @@ func (s *CoreService) UploadPhoto(ctx context.Context, file multipart.File, file
 	return nil
 }
 
-func (c *CoreService) GetPhoto(ctx context.Context, photoId uuid.UUID) (models.Photo, error) {
-	photo, err := c.storage.GetPhoto(ctx, photoId)
+// GetPhoto возвращает фото; viewerId - пользователь, для которого заполняется LikedByMe
+func (c *CoreService) GetPhoto(ctx context.Context, photoId uuid.UUID, viewerId uuid.UUID) (models.Photo, error) {
+	photo, err := c.storage.GetPhoto(ctx, photoId, viewerId)
 	if err != nil {
 		// Логирование ошибки, если нужно
 		return models.Photo{}, fmt.Errorf("failed to get photo: %w", err)
 	}
+	visible, err := c.canSeePhoto(ctx, photo, viewerId)
+	if err != nil {
+		return models.Photo{}, fmt.Errorf("failed to check photo visibility: %w", err)
+	}
+	if !visible {
+		return models.Photo{}, fmt.Errorf("failed to get photo: %w", storage.ErrNotFound)
+	}
 	return photo, nil
 }
 
@@ -123,8 +371,55 @@ func (c *CoreService) GetPhotos(ctx context.Context, filters models.PhotoFilters
 	return photos, nil
 }
 
-func (c *CoreService) UpdatePhoto(ctx context.Context, photoId uuid.UUID, data models.Photo) error {
-	err := c.storage.UpdatePhoto(ctx, photoId, data)
+// UpdatePhoto меняет фото от имени его автора userId; автора фото сменить нельзя
+func (c *CoreService) UpdatePhoto(ctx context.Context, userId uuid.UUID, photoId uuid.UUID, data models.Photo) error {
+	// Пустая видимость оставляет прежнюю
+	if data.Visibility != "" {
+		if err := validateVisibility(data.Visibility); err != nil {
+			return err
+		}
+	}
+	if err := c.checkPlaceRegion(ctx, data.PlaceId, data.RegionId); err != nil {
+		return err
+	}
+	description, flagged, err := c.checkText(TextPhotoDescription, data.Description)
+	if err != nil {
+		return err
+	}
+	data.Description = description
+	err = c.storage.WithTx(ctx, func(ctx context.Context) error {
+		before, err := c.authorizePhoto(ctx, photoId, userId)
+		if err != nil {
+			return err
+		}
+		data.UserId = before.UserId
+		// Статус применяется, только если заменен файл фото или фото перенесено в другой регион:
+		// доверие автору в одном регионе не распространяется на другие
+		status, err := c.initialModerationStatus(ctx, data.UserId, data.RegionId)
+		if err != nil {
+			return err
+		}
+		data.ModerationStatus = status
+		if err := c.storage.UpdatePhoto(ctx, photoId, data); err != nil {
+			return err
+		}
+		if len(flagged) > 0 {
+			if err := c.holdPhoto(ctx, photoId, flagged); err != nil {
+				return err
+			}
+		}
+		photo, err := c.storage.GetPhoto(ctx, photoId, uuid.Nil)
+		if err != nil {
+			return err
+		}
+		// Миниатюра сбрасывается при замене файла и строится заново
+		if photo.ThumbnailUrl == "" {
+			if err := jobs.Enqueue(ctx, c.storage, JobPhotoThumbnail, photoJob{PhotoId: photo.Id}, 0); err != nil {
+				return err
+			}
+		}
+		return c.emitPhotoChange(ctx, before, photo)
+	})
 	if err != nil {
 		// Логирование ошибки, если нужно
 		return fmt.Errorf("failed to update photo: %w", err)
@@ -132,8 +427,17 @@ func (c *CoreService) UpdatePhoto(ctx context.Context, photoId uuid.UUID, data m
 	return nil
 }
 
-func (c *CoreService) DeletePhoto(ctx context.Context, photoId uuid.UUID) error {
-	err := c.storage.DeletePhoto(ctx, photoId)
+// DeletePhoto удаляет фото от имени его автора userId
+func (c *CoreService) DeletePhoto(ctx context.Context, userId uuid.UUID, photoId uuid.UUID) error {
+	err := c.storage.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := c.authorizePhoto(ctx, photoId, userId); err != nil {
+			return err
+		}
+		if err := c.storage.DeletePhoto(ctx, photoId); err != nil {
+			return err
+		}
+		return c.emitEvent(ctx, models.EventPhotoDeleted, models.Photo{Id: photoId})
+	})
 	if err != nil {
 		// Логирование ошибки, если нужно
 		return fmt.Errorf("failed to delete photo: %w", err)
@@ -141,8 +445,34 @@ func (c *CoreService) DeletePhoto(ctx context.Context, photoId uuid.UUID) error
 	return nil
 }
 
-func (c *CoreService) GetPhotosByTripId(ctx context.Context, tripId uuid.UUID) ([]models.Photo, error) {
-	photos, err := c.storage.GetPhotosByTripId(ctx, tripId)
+// emitPhotoChange сообщает партнерам об изменении фото: фото, переставшее быть публичным,
+// для них удалено, а ставшее публичным - создано
+func (c *CoreService) emitPhotoChange(ctx context.Context, before models.Photo, photo models.Photo) error {
+	switch {
+	case isPublished(before) && isPublished(photo):
+		return c.emitEvent(ctx, models.EventPhotoUpdated, photo)
+	case isPublished(photo):
+		return c.emitEvent(ctx, models.EventPhotoCreated, photo)
+	case isPublished(before):
+		return c.emitEvent(ctx, models.EventPhotoDeleted, models.Photo{Id: photo.Id})
+	}
+	return nil
+}
+
+// authorizePhoto возвращает фото, если его автор - userId
+func (c *CoreService) authorizePhoto(ctx context.Context, photoId uuid.UUID, userId uuid.UUID) (models.Photo, error) {
+	photo, err := c.storage.GetPhoto(ctx, photoId, uuid.Nil)
+	if err != nil {
+		return models.Photo{}, err
+	}
+	if photo.UserId != userId {
+		return models.Photo{}, fmt.Errorf("%w: only the author can change the photo", storage.ErrForbidden)
+	}
+	return photo, nil
+}
+
+func (c *CoreService) GetPhotosByTripId(ctx context.Context, tripId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error) {
+	photos, err := c.storage.GetPhotosByTripId(ctx, tripId, viewerId)
 	if err != nil {
 		// Логирование ошибки, если нужно
 		return nil, fmt.Errorf("failed to get photos by trip ID: %w", err)
@@ -150,8 +480,8 @@ func (c *CoreService) GetPhotosByTripId(ctx context.Context, tripId uuid.UUID) (
 	return photos, nil
 }
 
-func (c *CoreService) GetPhotosByUserId(ctx context.Context, userId uuid.UUID) ([]models.Photo, error) {
-	photos, err := c.storage.GetPhotosByUserId(ctx, userId)
+func (c *CoreService) GetPhotosByUserId(ctx context.Context, userId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error) {
+	photos, err := c.storage.GetPhotosByUserId(ctx, userId, viewerId)
 	if err != nil {
 		// Логирование ошибки, если нужно
 		return nil, fmt.Errorf("failed to get photos by user ID: %w", err)
@@ -159,8 +489,8 @@ func (c *CoreService) GetPhotosByUserId(ctx context.Context, userId uuid.UUID) (
 	return photos, nil
 }
 
-func (c *CoreService) GetPhotosByRegionId(ctx context.Context, regionId uuid.UUID) ([]models.Photo, error) {
-	photos, err := c.storage.GetPhotosByRegionId(ctx, regionId)
+func (c *CoreService) GetPhotosByRegionId(ctx context.Context, regionId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error) {
+	photos, err := c.storage.GetPhotosByRegionId(ctx, regionId, viewerId)
 	if err != nil {
 		// Логирование ошибки, если нужно
 		return nil, fmt.Errorf("failed to get photos by region ID: %w", err)
@@ -169,11 +499,27 @@ func (c *CoreService) GetPhotosByRegionId(ctx context.Context, regionId uuid.UUI
 }
 
 func (c *CoreService) LikePhoto(ctx context.Context, photoId uuid.UUID, userId uuid.UUID) error {
-	err := c.storage.LikePhoto(ctx, photoId, userId)
+	// Лайкнуть можно только фото, которое пользователь видит
+	photo, err := c.GetPhoto(ctx, photoId, userId)
+	if err != nil {
+		return err
+	}
+	liked, err := c.storage.LikePhoto(ctx, photoId, userId)
 	if err != nil {
 		// Логирование ошибки, если нужно
 		return fmt.Errorf("failed to like photo: %w", err)
 	}
+	// Повторный лайк не создает уведомление
+	if !liked {
+		return nil
+	}
+
+	c.notify(ctx, models.NotificationEvent{
+		UserId:    photo.UserId,
+		Type:      models.NotificationPhotoLike,
+		SubjectId: photoId,
+		ActorId:   userId,
+	})
 	return nil
 }
 
@@ -186,8 +532,23 @@ func (c *CoreService) DislikePhoto(ctx context.Context, photoId uuid.UUID, userI
 	return nil
 }
 
+// GetPhotoLikes возвращает список пользователей, лайкнувших фото, если оно видно viewerId
+func (c *CoreService) GetPhotoLikes(ctx context.Context, photoId uuid.UUID, viewerId uuid.UUID) ([]models.Like, error) {
+	if _, err := c.GetPhoto(ctx, photoId, viewerId); err != nil {
+		return nil, err
+	}
+	likes, err := c.storage.GetPhotoLikes(ctx, photoId, viewerId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get photo likes: %w", err)
+	}
+	return likes, nil
+}
+
 // Работа с тегами
 func (c *CoreService) CreateTag(ctx context.Context, tag models.Tag) error {
+	if tag.Id == uuid.Nil {
+		tag.Id = uuid.New()
+	}
 	err := c.storage.CreateTag(ctx, tag)
 	if err != nil {
 		// Логирование ошибки, если нужно
@@ -0,0 +1,164 @@
+// Бизнес-логика подписок и домашней ленты
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+func (c *CoreService) FollowUser(ctx context.Context, followerId uuid.UUID, followeeId uuid.UUID) error {
+	if followerId == followeeId {
+		return fmt.Errorf("%w: user can't follow themselves", storage.ErrValidation)
+	}
+	blocked, err := c.storage.IsBlocked(ctx, followerId, followeeId)
+	if err != nil {
+		return fmt.Errorf("failed to check block: %w", err)
+	}
+	if blocked {
+		return fmt.Errorf("%w: user is blocked", storage.ErrForbidden)
+	}
+	if err := c.storage.FollowUser(ctx, followerId, followeeId); err != nil {
+		return err
+	}
+	c.notify(ctx, models.NotificationEvent{
+		UserId:    followeeId,
+		Type:      models.NotificationFollow,
+		SubjectId: followeeId,
+		ActorId:   followerId,
+	})
+	return nil
+}
+
+func (c *CoreService) UnfollowUser(ctx context.Context, followerId uuid.UUID, followeeId uuid.UUID) error {
+	return c.storage.UnfollowUser(ctx, followerId, followeeId)
+}
+
+func (c *CoreService) GetFollowers(ctx context.Context, userId uuid.UUID) ([]models.Follow, error) {
+	if _, err := c.storage.GetUserById(ctx, userId); err != nil {
+		return nil, err
+	}
+	return c.storage.GetFollowers(ctx, userId)
+}
+
+func (c *CoreService) GetFollowing(ctx context.Context, userId uuid.UUID) ([]models.Follow, error) {
+	if _, err := c.storage.GetUserById(ctx, userId); err != nil {
+		return nil, err
+	}
+	return c.storage.GetFollowing(ctx, userId)
+}
+
+func (c *CoreService) FollowRegion(ctx context.Context, userId uuid.UUID, regionId uuid.UUID) error {
+	return c.storage.FollowRegion(ctx, userId, regionId)
+}
+
+func (c *CoreService) UnfollowRegion(ctx context.Context, userId uuid.UUID, regionId uuid.UUID) error {
+	return c.storage.UnfollowRegion(ctx, userId, regionId)
+}
+
+func (c *CoreService) GetFollowedRegions(ctx context.Context, userId uuid.UUID) ([]models.Region, error) {
+	return c.storage.GetFollowedRegions(ctx, userId)
+}
+
+// GetHomeFeed возвращает домашнюю ленту пользователя: фото и поездки авторов и регионов,
+// на которые он подписан, от новых к старым. cursor - NextCursor предыдущей страницы
+func (c *CoreService) GetHomeFeed(ctx context.Context, userId uuid.UUID, cursor string, limit int) (models.HomeFeedPage, error) {
+	if limit <= 0 {
+		limit = defaultFeedLimit
+	}
+	limit = min(limit, maxFeedLimit)
+
+	var before *models.TimeCursor
+	if cursor != "" {
+		decoded, err := decodeTimeCursor(cursor)
+		if err != nil {
+			return models.HomeFeedPage{}, fmt.Errorf("%w: invalid cursor", storage.ErrValidation)
+		}
+		before = &decoded
+	}
+
+	// Из каждого источника берем на одну запись больше страницы: после слияния
+	// лишняя запись показывает, что следующая страница есть
+	photos, err := c.storage.GetHomePhotos(ctx, userId, before, limit+1)
+	if err != nil {
+		return models.HomeFeedPage{}, fmt.Errorf("failed to get home photos: %w", err)
+	}
+	trips, err := c.storage.GetHomeTrips(ctx, userId, before, limit+1)
+	if err != nil {
+		return models.HomeFeedPage{}, fmt.Errorf("failed to get home trips: %w", err)
+	}
+
+	items := mergeFeed(photos, trips)
+	page := models.HomeFeedPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = encodeTimeCursor(models.TimeCursor{At: last.CreatedAt, Id: feedItemId(last)})
+	}
+	return page, nil
+}
+
+// mergeFeed сливает уже отсортированные от новых к старым фото и поездки
+func mergeFeed(photos []models.Photo, trips []models.Trip) []models.FeedItem {
+	items := make([]models.FeedItem, 0, len(photos)+len(trips))
+	i, j := 0, 0
+	for i < len(photos) || j < len(trips) {
+		if j == len(trips) || (i < len(photos) && newer(photos[i].CreatedAt, photos[i].Id, trips[j].CreatedAt, trips[j].Id)) {
+			items = append(items, models.FeedItem{Kind: models.FeedItemPhoto, CreatedAt: photos[i].CreatedAt, Photo: &photos[i]})
+			i++
+		} else {
+			items = append(items, models.FeedItem{Kind: models.FeedItemTrip, CreatedAt: trips[j].CreatedAt, Trip: &trips[j]})
+			j++
+		}
+	}
+	return items
+}
+
+// newer сравнивает записи в порядке ленты: (created_at, id) по убыванию
+func newer(at time.Time, id uuid.UUID, otherAt time.Time, otherId uuid.UUID) bool {
+	if !at.Equal(otherAt) {
+		return at.After(otherAt)
+	}
+	return bytes.Compare(id[:], otherId[:]) > 0
+}
+
+func feedItemId(item models.FeedItem) uuid.UUID {
+	if item.Photo != nil {
+		return item.Photo.Id
+	}
+	return item.Trip.Id
+}
+
+// Курсор - base64 от "время в наносекундах|id записи"
+func encodeTimeCursor(cursor models.TimeCursor) string {
+	raw := strconv.FormatInt(cursor.At.UnixNano(), 10) + "|" + cursor.Id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeTimeCursor(cursor string) (models.TimeCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return models.TimeCursor{}, err
+	}
+	atStr, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return models.TimeCursor{}, fmt.Errorf("malformed cursor")
+	}
+	nanos, err := strconv.ParseInt(atStr, 10, 64)
+	if err != nil {
+		return models.TimeCursor{}, err
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return models.TimeCursor{}, err
+	}
+	return models.TimeCursor{At: time.Unix(0, nanos).UTC(), Id: id}, nil
+}
@@ -0,0 +1,18 @@
+// Проверка целостности данных
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+// CheckReferences возвращает висячие ссылки, которые помешают добавлению внешних ключей
+func (c *CoreService) CheckReferences(ctx context.Context) ([]models.DanglingReference, error) {
+	dangling, err := c.storage.CheckReferences(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check references: %w", err)
+	}
+	return dangling, nil
+}
@@ -0,0 +1,226 @@
+// Маршрут поездки: даты, остановки и хронология фото по дням
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// Наибольшее число остановок в маршруте
+const maxTripStops = 100
+
+// Формат дат поездки
+const tripDateLayout = "2006-01-02"
+
+// validateTripDates проверяет формат дат поездки и что поездка не заканчивается раньше, чем начинается
+func validateTripDates(startDate string, endDate string) error {
+	var start, end time.Time
+	var err error
+	if startDate != "" {
+		if start, err = time.Parse(tripDateLayout, startDate); err != nil {
+			return fmt.Errorf("%w: start date must be in YYYY-MM-DD format", storage.ErrValidation)
+		}
+	}
+	if endDate != "" {
+		if end, err = time.Parse(tripDateLayout, endDate); err != nil {
+			return fmt.Errorf("%w: end date must be in YYYY-MM-DD format", storage.ErrValidation)
+		}
+	}
+	if startDate != "" && endDate != "" && end.Before(start) {
+		return fmt.Errorf("%w: trip can't end before it starts", storage.ErrValidation)
+	}
+	return nil
+}
+
+// GetItinerary возвращает даты и маршрут поездки
+func (c *CoreService) GetItinerary(ctx context.Context, tripId uuid.UUID) (models.Itinerary, error) {
+	trip, err := c.GetTripById(ctx, tripId)
+	if err != nil {
+		return models.Itinerary{}, err
+	}
+	stops, err := c.storage.GetTripStops(ctx, tripId)
+	if err != nil {
+		return models.Itinerary{}, err
+	}
+	return models.Itinerary{TripId: tripId, StartDate: trip.StartDate, EndDate: trip.EndDate, Stops: stops}, nil
+}
+
+// SetItinerary заменяет даты и маршрут поездки. Менять маршрут могут только участники поездки.
+// Остановки с Id сохраняют его, остальные получают новый; порядок остановок берется из списка
+func (c *CoreService) SetItinerary(ctx context.Context, userId uuid.UUID, itinerary models.Itinerary) (models.Itinerary, error) {
+	if err := validateTripDates(itinerary.StartDate, itinerary.EndDate); err != nil {
+		return models.Itinerary{}, err
+	}
+	flagged, err := c.checkStops(itinerary.Stops)
+	if err != nil {
+		return models.Itinerary{}, err
+	}
+
+	trip, err := c.GetTripById(ctx, itinerary.TripId)
+	if err != nil {
+		return models.Itinerary{}, err
+	}
+	member, err := c.isTripMember(ctx, trip.Id, userId)
+	if err != nil {
+		return models.Itinerary{}, err
+	}
+	if !member {
+		return models.Itinerary{}, fmt.Errorf("%w: only trip members can change the itinerary", storage.ErrForbidden)
+	}
+
+	err = c.storage.WithTx(ctx, func(ctx context.Context) error {
+		if err := c.storage.SetTripDates(ctx, trip.Id, itinerary.StartDate, itinerary.EndDate); err != nil {
+			return err
+		}
+		if err := c.storage.ReplaceTripStops(ctx, trip.Id, itinerary.Stops); err != nil {
+			return err
+		}
+		// Заметки остановок - часть поездки, поэтому подозрительный текст скрывает всю поездку
+		if len(flagged) > 0 {
+			if err := c.holdTrip(ctx, trip, flagged); err != nil {
+				return err
+			}
+		}
+		updated, err := c.storage.GetTripById(ctx, trip.Id)
+		if err != nil {
+			return err
+		}
+		return c.emitEvent(ctx, models.EventTripUpdated, updated)
+	})
+	if err != nil {
+		return models.Itinerary{}, fmt.Errorf("failed to set itinerary: %w", err)
+	}
+	if len(flagged) > 0 {
+		// Поездка скрыта до решения модератора, но участник видит сохраненный маршрут
+		return models.Itinerary{TripId: trip.Id, StartDate: itinerary.StartDate, EndDate: itinerary.EndDate, Stops: itinerary.Stops}, nil
+	}
+	return c.GetItinerary(ctx, trip.Id)
+}
+
+// checkStops проверяет остановки, проставляет их позиции и Id и возвращает правила,
+// которые нарушили заметки
+func (c *CoreService) checkStops(stops []models.TripStop) ([]string, error) {
+	if len(stops) > maxTripStops {
+		return nil, fmt.Errorf("%w: trip can't have more than %d stops", storage.ErrValidation, maxTripStops)
+	}
+
+	var flagged []string
+	seen := make(map[uuid.UUID]bool, len(stops))
+	for i := range stops {
+		stop := &stops[i]
+		if stop.Id == uuid.Nil {
+			stop.Id = uuid.New()
+		}
+		if seen[stop.Id] {
+			return nil, fmt.Errorf("%w: stop %s is listed twice", storage.ErrValidation, stop.Id)
+		}
+		seen[stop.Id] = true
+		stop.Position = i
+
+		stop.Place = strings.TrimSpace(stop.Place)
+		if stop.Place == "" && !stop.PlaceId.Valid {
+			return nil, fmt.Errorf("%w: stop %d needs a place", storage.ErrValidation, i+1)
+		}
+		if stop.ArrivalAt != nil && stop.DepartureAt != nil && stop.DepartureAt.Before(*stop.ArrivalAt) {
+			return nil, fmt.Errorf("%w: stop %d departs before it arrives", storage.ErrValidation, i+1)
+		}
+
+		notes, notesFlagged, err := c.checkText(TextTripStopNotes, stop.Notes)
+		if err != nil {
+			return nil, err
+		}
+		stop.Notes = notes
+		flagged = append(flagged, notesFlagged...)
+	}
+	return flagged, nil
+}
+
+// GetTripTimeline возвращает фото поездки, видимые viewerId, сгруппированные по дням и остановкам.
+// Дни считаются в часовом поясе loc, фото без времени съемки попадают в день загрузки.
+// Время съемки берется только из метаданных, переданных клиентом при загрузке: EXIF не разбирается
+func (c *CoreService) GetTripTimeline(ctx context.Context, tripId uuid.UUID, viewerId uuid.UUID, loc *time.Location) (models.Timeline, error) {
+	trip, err := c.GetTripById(ctx, tripId)
+	if err != nil {
+		return models.Timeline{}, err
+	}
+	stops, err := c.storage.GetTripStops(ctx, tripId)
+	if err != nil {
+		return models.Timeline{}, err
+	}
+	photos, err := c.storage.GetPhotosByTripId(ctx, tripId, viewerId)
+	if err != nil {
+		return models.Timeline{}, fmt.Errorf("failed to get trip photos: %w", err)
+	}
+	return models.Timeline{Trip: trip, Stops: stops, Days: buildTimeline(photos, stops, loc)}, nil
+}
+
+// photoTime - время, по которому фото попадает в хронологию
+func photoTime(photo models.Photo) time.Time {
+	if photo.TakenAt != nil {
+		return *photo.TakenAt
+	}
+	return photo.CreatedAt
+}
+
+// buildTimeline раскладывает фото по дням, а внутри дня - по остановкам, на которых они сняты.
+// Дни и группы идут в хронологическом порядке
+func buildTimeline(photos []models.Photo, stops []models.TripStop, loc *time.Location) []models.TimelineDay {
+	sort.SliceStable(photos, func(i, j int) bool {
+		return photoTime(photos[i]).Before(photoTime(photos[j]))
+	})
+
+	var days []models.TimelineDay
+	// Индекс группы внутри текущего дня по индексу остановки; -1 - фото вне остановок
+	var groups map[int]int
+	for _, photo := range photos {
+		date := photoTime(photo).In(loc).Format(tripDateLayout)
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, models.TimelineDay{Date: date})
+			groups = make(map[int]int)
+		}
+		day := &days[len(days)-1]
+
+		stop := stopAt(stops, photoTime(photo))
+		group, ok := groups[stop]
+		if !ok {
+			entry := models.TimelineStop{}
+			if stop >= 0 {
+				entry.StopId = uuid.NullUUID{UUID: stops[stop].Id, Valid: true}
+				entry.Place = stops[stop].Place
+			}
+			day.Stops = append(day.Stops, entry)
+			group = len(day.Stops) - 1
+			groups[stop] = group
+		}
+		day.Stops[group].Photos = append(day.Stops[group].Photos, photo)
+	}
+	return days
+}
+
+// stopAt возвращает индекс остановки, на которой пользователь был в момент at, или -1.
+// Остановка без времени прибытия начинается с начала поездки, без времени отъезда - длится
+// до ее конца; остановки совсем без времени не учитываются. Если остановки пересекаются,
+// выбирается более поздняя по маршруту
+func stopAt(stops []models.TripStop, at time.Time) int {
+	for i := len(stops) - 1; i >= 0; i-- {
+		stop := stops[i]
+		if stop.ArrivalAt == nil && stop.DepartureAt == nil {
+			continue
+		}
+		if stop.ArrivalAt != nil && at.Before(*stop.ArrivalAt) {
+			continue
+		}
+		if stop.DepartureAt != nil && at.After(*stop.DepartureAt) {
+			continue
+		}
+		return i
+	}
+	return -1
+}
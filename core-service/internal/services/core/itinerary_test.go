@@ -0,0 +1,217 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+var (
+	moscow = time.FixedZone("MSK", 3*60*60)
+	tokyo  = time.FixedZone("JST", 9*60*60)
+)
+
+// at возвращает время 11 февраля 2024 года по UTC
+func at(hour int, minute int) *time.Time {
+	t := time.Date(2024, 2, 11, hour, minute, 0, 0, time.UTC)
+	return &t
+}
+
+func stop(place string, arrival *time.Time, departure *time.Time) models.TripStop {
+	return models.TripStop{Id: uuid.New(), Place: place, ArrivalAt: arrival, DepartureAt: departure}
+}
+
+func TestStopAt(t *testing.T) {
+	irkutsk := stop("Иркутск", at(6, 0), at(10, 0))
+	listvyanka := stop("Листвянка", at(9, 0), at(18, 0))
+	tests := []struct {
+		name  string
+		stops []models.TripStop
+		at    *time.Time
+		want  int
+	}{
+		{"no stops", nil, at(12, 0), -1},
+		{"inside stop", []models.TripStop{irkutsk}, at(7, 0), 0},
+		{"before arrival", []models.TripStop{irkutsk}, at(5, 59), -1},
+		{"after departure", []models.TripStop{irkutsk}, at(10, 1), -1},
+		{"at arrival", []models.TripStop{irkutsk}, at(6, 0), 0},
+		{"at departure", []models.TripStop{irkutsk}, at(10, 0), 0},
+		{"overlap picks later stop", []models.TripStop{irkutsk, listvyanka}, at(9, 30), 1},
+		{"before overlap", []models.TripStop{irkutsk, listvyanka}, at(8, 0), 0},
+		{"after overlap", []models.TripStop{irkutsk, listvyanka}, at(12, 0), 1},
+		{"gap between stops", []models.TripStop{stop("Иркутск", at(6, 0), at(8, 0)), stop("Листвянка", at(9, 0), at(18, 0))}, at(8, 30), -1},
+		{"open arrival", []models.TripStop{stop("Иркутск", nil, at(8, 0))}, at(0, 0), 0},
+		{"open arrival after departure", []models.TripStop{stop("Иркутск", nil, at(8, 0))}, at(8, 1), -1},
+		{"open departure", []models.TripStop{stop("Листвянка", at(9, 0), nil)}, at(23, 59), 0},
+		{"open departure before arrival", []models.TripStop{stop("Листвянка", at(9, 0), nil)}, at(8, 59), -1},
+		{"stop without times is skipped", []models.TripStop{stop("Байкал", nil, nil)}, at(12, 0), -1},
+		{"later open stop wins over earlier", []models.TripStop{stop("Иркутск", nil, nil), irkutsk, stop("Листвянка", at(7, 0), nil)}, at(7, 30), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stopAt(tt.stops, *tt.at); got != tt.want {
+				t.Errorf("stopAt(%s) = %d, want %d", tt.at.Format(time.Kitchen), got, tt.want)
+			}
+		})
+	}
+}
+
+func photo(name string, takenAt *time.Time, createdAt time.Time) models.Photo {
+	return models.Photo{Id: uuid.New(), Description: name, TakenAt: takenAt, CreatedAt: createdAt}
+}
+
+// timelineString описывает хронологию одной строкой: дни через "; ", группы через ", ",
+// группа - место остановки ("-" для фото вне остановок) и фото в квадратных скобках
+func timelineString(days []models.TimelineDay) string {
+	var b strings.Builder
+	for i, day := range days {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(day.Date + ":")
+		for j, group := range day.Stops {
+			if j > 0 {
+				b.WriteString(",")
+			}
+			place := "-"
+			if group.StopId.Valid {
+				place = group.Place
+			}
+			names := make([]string, 0, len(group.Photos))
+			for _, p := range group.Photos {
+				names = append(names, p.Description)
+			}
+			fmt.Fprintf(&b, " %s [%s]", place, strings.Join(names, " "))
+		}
+	}
+	return b.String()
+}
+
+func TestBuildTimeline(t *testing.T) {
+	irkutsk := stop("Иркутск", at(6, 0), at(10, 0))
+	listvyanka := stop("Листвянка", at(9, 0), nil)
+	stops := []models.TripStop{irkutsk, listvyanka}
+	uploaded := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		photos []models.Photo
+		stops  []models.TripStop
+		loc    *time.Location
+		want   string
+	}{
+		{
+			name:  "no photos",
+			stops: stops,
+			loc:   time.UTC,
+			want:  "",
+		},
+		{
+			name: "photos are sorted by time",
+			photos: []models.Photo{
+				photo("b", at(7, 30), uploaded),
+				photo("a", at(7, 0), uploaded),
+			},
+			stops: stops,
+			loc:   time.UTC,
+			want:  "2024-02-11: Иркутск [a b]",
+		},
+		{
+			name: "overlap goes to later stop",
+			photos: []models.Photo{
+				photo("a", at(8, 0), uploaded),
+				photo("b", at(9, 30), uploaded),
+				photo("c", at(20, 0), uploaded),
+			},
+			stops: stops,
+			loc:   time.UTC,
+			want:  "2024-02-11: Иркутск [a], Листвянка [b c]",
+		},
+		{
+			name: "photos outside stops",
+			photos: []models.Photo{
+				photo("a", at(5, 0), uploaded),
+				photo("b", at(7, 0), uploaded),
+			},
+			stops: stops,
+			loc:   time.UTC,
+			want:  "2024-02-11: - [a], Иркутск [b]",
+		},
+		{
+			name: "overlap follows route order, not arrival time",
+			photos: []models.Photo{
+				photo("a", at(7, 0), uploaded),
+				photo("b", at(9, 30), uploaded),
+				photo("c", at(9, 45), uploaded),
+			},
+			stops: []models.TripStop{stop("Листвянка", at(9, 0), nil), irkutsk},
+			loc:   time.UTC,
+			want:  "2024-02-11: Иркутск [a b c]",
+		},
+		{
+			name: "photo without taken_at uses created_at",
+			photos: []models.Photo{
+				photo("a", at(7, 0), uploaded),
+				photo("uploaded", nil, *at(8, 0)),
+				photo("later", nil, uploaded),
+			},
+			stops: stops,
+			loc:   time.UTC,
+			want:  "2024-02-11: Иркутск [a uploaded]; 2024-03-01: Листвянка [later]",
+		},
+		{
+			name: "same stop on different days is split",
+			photos: []models.Photo{
+				photo("a", at(20, 0), uploaded),
+				photo("b", at(28, 0), uploaded),
+			},
+			stops: stops,
+			loc:   time.UTC,
+			want:  "2024-02-11: Листвянка [a]; 2024-02-12: Листвянка [b]",
+		},
+		{
+			// 22:30 UTC - уже следующий день в Москве
+			name: "day boundary uses location",
+			photos: []models.Photo{
+				photo("a", at(20, 0), uploaded),
+				photo("b", at(22, 30), uploaded),
+			},
+			stops: stops,
+			loc:   moscow,
+			want:  "2024-02-11: Листвянка [a]; 2024-02-12: Листвянка [b]",
+		},
+		{
+			name: "same photos in utc stay on one day",
+			photos: []models.Photo{
+				photo("a", at(20, 0), uploaded),
+				photo("b", at(22, 30), uploaded),
+			},
+			stops: stops,
+			loc:   time.UTC,
+			want:  "2024-02-11: Листвянка [a b]",
+		},
+		{
+			// 14:00 UTC - 23:00 в Токио, 15:30 UTC - уже 12 февраля
+			name: "stop is split by local midnight",
+			photos: []models.Photo{
+				photo("a", at(14, 0), uploaded),
+				photo("b", at(15, 30), uploaded),
+			},
+			stops: stops,
+			loc:   tokyo,
+			want:  "2024-02-11: Листвянка [a]; 2024-02-12: Листвянка [b]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := timelineString(buildTimeline(tt.photos, tt.stops, tt.loc))
+			if got != tt.want {
+				t.Errorf("buildTimeline() = %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
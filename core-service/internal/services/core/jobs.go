@@ -0,0 +1,102 @@
+// Фоновые задачи сервиса и управление очередью
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/jobs"
+	"github.com/shameoff/more-than-trip/core/internal/lib/imaging"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// Типы фоновых задач
+const (
+	JobPhotoThumbnail = "photo.thumbnail"
+)
+
+const (
+	thumbnailSize    = 320
+	thumbnailQuality = 80
+
+	defaultJobsLimit = 100
+)
+
+// photoJob - параметры задач, относящихся к одному фото
+type photoJob struct {
+	PhotoId uuid.UUID
+}
+
+// RegisterJobs регистрирует обработчики фоновых задач сервиса
+func (c *CoreService) RegisterJobs(r *jobs.Runner) {
+	jobs.Handle(r, JobPhotoThumbnail, c.buildThumbnail)
+}
+
+// buildThumbnail строит уменьшенную копию фото и сохраняет ее рядом с оригиналом
+func (c *CoreService) buildThumbnail(ctx context.Context, payload photoJob) error {
+	photo, err := c.storage.GetPhoto(ctx, payload.PhotoId, uuid.Nil)
+	if errors.Is(err, storage.ErrNotFound) {
+		// Фото удалили, пока задача ждала очереди
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get photo: %w", err)
+	}
+	if photo.ThumbnailUrl != "" {
+		return nil
+	}
+
+	original, err := c.s3Storage.DownloadPhoto(ctx, photo.ImgUrl)
+	if err != nil {
+		return err
+	}
+	thumbnail, err := imaging.Thumbnail(original, thumbnailSize, thumbnailQuality)
+	if err != nil {
+		// Файл не является поддерживаемым изображением, повтор не поможет
+		return jobs.Permanent(err)
+	}
+
+	name := path.Base(photo.ImgUrl)
+	fileName := fmt.Sprintf("thumb_%s.jpg", strings.TrimSuffix(name, path.Ext(name)))
+	thumbnailUrl, err := c.s3Storage.PutObject(ctx, thumbnail, fileName, "image/jpeg")
+	if err != nil {
+		return err
+	}
+
+	err = c.storage.SetPhotoThumbnail(ctx, photo.Id, photo.ImgUrl, thumbnailUrl)
+	if errors.Is(err, storage.ErrNotFound) {
+		// Фото удалили или заменили файл; для нового файла поставлена своя задача
+		return nil
+	}
+	return err
+}
+
+// GetJobs возвращает последние задачи в состоянии status, по умолчанию - упавшие окончательно.
+// Очередь задач видят только модераторы
+func (c *CoreService) GetJobs(ctx context.Context, callerId uuid.UUID, status string) ([]models.Job, error) {
+	if err := c.authorizeModerator(ctx, callerId); err != nil {
+		return nil, err
+	}
+	if status == "" {
+		status = models.JobDead
+	}
+	switch status {
+	case models.JobQueued, models.JobRunning, models.JobDone, models.JobDead:
+	default:
+		return nil, fmt.Errorf("%w: unknown job status %q", storage.ErrValidation, status)
+	}
+	return c.storage.GetJobs(ctx, status, defaultJobsLimit)
+}
+
+// RetryJob возвращает упавшую окончательно задачу в очередь; доступно только модераторам
+func (c *CoreService) RetryJob(ctx context.Context, callerId uuid.UUID, jobId uuid.UUID) error {
+	if err := c.authorizeModerator(ctx, callerId); err != nil {
+		return err
+	}
+	return c.storage.RequeueDeadJob(ctx, jobId)
+}
@@ -0,0 +1,179 @@
+// Бизнес-логика модерации фото
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+const (
+	defaultModerationQueueLimit = 50
+	maxModerationQueueLimit     = 200
+	maxModerationReasonLength   = 500
+)
+
+// moderationTransitions - из каких статусов фото можно перевести в каждый статус
+var moderationTransitions = map[string][]string{
+	models.ModerationApproved: {models.ModerationPending, models.ModerationRejected, models.ModerationHidden},
+	models.ModerationRejected: {models.ModerationPending},
+	models.ModerationHidden:   {models.ModerationApproved},
+}
+
+// initialModerationStatus - статус нового фото: доверенным в регионе пользователям
+// модерация не нужна, остальные фото ждут решения модератора
+func (c *CoreService) initialModerationStatus(ctx context.Context, userId uuid.UUID, regionId uuid.UUID) (string, error) {
+	trusted, err := c.storage.IsTrustedInRegion(ctx, userId, regionId)
+	if err != nil {
+		return "", err
+	}
+	if trusted {
+		return models.ModerationApproved, nil
+	}
+	return models.ModerationPending, nil
+}
+
+// authorizeModerator возвращает storage.ErrForbidden, если пользователь не модератор
+func (c *CoreService) authorizeModerator(ctx context.Context, userId uuid.UUID) error {
+	moderator, err := c.storage.IsModerator(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("failed to check moderator: %w", err)
+	}
+	if !moderator {
+		return fmt.Errorf("%w: moderator role required", storage.ErrForbidden)
+	}
+	return nil
+}
+
+// canSeePhoto сообщает, видно ли фото пользователю. Автор и модераторы видят фото всегда,
+// остальные - только одобренные фото с подходящей видимостью, если с автором нет блокировки
+func (c *CoreService) canSeePhoto(ctx context.Context, photo models.Photo, viewerId uuid.UUID) (bool, error) {
+	if photo.UserId == viewerId {
+		return true, nil
+	}
+	if photo.ModerationStatus == models.ModerationApproved {
+		if photo.Visibility == models.VisibilityPublic && viewerId == uuid.Nil {
+			return true, nil
+		}
+		visible, err := c.storage.IsPhotoVisibleTo(ctx, photo.Id, viewerId)
+		if err != nil || visible {
+			return visible, err
+		}
+	}
+	if viewerId == uuid.Nil {
+		return false, nil
+	}
+	return c.storage.IsModerator(ctx, viewerId)
+}
+
+// GetModerationQueue возвращает фото, ожидающие решения, начиная с самых старых.
+// regionId ограничивает очередь одним регионом, uuid.Nil - все регионы
+func (c *CoreService) GetModerationQueue(ctx context.Context, moderatorId uuid.UUID, regionId uuid.UUID, limit int) ([]models.Photo, error) {
+	if err := c.authorizeModerator(ctx, moderatorId); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultModerationQueueLimit
+	}
+	limit = min(limit, maxModerationQueueLimit)
+
+	photos, err := c.storage.GetModerationQueue(ctx, regionId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get moderation queue: %w", err)
+	}
+	return photos, nil
+}
+
+// ModeratePhoto переводит фото в статус status. Для отклонения и скрытия нужна причина.
+// Партнеры узнают о фото из события photo.created, когда его впервые одобряют
+func (c *CoreService) ModeratePhoto(ctx context.Context, moderatorId uuid.UUID, photoId uuid.UUID, status string, reason string) (models.Photo, error) {
+	if err := c.authorizeModerator(ctx, moderatorId); err != nil {
+		return models.Photo{}, err
+	}
+	reason = strings.TrimSpace(reason)
+	if len([]rune(reason)) > maxModerationReasonLength {
+		return models.Photo{}, fmt.Errorf("%w: reason is longer than %d characters", storage.ErrValidation, maxModerationReasonLength)
+	}
+	if status != models.ModerationApproved && reason == "" {
+		return models.Photo{}, fmt.Errorf("%w: reason is required to reject or hide a photo", storage.ErrValidation)
+	}
+
+	var moderated models.Photo
+	err := c.storage.WithTx(ctx, func(ctx context.Context) error {
+		photo, err := c.storage.GetPhoto(ctx, photoId, uuid.Nil)
+		if err != nil {
+			return err
+		}
+		allowed, ok := moderationTransitions[status]
+		if !ok {
+			return fmt.Errorf("%w: unknown moderation status %q", storage.ErrValidation, status)
+		}
+		if !slices.Contains(allowed, photo.ModerationStatus) {
+			return fmt.Errorf("%w: photo is %s and can't become %s", storage.ErrConflict, photo.ModerationStatus, status)
+		}
+
+		err = c.storage.ModeratePhoto(ctx, photoId, models.ModerationDecision{Status: status, Reason: reason, ModeratorId: moderatorId})
+		if err != nil {
+			return err
+		}
+		moderated, err = c.storage.GetPhoto(ctx, photoId, uuid.Nil)
+		if err != nil {
+			return err
+		}
+
+		// О непубличных фото партнеры не узнают
+		if moderated.Visibility != models.VisibilityPublic {
+			return nil
+		}
+		eventType := models.EventPhotoUpdated
+		if photo.ModerationStatus == models.ModerationPending && status == models.ModerationApproved {
+			eventType = models.EventPhotoCreated
+		}
+		return c.emitEvent(ctx, eventType, moderated)
+	})
+	if err != nil {
+		return models.Photo{}, fmt.Errorf("failed to moderate photo: %w", err)
+	}
+	return moderated, nil
+}
+
+// GetTrustedUsers возвращает пользователей, фото которых в регионе одобряются без очереди
+func (c *CoreService) GetTrustedUsers(ctx context.Context, moderatorId uuid.UUID, regionId uuid.UUID) ([]models.TrustedUser, error) {
+	if err := c.authorizeModerator(ctx, moderatorId); err != nil {
+		return nil, err
+	}
+	return c.storage.GetTrustedUsers(ctx, regionId)
+}
+
+// TrustUser включает автоодобрение фото пользователя в регионе и во вложенных регионах
+func (c *CoreService) TrustUser(ctx context.Context, moderatorId uuid.UUID, regionId uuid.UUID, userId uuid.UUID) error {
+	if err := c.authorizeModerator(ctx, moderatorId); err != nil {
+		return err
+	}
+	return c.storage.AddTrustedUser(ctx, models.TrustedUser{
+		RegionId:  regionId,
+		UserId:    userId,
+		GrantedBy: uuid.NullUUID{UUID: moderatorId, Valid: true},
+	})
+}
+
+func (c *CoreService) DistrustUser(ctx context.Context, moderatorId uuid.UUID, regionId uuid.UUID, userId uuid.UUID) error {
+	if err := c.authorizeModerator(ctx, moderatorId); err != nil {
+		return err
+	}
+	return c.storage.RemoveTrustedUser(ctx, regionId, userId)
+}
+
+// SetModerator выдает или отзывает роль модератора. Менять роли могут только модераторы;
+// первого модератора назначают напрямую в БД (user_account.is_moderator)
+func (c *CoreService) SetModerator(ctx context.Context, callerId uuid.UUID, userId uuid.UUID, moderator bool) error {
+	if err := c.authorizeModerator(ctx, callerId); err != nil {
+		return err
+	}
+	return c.storage.SetModerator(ctx, userId, moderator)
+}
@@ -0,0 +1,155 @@
+// Бизнес-логика уведомлений
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/lib/logger/sl"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+const (
+	defaultNotificationLimit = 50
+	maxNotificationLimit     = 200
+)
+
+// GetNotifications возвращает уведомления пользователя и число непрочитанных
+func (c *CoreService) GetNotifications(ctx context.Context, userId uuid.UUID, unreadOnly bool, limit int) (models.NotificationInbox, error) {
+	if limit <= 0 {
+		limit = defaultNotificationLimit
+	}
+	limit = min(limit, maxNotificationLimit)
+
+	var inbox models.NotificationInbox
+	items, err := c.storage.GetNotifications(ctx, userId, unreadOnly, limit)
+	if err != nil {
+		return inbox, fmt.Errorf("failed to get notifications: %w", err)
+	}
+	unread, err := c.storage.CountUnreadNotifications(ctx, userId)
+	if err != nil {
+		return inbox, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	inbox.Items = items
+	inbox.UnreadCount = unread
+	return inbox, nil
+}
+
+func (c *CoreService) MarkNotificationRead(ctx context.Context, userId uuid.UUID, notificationId uuid.UUID) error {
+	return c.storage.MarkNotificationRead(ctx, userId, notificationId)
+}
+
+func (c *CoreService) MarkAllNotificationsRead(ctx context.Context, userId uuid.UUID) (int, error) {
+	return c.storage.MarkAllNotificationsRead(ctx, userId)
+}
+
+// GetNotificationPreferences возвращает настройки по всем типам уведомлений;
+// типы, которые пользователь не настраивал, включены
+func (c *CoreService) GetNotificationPreferences(ctx context.Context, userId uuid.UUID) ([]models.NotificationPreference, error) {
+	saved, err := c.storage.GetNotificationPreferences(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	enabled := make(map[string]bool, len(saved))
+	for _, preference := range saved {
+		enabled[preference.Type] = preference.Enabled
+	}
+	preferences := make([]models.NotificationPreference, 0, len(models.NotificationTypes))
+	for _, notificationType := range models.NotificationTypes {
+		value, ok := enabled[notificationType]
+		preferences = append(preferences, models.NotificationPreference{Type: notificationType, Enabled: !ok || value})
+	}
+	return preferences, nil
+}
+
+// SetNotificationPreferences сохраняет переданные настройки, остальные типы не меняются
+func (c *CoreService) SetNotificationPreferences(ctx context.Context, userId uuid.UUID, preferences []models.NotificationPreference) ([]models.NotificationPreference, error) {
+	for _, preference := range preferences {
+		if !slices.Contains(models.NotificationTypes, preference.Type) {
+			return nil, fmt.Errorf("%w: unknown notification type %q", storage.ErrValidation, preference.Type)
+		}
+	}
+
+	err := c.storage.WithTx(ctx, func(ctx context.Context) error {
+		for _, preference := range preferences {
+			if err := c.storage.SetNotificationPreference(ctx, userId, preference); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set notification preferences: %w", err)
+	}
+	return c.GetNotificationPreferences(ctx, userId)
+}
+
+// notify создает уведомления о событиях. Уведомления вторичны по отношению к действию,
+// которое их вызвало, поэтому ошибки только пишутся в лог. События о собственных
+// действиях получателя пропускаются
+func (c *CoreService) notify(ctx context.Context, events ...models.NotificationEvent) {
+	for _, event := range events {
+		if event.UserId == uuid.Nil || event.UserId == event.ActorId {
+			continue
+		}
+		if err := c.storage.AddNotification(ctx, event); err != nil {
+			c.log.Error("failed to add notification",
+				slog.String("type", event.Type),
+				slog.String("user_id", event.UserId.String()),
+				sl.Err(err))
+		}
+	}
+}
+
+// notifyComment оповещает о новом комментарии упомянутых пользователей, автора
+// родительского комментария и владельцев объекта. Каждый получатель получает
+// одно уведомление, самое конкретное из подходящих
+func (c *CoreService) notifyComment(ctx context.Context, comment models.Comment, parentAuthor uuid.UUID) {
+	notified := map[uuid.UUID]bool{comment.UserId: true}
+	var events []models.NotificationEvent
+	add := func(userId uuid.UUID, notificationType string, subjectId uuid.UUID) {
+		if notified[userId] {
+			return
+		}
+		notified[userId] = true
+		events = append(events, models.NotificationEvent{
+			UserId:    userId,
+			Type:      notificationType,
+			SubjectId: subjectId,
+			ActorId:   comment.UserId,
+		})
+	}
+
+	for _, userId := range comment.Mentions {
+		add(userId, models.NotificationMention, comment.Id)
+	}
+	if comment.ParentId.Valid {
+		add(parentAuthor, models.NotificationCommentReply, comment.ParentId.UUID)
+	}
+
+	switch comment.Target.Kind {
+	case models.CommentTargetPhoto:
+		photo, err := c.storage.GetPhoto(ctx, comment.Target.Id, uuid.Nil)
+		if err != nil {
+			c.log.Error("failed to get photo for notification", sl.Err(err))
+			break
+		}
+		add(photo.UserId, models.NotificationPhotoComment, photo.Id)
+	case models.CommentTargetTrip:
+		members, err := c.storage.GetTripMembers(ctx, comment.Target.Id)
+		if err != nil {
+			c.log.Error("failed to get trip members for notification", sl.Err(err))
+			break
+		}
+		for _, userId := range members {
+			add(userId, models.NotificationTripComment, comment.Target.Id)
+		}
+	}
+
+	c.notify(ctx, events...)
+}
@@ -0,0 +1,231 @@
+// Доменные события для партнеров: запись в outbox и доставка на вебхуки
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/lib/backoff"
+	"github.com/shameoff/more-than-trip/core/internal/lib/logger/sl"
+	"github.com/shameoff/more-than-trip/core/internal/lib/webhook"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+const defaultDeliveryLogLimit = 100
+
+// WebhookDispatch - настройки доставки событий на вебхуки
+type WebhookDispatch struct {
+	// Как часто диспетчер проверяет новые события и доставки, которым пора повториться
+	PollInterval time.Duration
+	// Сколько событий и доставок обрабатывается за один проход
+	BatchSize int
+	// Время ожидания ответа получателя
+	Timeout time.Duration
+	// После MaxAttempts неудачных попыток доставка помечается failed
+	MaxAttempts int
+	// Пауза перед повтором растет вдвое с каждой попыткой, начиная с RetryBase, но не больше RetryMax
+	RetryBase time.Duration
+	RetryMax  time.Duration
+}
+
+// webhookBody - тело запроса, которое получает партнер
+type webhookBody struct {
+	Id        uuid.UUID
+	Type      string
+	CreatedAt time.Time
+	Data      json.RawMessage
+}
+
+// emitEvent записывает доменное событие в транзакции из ctx, поэтому его нужно вызывать
+// внутри WithTx вместе с изменением, о котором сообщает событие
+func (c *CoreService) emitEvent(ctx context.Context, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	return c.storage.AddOutboxEvent(ctx, models.OutboxEvent{
+		Id:      uuid.New(),
+		Type:    eventType,
+		Payload: payload,
+	})
+}
+
+// CreateWebhook регистрирует адрес партнера от имени модератора callerId. Адрес должен вести
+// на публичный хост, чтобы через вебхук нельзя было обращаться к внутренней сети.
+// Если секрет не передан, он генерируется; секрет возвращается только в ответе на регистрацию
+func (c *CoreService) CreateWebhook(ctx context.Context, callerId uuid.UUID, data models.Webhook) (models.Webhook, error) {
+	if err := c.authorizeModerator(ctx, callerId); err != nil {
+		return models.Webhook{}, err
+	}
+	target, err := url.Parse(data.Url)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return models.Webhook{}, fmt.Errorf("%w: webhook url must be an absolute http(s) url", storage.ErrValidation)
+	}
+	if err := webhook.CheckTarget(ctx, net.DefaultResolver, target.Hostname()); err != nil {
+		return models.Webhook{}, fmt.Errorf("%w: webhook url must point to a public host", storage.ErrValidation)
+	}
+	for _, eventType := range data.EventTypes {
+		if !slices.Contains(models.EventTypes, eventType) {
+			return models.Webhook{}, fmt.Errorf("%w: unknown event type %q", storage.ErrValidation, eventType)
+		}
+	}
+	if data.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return models.Webhook{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		data.Secret = hex.EncodeToString(secret)
+	}
+
+	data.Id = uuid.New()
+	data.Active = true
+	data.CreatedAt = time.Now().UTC()
+	if err := c.storage.CreateWebhook(ctx, data); err != nil {
+		return models.Webhook{}, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return data, nil
+}
+
+// Вебхуки и журнал доставок доступны только модераторам
+
+func (c *CoreService) GetWebhooks(ctx context.Context, callerId uuid.UUID) ([]models.Webhook, error) {
+	if err := c.authorizeModerator(ctx, callerId); err != nil {
+		return nil, err
+	}
+	return c.storage.GetWebhooks(ctx)
+}
+
+func (c *CoreService) DeleteWebhook(ctx context.Context, callerId uuid.UUID, webhookId uuid.UUID) error {
+	if err := c.authorizeModerator(ctx, callerId); err != nil {
+		return err
+	}
+	return c.storage.DeleteWebhook(ctx, webhookId)
+}
+
+// GetWebhookDeliveries возвращает журнал доставок вебхука
+func (c *CoreService) GetWebhookDeliveries(ctx context.Context, callerId uuid.UUID, webhookId uuid.UUID) ([]models.WebhookDelivery, error) {
+	if err := c.authorizeModerator(ctx, callerId); err != nil {
+		return nil, err
+	}
+	return c.storage.GetWebhookDeliveries(ctx, webhookId, defaultDeliveryLogLimit)
+}
+
+func (c *CoreService) GetWebhookAttempts(ctx context.Context, callerId uuid.UUID, deliveryId uuid.UUID) ([]models.WebhookAttempt, error) {
+	if err := c.authorizeModerator(ctx, callerId); err != nil {
+		return nil, err
+	}
+	return c.storage.GetWebhookAttempts(ctx, deliveryId)
+}
+
+// RunWebhookDispatcher раскладывает события из outbox по вебхукам и доставляет их,
+// пока не отменен ctx. Реплики могут работать одновременно: события и доставки
+// забираются с блокировкой, поэтому каждое событие уходит получателю один раз за попытку
+func (c *CoreService) RunWebhookDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(c.webhooks.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		c.dispatchWebhooks(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *CoreService) dispatchWebhooks(ctx context.Context) {
+	for {
+		fanned, err := c.storage.FanOutOutboxEvents(ctx, c.webhooks.BatchSize)
+		if err != nil {
+			c.log.Error("failed to fan out outbox events", sl.Err(err))
+			return
+		}
+		if fanned < c.webhooks.BatchSize {
+			break
+		}
+	}
+
+	for ctx.Err() == nil {
+		// Доставка откладывается на время запроса с запасом, чтобы ее не взяла другая реплика
+		deliveries, err := c.storage.ClaimWebhookDeliveries(ctx, c.webhooks.BatchSize, 2*c.webhooks.Timeout)
+		if err != nil {
+			c.log.Error("failed to claim webhook deliveries", sl.Err(err))
+			return
+		}
+
+		// Медленный получатель не должен задерживать доставку остальным
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.deliverWebhook(ctx, delivery)
+			}()
+		}
+		wg.Wait()
+
+		if len(deliveries) < c.webhooks.BatchSize {
+			return
+		}
+	}
+}
+
+func (c *CoreService) deliverWebhook(ctx context.Context, delivery models.WebhookDelivery) {
+	body, err := json.Marshal(webhookBody{
+		Id:        delivery.Event.Id,
+		Type:      delivery.Event.Type,
+		CreatedAt: delivery.Event.CreatedAt,
+		Data:      delivery.Event.Payload,
+	})
+	if err != nil {
+		c.log.Error("failed to encode webhook body", slog.String("delivery_id", delivery.Id.String()), sl.Err(err))
+		return
+	}
+
+	attempt := models.WebhookAttempt{DeliveryId: delivery.Id, AttemptedAt: time.Now().UTC()}
+	result, sendErr := c.webhookClient.Send(ctx, delivery.Url, delivery.Secret, webhook.Message{
+		EventId:   delivery.Event.Id.String(),
+		EventType: delivery.Event.Type,
+		Body:      body,
+	})
+	attempt.StatusCode = result.StatusCode
+	attempt.Response = result.Response
+	attempt.Duration = result.Duration
+
+	status := models.DeliveryDelivered
+	nextAttemptAt := attempt.AttemptedAt
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+		status = models.DeliveryPending
+		nextAttemptAt = attempt.AttemptedAt.Add(backoff.Exponential(c.webhooks.RetryBase, c.webhooks.RetryMax, delivery.Attempts))
+		if delivery.Attempts >= c.webhooks.MaxAttempts {
+			status = models.DeliveryFailed
+		}
+		c.log.Warn("webhook delivery failed",
+			slog.String("delivery_id", delivery.Id.String()),
+			slog.String("event_type", delivery.Event.Type),
+			slog.Int("attempt", delivery.Attempts),
+			sl.Err(sendErr))
+	}
+
+	// Результат записывается и после отмены ctx, иначе попытка потеряется из журнала
+	saveCtx := context.WithoutCancel(ctx)
+	err = c.storage.WithTx(saveCtx, func(ctx context.Context) error {
+		return c.storage.CompleteWebhookAttempt(ctx, attempt, status, nextAttemptAt)
+	})
+	if err != nil {
+		c.log.Error("failed to save webhook attempt", slog.String("delivery_id", delivery.Id.String()), sl.Err(err))
+	}
+}
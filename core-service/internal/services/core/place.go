@@ -0,0 +1,50 @@
+// Бизнес-логика, связанная со справочником мест
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+func (c *CoreService) CreatePlace(ctx context.Context, place models.Place) error {
+	return c.storage.CreatePlace(ctx, place)
+}
+
+func (c *CoreService) DeletePlace(ctx context.Context, placeId uuid.UUID) error {
+	return c.storage.DeletePlace(ctx, placeId)
+}
+
+func (c *CoreService) GetPlaceById(ctx context.Context, placeId uuid.UUID) (models.Place, error) {
+	return c.storage.GetPlaceById(ctx, placeId)
+}
+
+func (c *CoreService) GetPlaces(ctx context.Context, regionId uuid.UUID) ([]models.Place, error) {
+	return c.storage.GetPlaces(ctx, regionId)
+}
+
+func (c *CoreService) UpdatePlace(ctx context.Context, placeId uuid.UUID, data models.Place) error {
+	return c.storage.UpdatePlace(ctx, placeId, data)
+}
+
+// checkPlaceRegion проверяет, что место из справочника существует и находится в регионе фото или поездки
+func (c *CoreService) checkPlaceRegion(ctx context.Context, placeId uuid.NullUUID, regionId uuid.UUID) error {
+	if !placeId.Valid {
+		return nil
+	}
+	place, err := c.storage.GetPlaceById(ctx, placeId.UUID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("%w: place %s does not exist", storage.ErrValidation, placeId.UUID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get place: %w", err)
+	}
+	if place.RegionId != regionId {
+		return fmt.Errorf("%w: place %s is not in region %s", storage.ErrValidation, place.Id, regionId)
+	}
+	return nil
+}
@@ -3,17 +3,33 @@ package core
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
 )
 
 func (c *CoreService) CreateRegion(ctx context.Context, region models.Region) error {
+	if region.Id == uuid.Nil {
+		region.Id = uuid.New()
+	}
+	if err := c.validateRegion(ctx, region.Id, &region); err != nil {
+		return err
+	}
 	return c.storage.CreateRegion(ctx, region)
 }
 
+// DeleteRegion удаляет регион. Если на регион ссылаются фото, поездки, места или
+// дочерние регионы, возвращается ошибка storage.ErrConflict
 func (c *CoreService) DeleteRegion(ctx context.Context, regionId uuid.UUID) error {
-	return c.storage.DeleteRegion(ctx, regionId)
+	err := c.storage.DeleteRegion(ctx, regionId)
+	if errors.Is(err, storage.ErrConflict) {
+		return fmt.Errorf("region is used by photos, trips, places or child regions: %w", err)
+	}
+	return err
 }
 
 func (c *CoreService) GetRegionById(ctx context.Context, regionId uuid.UUID) (models.Region, error) {
@@ -28,6 +44,54 @@ func (c *CoreService) GetRegions(ctx context.Context) ([]models.Region, error) {
 	return c.storage.GetRegions(ctx)
 }
 
+func (c *CoreService) GetRegionChildren(ctx context.Context, parentId uuid.UUID) ([]models.Region, error) {
+	return c.storage.GetRegionChildren(ctx, parentId)
+}
+
 func (c *CoreService) UpdateRegion(ctx context.Context, regionId uuid.UUID, data models.Region) error {
+	if err := c.validateRegion(ctx, regionId, &data); err != nil {
+		return err
+	}
 	return c.storage.UpdateRegion(ctx, regionId, data)
 }
+
+// validateRegion проверяет обязательные поля и положение региона в иерархии:
+// родитель должен быть уровнем выше, а уже существующие дочерние регионы — уровнем ниже
+func (c *CoreService) validateRegion(ctx context.Context, regionId uuid.UUID, region *models.Region) error {
+	region.ObjectKey = strings.TrimSpace(region.ObjectKey)
+	if region.ObjectKey == "" {
+		return fmt.Errorf("%w: region object key is required", storage.ErrValidation)
+	}
+	if region.Kind == "" {
+		region.Kind = models.RegionKindRegion
+	}
+	depth, ok := models.RegionKindDepth[region.Kind]
+	if !ok {
+		return fmt.Errorf("%w: unknown region kind %q", storage.ErrValidation, region.Kind)
+	}
+
+	if region.ParentId.Valid {
+		parent, err := c.storage.GetRegionById(ctx, region.ParentId.UUID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("%w: parent region %s does not exist", storage.ErrValidation, region.ParentId.UUID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get parent region: %w", err)
+		}
+		if models.RegionKindDepth[parent.Kind] >= depth {
+			return fmt.Errorf("%w: region of kind %q can't be nested into %q", storage.ErrValidation, region.Kind, parent.Kind)
+		}
+	}
+
+	children, err := c.storage.GetRegionChildren(ctx, regionId)
+	if err != nil {
+		return fmt.Errorf("failed to get region children: %w", err)
+	}
+	for _, child := range children {
+		if models.RegionKindDepth[child.Kind] <= depth {
+			return fmt.Errorf("%w: region of kind %q can't contain %q", storage.ErrValidation, region.Kind, child.Kind)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+// Бизнес-логика определения региона фото по границам регионов
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/lib/geo"
+	"github.com/shameoff/more-than-trip/core/internal/lib/logger/sl"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+type regionShape struct {
+	regionId uuid.UUID
+	depth    int
+	shape    geo.MultiPolygon
+}
+
+// SetRegionBoundary сохраняет границу региона из GeoJSON (Polygon, MultiPolygon, Feature или FeatureCollection).
+// Границы задают только модераторы
+func (c *CoreService) SetRegionBoundary(ctx context.Context, callerId uuid.UUID, regionId uuid.UUID, geoJSON []byte) error {
+	if err := c.authorizeModerator(ctx, callerId); err != nil {
+		return err
+	}
+	shape, err := geo.ParseGeoJSON(geoJSON)
+	if err != nil {
+		return fmt.Errorf("failed to parse region boundary: %w: %w", storage.ErrValidation, err)
+	}
+	box := shape.BBox()
+
+	return c.storage.SetRegionBoundary(ctx, models.RegionBoundary{
+		RegionId: regionId,
+		GeoJSON:  geoJSON,
+		MinLat:   box.MinLat,
+		MinLon:   box.MinLon,
+		MaxLat:   box.MaxLat,
+		MaxLon:   box.MaxLon,
+	})
+}
+
+// ResolveRegion определяет наиболее детальный регион, в границы которого попадают координаты.
+// Если найденный регион является предком уже указанного currentId (например, граница задана
+// только у страны), остается более точный currentId. Второе значение false, если ни одна граница не подошла
+func (c *CoreService) ResolveRegion(ctx context.Context, coords string, currentId uuid.UUID) (uuid.UUID, bool, error) {
+	point, err := geo.ParseCoords(coords)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+
+	boundaries, err := c.storage.GetRegionBoundariesAt(ctx, point.Lat, point.Lon)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+
+	regionId, found := locate(parseRegionShapes(c.log, boundaries), point)
+	if !found || currentId == uuid.Nil || regionId == currentId {
+		return regionId, found, nil
+	}
+
+	parents, err := c.regionParents(ctx)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	if isWithin(parents, currentId, regionId) {
+		return currentId, true, nil
+	}
+	return regionId, true, nil
+}
+
+// ReassignPhotoRegions заново определяет регион всех фото с координатами.
+// Запускать пересчет могут только модераторы. Возвращает количество фото, у которых регион изменился
+func (c *CoreService) ReassignPhotoRegions(ctx context.Context, callerId uuid.UUID) (int, error) {
+	if err := c.authorizeModerator(ctx, callerId); err != nil {
+		return 0, err
+	}
+	boundaries, err := c.storage.GetRegionBoundaries(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get region boundaries: %w", err)
+	}
+	shapes := parseRegionShapes(c.log, boundaries)
+
+	parents, err := c.regionParents(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	photos, err := c.storage.GetPhotosWithCoords(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get photos: %w", err)
+	}
+
+	updated := 0
+	for _, photo := range photos {
+		point, err := geo.ParseCoords(photo.Coords)
+		if err != nil {
+			c.log.Debug("skipping photo with invalid coords", slog.String("photo_id", photo.Id.String()), sl.Err(err))
+			continue
+		}
+		regionId, found := locate(shapes, point)
+		if !found || regionId == photo.RegionId || isWithin(parents, photo.RegionId, regionId) {
+			continue
+		}
+		if err := c.movePhotoToRegion(ctx, photo.Id, regionId); err != nil {
+			return updated, fmt.Errorf("failed to update photo region: %w", err)
+		}
+		updated++
+	}
+
+	return updated, nil
+}
+
+// movePhotoToRegion переносит фото в регион regionId. Доверие автору в прежнем регионе
+// на новый не распространяется, поэтому одобренное фото заново проходит модерацию,
+// если автор не доверенный и в новом регионе. Место из другого региона с фото снимается,
+// как и при ручном изменении фото место должно принадлежать его региону
+func (c *CoreService) movePhotoToRegion(ctx context.Context, photoId uuid.UUID, regionId uuid.UUID) error {
+	return c.storage.WithTx(ctx, func(ctx context.Context) error {
+		before, err := c.storage.GetPhoto(ctx, photoId, uuid.Nil)
+		if err != nil {
+			return err
+		}
+		status, err := c.initialModerationStatus(ctx, before.UserId, regionId)
+		if err != nil {
+			return err
+		}
+		if err := c.storage.UpdatePhotoRegion(ctx, photoId, regionId, status); err != nil {
+			return err
+		}
+		photo, err := c.storage.GetPhoto(ctx, photoId, uuid.Nil)
+		if err != nil {
+			return err
+		}
+		return c.emitPhotoChange(ctx, before, photo)
+	})
+}
+
+// parseRegionShapes разбирает границы и упорядочивает их от самых детальных регионов к странам
+func parseRegionShapes(log *slog.Logger, boundaries []models.RegionBoundary) []regionShape {
+	shapes := make([]regionShape, 0, len(boundaries))
+	for _, b := range boundaries {
+		shape, err := geo.ParseGeoJSON(b.GeoJSON)
+		if err != nil {
+			log.Warn("skipping invalid region boundary", slog.String("region_id", b.RegionId.String()), sl.Err(err))
+			continue
+		}
+		shapes = append(shapes, regionShape{regionId: b.RegionId, depth: models.RegionKindDepth[b.Kind], shape: shape})
+	}
+	sort.SliceStable(shapes, func(i, j int) bool {
+		return shapes[i].depth > shapes[j].depth
+	})
+	return shapes
+}
+
+// regionParents строит отображение регион → родитель для обхода иерархии в памяти
+func (c *CoreService) regionParents(ctx context.Context) (map[uuid.UUID]uuid.NullUUID, error) {
+	regions, err := c.storage.GetRegions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get regions: %w", err)
+	}
+	parents := make(map[uuid.UUID]uuid.NullUUID, len(regions))
+	for _, region := range regions {
+		parents[region.Id] = region.ParentId
+	}
+	return parents, nil
+}
+
+// isWithin проверяет, что regionId вложен в ancestorId на любом уровне
+func isWithin(parents map[uuid.UUID]uuid.NullUUID, regionId uuid.UUID, ancestorId uuid.UUID) bool {
+	for depth := 0; depth <= len(models.RegionKindDepth); depth++ {
+		parent, ok := parents[regionId]
+		if !ok || !parent.Valid {
+			return false
+		}
+		if parent.UUID == ancestorId {
+			return true
+		}
+		regionId = parent.UUID
+	}
+	return false
+}
+
+func locate(shapes []regionShape, point geo.Point) (uuid.UUID, bool) {
+	for _, s := range shapes {
+		if s.shape.Contains(point) {
+			return s.regionId, true
+		}
+	}
+	return uuid.Nil, false
+}
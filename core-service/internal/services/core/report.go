@@ -0,0 +1,263 @@
+// Бизнес-логика жалоб пользователей
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+const (
+	maxReportDetailsLength = 1000
+	defaultReportsLimit    = 50
+	maxReportsLimit        = 200
+	autoHideReason         = "hidden automatically after %d reports"
+	reportHideReason       = "hidden after reports"
+)
+
+// ReportPolicy - настройки обработки жалоб
+type ReportPolicy struct {
+	// После стольких открытых жалоб объект скрывается до решения модератора; 0 - не скрывать
+	AutoHideThreshold int
+}
+
+// CreateReport сохраняет жалобу reporterId на объект. Повторная жалоба того же пользователя,
+// пока открыта первая, обновляет ее; created сообщает, создана ли новая жалоба
+func (c *CoreService) CreateReport(ctx context.Context, report models.Report) (saved models.Report, created bool, err error) {
+	if !slices.Contains(models.ReportReasons, report.Reason) {
+		return report, false, fmt.Errorf("%w: unknown report reason %q", storage.ErrValidation, report.Reason)
+	}
+	report.Details = strings.TrimSpace(report.Details)
+	if len([]rune(report.Details)) > maxReportDetailsLength {
+		return report, false, fmt.Errorf("%w: details are longer than %d characters", storage.ErrValidation, maxReportDetailsLength)
+	}
+	if report.Reason == models.ReportReasonOther && report.Details == "" {
+		return report, false, fmt.Errorf("%w: details are required for reason %q", storage.ErrValidation, report.Reason)
+	}
+	if report.TargetType == models.ReportTargetUser && report.TargetId == report.ReporterId {
+		return report, false, fmt.Errorf("%w: can't report yourself", storage.ErrValidation)
+	}
+
+	report.Content, err = c.reportedContent(ctx, report.TargetType, report.TargetId, report.ReporterId)
+	if err != nil {
+		return report, false, err
+	}
+
+	report.Id = uuid.New()
+	err = c.storage.WithTx(ctx, func(ctx context.Context) error {
+		saved, created, err = c.storage.SaveReport(ctx, report)
+		if err != nil || !created || c.reports.AutoHideThreshold <= 0 {
+			return err
+		}
+		open, err := c.storage.CountOpenReports(ctx, report.TargetType, report.TargetId)
+		if err != nil {
+			return err
+		}
+		if open < c.reports.AutoHideThreshold {
+			return nil
+		}
+		hidden, err := c.hideReported(ctx, report.TargetType, report.TargetId, fmt.Sprintf(autoHideReason, open), uuid.Nil)
+		if hidden {
+			c.log.Info("content hidden after reports",
+				slog.String("target_type", report.TargetType),
+				slog.String("target_id", report.TargetId.String()),
+				slog.Int("reports", open))
+		}
+		return err
+	})
+	if err != nil {
+		return saved, false, fmt.Errorf("failed to create report: %w", err)
+	}
+	return saved, created, nil
+}
+
+// reportedContent проверяет, что объект жалобы существует и виден пожаловавшемуся,
+// и возвращает его текст, который сохраняется вместе с жалобой
+func (c *CoreService) reportedContent(ctx context.Context, targetType string, targetId uuid.UUID, reporterId uuid.UUID) (string, error) {
+	switch targetType {
+	case models.ReportTargetPhoto:
+		photo, err := c.GetPhoto(ctx, targetId, reporterId)
+		if err != nil {
+			return "", err
+		}
+		return photo.Description, nil
+	case models.ReportTargetComment:
+		comment, err := c.storage.GetComment(ctx, targetId)
+		if err != nil {
+			return "", fmt.Errorf("failed to get comment: %w", err)
+		}
+		if comment.Deleted || comment.Hidden {
+			return "", fmt.Errorf("comment %s: %w", targetId, storage.ErrNotFound)
+		}
+		return comment.Body, nil
+	case models.ReportTargetTrip:
+		trip, err := c.GetTripById(ctx, targetId)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(trip.Name + "\n" + trip.Description), nil
+	case models.ReportTargetUser:
+		user, err := c.storage.GetUserById(ctx, targetId)
+		if err != nil {
+			return "", fmt.Errorf("failed to get user: %w", err)
+		}
+		return user.UserName, nil
+	}
+	return "", fmt.Errorf("%w: unknown report target %q", storage.ErrValidation, targetType)
+}
+
+// hideReported скрывает объект жалоб и сообщает, был ли он виден до этого.
+// Пользователи не скрываются: решение по ним принимает модератор
+func (c *CoreService) hideReported(ctx context.Context, targetType string, targetId uuid.UUID, reason string, moderatorId uuid.UUID) (bool, error) {
+	switch targetType {
+	case models.ReportTargetPhoto:
+		photo, err := c.storage.GetPhoto(ctx, targetId, uuid.Nil)
+		if err != nil || photo.ModerationStatus != models.ModerationApproved {
+			return false, err
+		}
+		err = c.storage.ModeratePhoto(ctx, targetId, models.ModerationDecision{Status: models.ModerationHidden, Reason: reason, ModeratorId: moderatorId})
+		if err != nil {
+			return false, err
+		}
+		photo.ModerationStatus = models.ModerationHidden
+		photo.ModerationReason = reason
+		if photo.Visibility != models.VisibilityPublic {
+			return true, nil
+		}
+		return true, c.emitEvent(ctx, models.EventPhotoUpdated, photo)
+	case models.ReportTargetComment:
+		comment, err := c.storage.GetComment(ctx, targetId)
+		if err != nil || comment.Hidden {
+			return false, err
+		}
+		return true, c.storage.SetCommentHidden(ctx, targetId, true)
+	case models.ReportTargetTrip:
+		trip, err := c.storage.GetTripById(ctx, targetId)
+		if err != nil || trip.Hidden {
+			return false, err
+		}
+		if err := c.storage.SetTripHidden(ctx, targetId, true); err != nil {
+			return false, err
+		}
+		trip.Hidden = true
+		return true, c.emitEvent(ctx, models.EventTripUpdated, trip)
+	}
+	return false, nil
+}
+
+// restoreReported возвращает объект, скрытый по жалобам или модератором
+func (c *CoreService) restoreReported(ctx context.Context, targetType string, targetId uuid.UUID, moderatorId uuid.UUID) error {
+	switch targetType {
+	case models.ReportTargetPhoto:
+		photo, err := c.storage.GetPhoto(ctx, targetId, uuid.Nil)
+		if err != nil || photo.ModerationStatus != models.ModerationHidden {
+			return err
+		}
+		err = c.storage.ModeratePhoto(ctx, targetId, models.ModerationDecision{Status: models.ModerationApproved, ModeratorId: moderatorId})
+		if err != nil {
+			return err
+		}
+		photo.ModerationStatus = models.ModerationApproved
+		photo.ModerationReason = ""
+		if photo.Visibility != models.VisibilityPublic {
+			return nil
+		}
+		return c.emitEvent(ctx, models.EventPhotoUpdated, photo)
+	case models.ReportTargetComment:
+		return c.storage.SetCommentHidden(ctx, targetId, false)
+	case models.ReportTargetTrip:
+		trip, err := c.storage.GetTripById(ctx, targetId)
+		if err != nil || !trip.Hidden {
+			return err
+		}
+		if err := c.storage.SetTripHidden(ctx, targetId, false); err != nil {
+			return err
+		}
+		trip.Hidden = false
+		return c.emitEvent(ctx, models.EventTripUpdated, trip)
+	}
+	return nil
+}
+
+// GetReportSummaries возвращает объекты с открытыми жалобами для панели модератора
+func (c *CoreService) GetReportSummaries(ctx context.Context, moderatorId uuid.UUID, targetType string, limit int) ([]models.ReportSummary, error) {
+	if err := c.authorizeModerator(ctx, moderatorId); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultReportsLimit
+	}
+	limit = min(limit, maxReportsLimit)
+
+	summaries, err := c.storage.GetReportSummaries(ctx, targetType, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report summaries: %w", err)
+	}
+	return summaries, nil
+}
+
+// GetReports возвращает все жалобы на объект, включая закрытые
+func (c *CoreService) GetReports(ctx context.Context, moderatorId uuid.UUID, targetType string, targetId uuid.UUID) ([]models.Report, error) {
+	if err := c.authorizeModerator(ctx, moderatorId); err != nil {
+		return nil, err
+	}
+	return c.storage.GetReports(ctx, targetType, targetId)
+}
+
+// ResolveReports закрывает открытые жалобы на объект решением модератора
+// и возвращает обновленный список жалоб на него
+func (c *CoreService) ResolveReports(ctx context.Context, targetType string, targetId uuid.UUID, resolution models.ReportResolution) ([]models.Report, error) {
+	if err := c.authorizeModerator(ctx, resolution.ModeratorId); err != nil {
+		return nil, err
+	}
+	resolution.Note = strings.TrimSpace(resolution.Note)
+	if len([]rune(resolution.Note)) > maxModerationReasonLength {
+		return nil, fmt.Errorf("%w: note is longer than %d characters", storage.ErrValidation, maxModerationReasonLength)
+	}
+
+	status := models.ReportResolved
+	switch resolution.Action {
+	case models.ReportActionDismiss:
+		status = models.ReportDismissed
+	case models.ReportActionHide:
+		if targetType == models.ReportTargetUser {
+			return nil, fmt.Errorf("%w: users can't be hidden", storage.ErrValidation)
+		}
+	case models.ReportActionResolve:
+	default:
+		return nil, fmt.Errorf("%w: unknown report action %q", storage.ErrValidation, resolution.Action)
+	}
+
+	err := c.storage.WithTx(ctx, func(ctx context.Context) error {
+		closed, err := c.storage.CloseReports(ctx, targetType, targetId, status, resolution)
+		if err != nil {
+			return err
+		}
+		if closed == 0 {
+			return fmt.Errorf("open reports on %s %s: %w", targetType, targetId, storage.ErrNotFound)
+		}
+
+		switch resolution.Action {
+		case models.ReportActionDismiss:
+			return c.restoreReported(ctx, targetType, targetId, resolution.ModeratorId)
+		case models.ReportActionHide:
+			reason := resolution.Note
+			if reason == "" {
+				reason = reportHideReason
+			}
+			_, err := c.hideReported(ctx, targetType, targetId, reason, resolution.ModeratorId)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve reports: %w", err)
+	}
+	return c.storage.GetReports(ctx, targetType, targetId)
+}
@@ -0,0 +1,176 @@
+// Видимость фото и ссылки, открывающие фото или поездку по токену
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// Длина токена доступа (ссылки или календаря) в байтах до кодирования
+const accessTokenBytes = 32
+
+// ShareLinks - настройки ссылок для доступа
+type ShareLinks struct {
+	// Адрес API, от которого строятся ссылки, например https://api.example.com
+	BaseUrl string
+}
+
+func validateVisibility(visibility string) error {
+	if !slices.Contains(models.Visibilities, visibility) {
+		return fmt.Errorf("%w: unknown visibility %q, expected one of %s", storage.ErrValidation, visibility,
+			strings.Join(models.Visibilities, ", "))
+	}
+	return nil
+}
+
+// isPublished сообщает, видно ли фото всем: только о таких фото узнают партнеры
+func isPublished(photo models.Photo) bool {
+	return photo.ModerationStatus == models.ModerationApproved && photo.Visibility == models.VisibilityPublic
+}
+
+// CreateShareLink создает ссылку на фото или поездку от имени link.CreatedBy.
+// Делиться фото может только автор, поездкой - ее участники
+func (c *CoreService) CreateShareLink(ctx context.Context, link models.ShareLink) (models.ShareLink, error) {
+	if link.ExpiresAt != nil && !link.ExpiresAt.After(time.Now()) {
+		return models.ShareLink{}, fmt.Errorf("%w: expiry must be in the future", storage.ErrValidation)
+	}
+	if err := c.authorizeShare(ctx, link.TargetType, link.TargetId, link.CreatedBy); err != nil {
+		return models.ShareLink{}, err
+	}
+
+	token, err := newAccessToken()
+	if err != nil {
+		return models.ShareLink{}, err
+	}
+	link.Id = uuid.New()
+	saved, err := c.storage.SaveShareLink(ctx, link, hashToken(token))
+	if err != nil {
+		return models.ShareLink{}, fmt.Errorf("failed to create share link: %w", err)
+	}
+	saved.Token = token
+	saved.Url = strings.TrimRight(c.share.BaseUrl, "/") + "/api/share/" + token
+	return saved, nil
+}
+
+func (c *CoreService) authorizeShare(ctx context.Context, targetType string, targetId uuid.UUID, userId uuid.UUID) error {
+	switch targetType {
+	case models.ShareTargetPhoto:
+		photo, err := c.storage.GetPhoto(ctx, targetId, uuid.Nil)
+		if err != nil {
+			return fmt.Errorf("failed to get photo: %w", err)
+		}
+		if photo.UserId != userId {
+			return fmt.Errorf("%w: only the author can share the photo", storage.ErrForbidden)
+		}
+		return nil
+	case models.ShareTargetTrip:
+		if _, err := c.GetTripById(ctx, targetId); err != nil {
+			return err
+		}
+		member, err := c.isTripMember(ctx, targetId, userId)
+		if err != nil {
+			return err
+		}
+		if !member {
+			return fmt.Errorf("%w: only trip members can share the trip", storage.ErrForbidden)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unknown share target %q", storage.ErrValidation, targetType)
+}
+
+func (c *CoreService) isTripMember(ctx context.Context, tripId uuid.UUID, userId uuid.UUID) (bool, error) {
+	members, err := c.storage.GetTripMembers(ctx, tripId)
+	if err != nil {
+		return false, fmt.Errorf("failed to get trip members: %w", err)
+	}
+	return slices.Contains(members, userId), nil
+}
+
+// GetSharedContent возвращает фото или поездку по токену ссылки. Ссылка не открывает
+// неодобренные фото и скрытые поездки и перестает действовать, если ее автор больше
+// не участник поездки
+func (c *CoreService) GetSharedContent(ctx context.Context, token string) (models.SharedContent, error) {
+	link, err := c.storage.GetShareLinkByToken(ctx, hashToken(token))
+	if err != nil {
+		return models.SharedContent{}, fmt.Errorf("failed to get share link: %w", err)
+	}
+	if link.ExpiresAt != nil && !link.ExpiresAt.After(time.Now()) {
+		return models.SharedContent{}, fmt.Errorf("share link has expired: %w", storage.ErrNotFound)
+	}
+
+	content := models.SharedContent{TargetType: link.TargetType, ExpiresAt: link.ExpiresAt}
+	switch link.TargetType {
+	case models.ShareTargetPhoto:
+		photo, err := c.storage.GetPhoto(ctx, link.TargetId, uuid.Nil)
+		if err != nil {
+			return models.SharedContent{}, fmt.Errorf("failed to get photo: %w", err)
+		}
+		if photo.ModerationStatus != models.ModerationApproved {
+			return models.SharedContent{}, fmt.Errorf("failed to get photo: %w", storage.ErrNotFound)
+		}
+		content.Photo = &photo
+	case models.ShareTargetTrip:
+		member, err := c.isTripMember(ctx, link.TargetId, link.CreatedBy)
+		if err != nil {
+			return models.SharedContent{}, err
+		}
+		if !member {
+			return models.SharedContent{}, fmt.Errorf("share link author left the trip: %w", storage.ErrNotFound)
+		}
+		trip, err := c.GetTripById(ctx, link.TargetId)
+		if err != nil {
+			return models.SharedContent{}, err
+		}
+		photos, err := c.storage.GetSharedTripPhotos(ctx, link.TargetId, link.CreatedBy)
+		if err != nil {
+			return models.SharedContent{}, fmt.Errorf("failed to get trip photos: %w", err)
+		}
+		content.Trip = &trip
+		content.Photos = photos
+	}
+	return content, nil
+}
+
+// GetShareLinks возвращает ссылки, созданные пользователем. Токены ссылок не хранятся,
+// поэтому адреса в списке не заполняются
+func (c *CoreService) GetShareLinks(ctx context.Context, userId uuid.UUID) ([]models.ShareLink, error) {
+	links, err := c.storage.GetShareLinks(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share links: %w", err)
+	}
+	return links, nil
+}
+
+// RevokeShareLink удаляет ссылку пользователя; доступ по ней сразу прекращается
+func (c *CoreService) RevokeShareLink(ctx context.Context, userId uuid.UUID, linkId uuid.UUID) error {
+	if err := c.storage.DeleteShareLink(ctx, linkId, userId); err != nil {
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+	return nil
+}
+
+// newAccessToken создает случайный токен доступа для ссылок и календаря
+func newAccessToken() (string, error) {
+	b := make([]byte, accessTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken возвращает хеш токена, под которым он хранится в БД
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
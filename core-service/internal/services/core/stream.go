@@ -0,0 +1,271 @@
+// Поток обновлений в реальном времени: события приходят из PostgreSQL
+// через LISTEN/NOTIFY и раздаются подписчикам этой реплики
+package core
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/lib/logger/sl"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+const (
+	// Канал NOTIFY, в который пишут триггеры из миграции 0012_stream_events
+	streamChannel = "stream_events"
+	// Сколько событий может ждать отправки одному подписчику. Медленный клиент
+	// теряет события сверх этого, чтобы не задерживать остальных
+	streamBufferSize = 64
+
+	streamRetryMin = time.Second
+	streamRetryMax = 30 * time.Second
+)
+
+// StreamAuth - настройки доступа к потоку из браузера
+type StreamAuth struct {
+	// Ключ подписи токенов потока
+	TokenSecret []byte
+	// Сколько действует токен. Токен нужен только для подключения,
+	// открытое соединение по истечении срока не закрывается
+	TokenTTL time.Duration
+	// Origin сайтов, с которых можно открыть WebSocket
+	AllowedOrigins []string
+}
+
+// streamHub раздает события подписчикам внутри процесса
+type streamHub struct {
+	mu          sync.RWMutex
+	subscribers map[*streamSubscriber]struct{}
+	closed      bool
+}
+
+type streamSubscriber struct {
+	userId  uuid.UUID
+	regions map[uuid.UUID]bool
+	trips   map[uuid.UUID]bool
+	photos  map[uuid.UUID]bool
+	// Авторы, заблокированные или скрытые пользователем на момент подписки
+	hidden map[uuid.UUID]bool
+	events chan models.StreamEvent
+}
+
+func newStreamHub() *streamHub {
+	return &streamHub{subscribers: make(map[*streamSubscriber]struct{})}
+}
+
+func (h *streamHub) subscribe(sub *streamSubscriber) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	h.subscribers[sub] = struct{}{}
+	return true
+}
+
+func (h *streamHub) unsubscribe(sub *streamSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// publish отправляет событие подходящим подписчикам и возвращает число тех,
+// кому событие не досталось из-за переполненного буфера
+func (h *streamHub) publish(event models.StreamEvent) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	dropped := 0
+	for sub := range h.subscribers {
+		if !sub.matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// close отключает всех подписчиков: их каналы закрываются, новые подписки не принимаются
+func (h *streamHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+func (s *streamSubscriber) matches(event models.StreamEvent) bool {
+	if event.Type != models.StreamNotification && s.hidden[event.UserId] {
+		return false
+	}
+	switch event.Type {
+	case models.StreamNotification:
+		return s.userId != uuid.Nil && event.UserId == s.userId
+	case models.StreamPhotoLikes:
+		if s.photos[event.PhotoId] || (s.userId != uuid.Nil && event.UserId == s.userId) {
+			return true
+		}
+		return s.regions[event.RegionId] || s.trips[event.TripId]
+	case models.StreamPhotoCreated:
+		return s.regions[event.RegionId] || s.trips[event.TripId]
+	}
+	return false
+}
+
+// SubscribeStream подписывает клиента на события. К регионам из фильтра добавляются
+// регионы, на которые подписан пользователь; события фото заблокированных и скрытых
+// авторов не приходят. Возвращает канал событий, который
+// закрывается при остановке сервиса, и функцию отписки
+func (c *CoreService) SubscribeStream(ctx context.Context, filter models.StreamFilter) (<-chan models.StreamEvent, func(), error) {
+	sub := &streamSubscriber{
+		userId:  filter.UserId,
+		regions: make(map[uuid.UUID]bool),
+		trips:   make(map[uuid.UUID]bool),
+		photos:  make(map[uuid.UUID]bool),
+		hidden:  make(map[uuid.UUID]bool),
+		events:  make(chan models.StreamEvent, streamBufferSize),
+	}
+	for _, id := range filter.Regions {
+		sub.regions[id] = true
+	}
+	for _, id := range filter.Trips {
+		sub.trips[id] = true
+	}
+	for _, id := range filter.Photos {
+		sub.photos[id] = true
+	}
+	if filter.UserId != uuid.Nil {
+		regions, err := c.storage.GetFollowedRegions(ctx, filter.UserId)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, region := range regions {
+			sub.regions[region.Id] = true
+		}
+		hidden, err := c.storage.GetHiddenUserIds(ctx, filter.UserId)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, id := range hidden {
+			sub.hidden[id] = true
+		}
+	}
+
+	if !c.stream.subscribe(sub) {
+		close(sub.events)
+		return sub.events, func() {}, nil
+	}
+	return sub.events, func() { c.stream.unsubscribe(sub) }, nil
+}
+
+// Длина токена потока: пользователь, срок действия в секундах Unix и подпись
+const streamTokenLength = 16 + 8 + sha256.Size
+
+// CreateStreamToken выдает пользователю токен для подключения к потоку без заголовка User-Id
+func (c *CoreService) CreateStreamToken(ctx context.Context, userId uuid.UUID) (models.StreamToken, error) {
+	expiresAt := time.Now().Add(c.streamAuth.TokenTTL).Truncate(time.Second)
+
+	payload := make([]byte, 0, streamTokenLength)
+	payload = append(payload, userId[:]...)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(expiresAt.Unix()))
+	token := append(payload, c.signStreamToken(payload)...)
+	return models.StreamToken{
+		Token:     base64.RawURLEncoding.EncodeToString(token),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// StreamTokenUser возвращает пользователя, которому выдан токен потока
+func (c *CoreService) StreamTokenUser(token string) (uuid.UUID, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) != streamTokenLength {
+		return uuid.Nil, fmt.Errorf("%w: malformed stream token", storage.ErrValidation)
+	}
+	payload, signature := data[:16+8], data[16+8:]
+	if !hmac.Equal(signature, c.signStreamToken(payload)) {
+		return uuid.Nil, fmt.Errorf("%w: invalid stream token", storage.ErrForbidden)
+	}
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(payload[16:])), 0)
+	if time.Now().After(expiresAt) {
+		return uuid.Nil, fmt.Errorf("%w: stream token expired", storage.ErrForbidden)
+	}
+	userId, err := uuid.FromBytes(payload[:16])
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: malformed stream token", storage.ErrValidation)
+	}
+	return userId, nil
+}
+
+func (c *CoreService) signStreamToken(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.streamAuth.TokenSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// IsStreamOriginAllowed сообщает, можно ли открыть WebSocket со страницы origin
+func (c *CoreService) IsStreamOriginAllowed(origin string) bool {
+	return slices.Contains(c.streamAuth.AllowedOrigins, origin)
+}
+
+// CloseStreams отключает всех подписчиков потока. Вызывается при остановке
+// HTTP-сервера, чтобы долгие соединения не задерживали ее
+func (c *CoreService) CloseStreams() {
+	c.stream.close()
+}
+
+// RunStreamListener слушает канал событий PostgreSQL и раздает события подписчикам,
+// пока не отменен ctx. При обрыве соединения переподключается с нарастающей паузой
+func (c *CoreService) RunStreamListener(ctx context.Context) {
+	retry := streamRetryMin
+	for {
+		started := time.Now()
+		err := c.storage.Listen(ctx, streamChannel, c.dispatchStreamEvent)
+		if ctx.Err() != nil {
+			return
+		}
+		c.log.Error("stream listener stopped", sl.Err(err), slog.Duration("retry_in", retry))
+
+		// Если подписка успела поработать, пауза начинается заново
+		if time.Since(started) > streamRetryMax {
+			retry = streamRetryMin
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+		retry = min(retry*2, streamRetryMax)
+	}
+}
+
+func (c *CoreService) dispatchStreamEvent(payload string) {
+	var event models.StreamEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		c.log.Error("failed to decode stream event", slog.String("payload", payload), sl.Err(err))
+		return
+	}
+	if dropped := c.stream.publish(event); dropped > 0 {
+		c.log.Warn("stream subscribers are too slow, event dropped",
+			slog.String("type", event.Type), slog.Int("subscribers", dropped))
+	}
+}
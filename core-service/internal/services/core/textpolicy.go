@@ -0,0 +1,144 @@
+// Проверка пользовательского текста: описаний, названий, имен и комментариев
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/lib/textpolicy"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// Поля, текст которых проверяется политикой
+const (
+	TextPhotoDescription = "photo_description"
+	TextTripName         = "trip_name"
+	TextTripDescription  = "trip_description"
+	TextFullName         = "full_name"
+	TextComment          = "comment"
+	TextAlbumTitle       = "album_title"
+	TextAlbumDescription = "album_description"
+	TextTripStopNotes    = "trip_stop_notes"
+)
+
+// Максимальная длина полей в символах, если она не задана в настройках
+var defaultTextMaxLength = map[string]int{
+	TextPhotoDescription: 2000,
+	TextTripName:         250,
+	TextTripDescription:  5000,
+	TextFullName:         250,
+	TextComment:          maxCommentLength,
+	TextAlbumTitle:       250,
+	TextAlbumDescription: 2000,
+	TextTripStopNotes:    2000,
+}
+
+// Имена правил проверки текста
+const (
+	textRuleProfanity = "profanity"
+	textRuleStopWords = "stop_words"
+	textRuleLinks     = "links"
+	textRulePhones    = "phones"
+)
+
+// TextPolicy - настройки проверки текста. Действия: allow, mask, moderate или reject
+type TextPolicy struct {
+	// Действие для нецензурных слов из встроенного списка и ExtraProfanity
+	Profanity string
+	// Действие для слов из StopWordList
+	StopWords string
+	// Действие для ссылок и доменных имен
+	Links string
+	// Действие для телефонных номеров
+	Phones string
+	// Записи в формате textpolicy.Words
+	ExtraProfanity []string
+	StopWordList   []string
+	// Максимальная длина полей в символах; не заданные поля берутся из defaultTextMaxLength
+	MaxLength map[string]int
+}
+
+// Validate проверяет, что все действия известны
+func (p TextPolicy) Validate() error {
+	for name, action := range map[string]string{
+		textRuleProfanity: p.Profanity,
+		textRuleStopWords: p.StopWords,
+		textRuleLinks:     p.Links,
+		textRulePhones:    p.Phones,
+	} {
+		if !textpolicy.ValidAction(action) {
+			return fmt.Errorf("unknown text policy action %q for %s", action, name)
+		}
+	}
+	for field := range p.MaxLength {
+		if _, ok := defaultTextMaxLength[field]; !ok {
+			return fmt.Errorf("unknown text policy field %q", field)
+		}
+	}
+	return nil
+}
+
+func newTextPipeline(policy TextPolicy) *textpolicy.Pipeline {
+	pipeline := textpolicy.New().
+		Add(textpolicy.Words(textRuleProfanity, append(textpolicy.DefaultProfanity(), policy.ExtraProfanity...)), policy.Profanity).
+		Add(textpolicy.Links(textRuleLinks), policy.Links).
+		Add(textpolicy.Phones(textRulePhones), policy.Phones)
+	if len(policy.StopWordList) > 0 {
+		pipeline.Add(textpolicy.Words(textRuleStopWords, policy.StopWordList), policy.StopWords)
+	}
+	return pipeline
+}
+
+// checkText проверяет текст поля. Возвращает текст для сохранения, в котором замаскированы
+// нарушения с действием mask, и правила, из-за которых текст нужно отправить на модерацию.
+// Нарушения с действием reject и превышение длины возвращают storage.ErrValidation
+func (c *CoreService) checkText(field string, text string) (string, []string, error) {
+	maxLength, ok := c.text.MaxLength[field]
+	if !ok {
+		maxLength = defaultTextMaxLength[field]
+	}
+	if maxLength > 0 && utf8.RuneCountInString(text) > maxLength {
+		return "", nil, fmt.Errorf("%w: %s is longer than %d characters", storage.ErrValidation, field, maxLength)
+	}
+
+	result := c.textPipeline.Check(text)
+	switch result.Action {
+	case textpolicy.ActionReject:
+		return "", nil, fmt.Errorf("%w: %s is not allowed: %s", storage.ErrValidation, field,
+			strings.Join(result.Rules(textpolicy.ActionReject), ", "))
+	case textpolicy.ActionModerate:
+		return result.Text, result.Rules(textpolicy.ActionModerate), nil
+	}
+	return result.Text, nil, nil
+}
+
+// textModerationReason - причина, с которой текст отправляется на модерацию
+func textModerationReason(rules []string) string {
+	return "text policy: " + strings.Join(rules, ", ")
+}
+
+// holdPhoto возвращает фото в очередь модерации с причиной, найденной проверкой текста
+func (c *CoreService) holdPhoto(ctx context.Context, photoId uuid.UUID, rules []string) error {
+	return c.storage.ModeratePhoto(ctx, photoId, models.ModerationDecision{
+		Status: models.ModerationPending,
+		Reason: textModerationReason(rules),
+	})
+}
+
+// reportText подает жалобу от имени системы на объект, текст которого требует модерации.
+// Жалоба попадает в панель модератора, где ее можно отклонить или скрыть объект
+func (c *CoreService) reportText(ctx context.Context, targetType string, targetId uuid.UUID, content string, rules []string) error {
+	_, _, err := c.storage.SaveReport(ctx, models.Report{
+		Id:         uuid.New(),
+		TargetType: targetType,
+		TargetId:   targetId,
+		Reason:     models.ReportReasonOther,
+		Details:    textModerationReason(rules),
+		Content:    content,
+	})
+	return err
+}
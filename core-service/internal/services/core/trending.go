@@ -0,0 +1,130 @@
+// Бизнес-логика ленты популярных фото
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/lib/logger/sl"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+)
+
+// TrendingRanking - настройки пересчета рейтинга популярных фото
+type TrendingRanking struct {
+	RefreshInterval time.Duration
+	LikeWeight      float64
+	ViewWeight      float64
+}
+
+// GetTrendingFeed возвращает страницу ленты популярного за окно window ("24h", "7d", "30d").
+// cursor - значение NextCursor предыдущей страницы или пустая строка для первой
+func (c *CoreService) GetTrendingFeed(ctx context.Context, window string, filters models.PhotoFiltersDTO, cursor string, limit int) (models.FeedPage, error) {
+	trendingWindow, ok := findTrendingWindow(window)
+	if !ok {
+		return models.FeedPage{}, fmt.Errorf("%w: unknown trending window %q", storage.ErrValidation, window)
+	}
+	if limit <= 0 {
+		limit = defaultFeedLimit
+	}
+	limit = min(limit, maxFeedLimit)
+
+	var after *models.FeedCursor
+	if cursor != "" {
+		decoded, err := decodeFeedCursor(cursor)
+		if err != nil {
+			return models.FeedPage{}, fmt.Errorf("%w: invalid cursor", storage.ErrValidation)
+		}
+		after = &decoded
+	}
+
+	// Запрашиваем на одно фото больше, чтобы понять, есть ли следующая страница
+	photos, err := c.storage.GetTrendingPhotos(ctx, trendingWindow.Name, filters, after, limit+1)
+	if err != nil {
+		return models.FeedPage{}, fmt.Errorf("failed to get trending photos: %w", err)
+	}
+
+	page := models.FeedPage{Photos: photos}
+	if len(photos) > limit {
+		page.Photos = photos[:limit]
+		last := page.Photos[limit-1]
+		page.NextCursor = encodeFeedCursor(models.FeedCursor{Score: last.Score, PhotoId: last.Id})
+	}
+	return page, nil
+}
+
+// RunTrendingWorker пересчитывает рейтинг всех окон сразу после запуска
+// и затем раз в RefreshInterval, пока не отменен ctx
+func (c *CoreService) RunTrendingWorker(ctx context.Context) {
+	ticker := time.NewTicker(c.trending.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		c.refreshTrending(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *CoreService) refreshTrending(ctx context.Context) {
+	for _, window := range models.TrendingWindows {
+		ranked, err := c.storage.RefreshPhotoRanking(ctx, window, c.trending.LikeWeight, c.trending.ViewWeight)
+		if err != nil {
+			c.log.Error("failed to refresh photo ranking", slog.String("window", window.Name), sl.Err(err))
+			continue
+		}
+		c.log.Debug("photo ranking refreshed", slog.String("window", window.Name), slog.Int("photos", ranked))
+	}
+}
+
+func findTrendingWindow(name string) (models.TrendingWindow, bool) {
+	if name == "" {
+		return models.TrendingWindows[0], true
+	}
+	for _, window := range models.TrendingWindows {
+		if window.Name == name {
+			return window, true
+		}
+	}
+	return models.TrendingWindow{}, false
+}
+
+// Курсор - base64 от "рейтинг|id фото"
+func encodeFeedCursor(cursor models.FeedCursor) string {
+	raw := strconv.FormatFloat(cursor.Score, 'g', -1, 64) + "|" + cursor.PhotoId.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeFeedCursor(cursor string) (models.FeedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return models.FeedCursor{}, err
+	}
+	scoreStr, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return models.FeedCursor{}, fmt.Errorf("malformed cursor")
+	}
+	score, err := strconv.ParseFloat(scoreStr, 64)
+	if err != nil {
+		return models.FeedCursor{}, err
+	}
+	photoId, err := uuid.Parse(idStr)
+	if err != nil {
+		return models.FeedCursor{}, err
+	}
+	return models.FeedCursor{Score: score, PhotoId: photoId}, nil
+}
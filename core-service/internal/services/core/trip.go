@@ -3,22 +3,81 @@ package core
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
 )
 
 // Работа с поездками
 func (c *CoreService) CreateTrip(ctx context.Context, trip models.Trip) error {
-	return c.storage.CreateTrip(ctx, trip)
+	if trip.Id == uuid.Nil {
+		trip.Id = uuid.New()
+	}
+	if err := validateTripDates(trip.StartDate, trip.EndDate); err != nil {
+		return err
+	}
+	if err := c.checkPlaceRegion(ctx, trip.PlaceId, trip.RegionId); err != nil {
+		return err
+	}
+	flagged, err := c.checkTripText(&trip)
+	if err != nil {
+		return err
+	}
+	return c.storage.WithTx(ctx, func(ctx context.Context) error {
+		if err := c.storage.CreateTrip(ctx, trip); err != nil {
+			return err
+		}
+		if len(flagged) > 0 {
+			if err := c.holdTrip(ctx, trip, flagged); err != nil {
+				return err
+			}
+		}
+		created, err := c.storage.GetTripById(ctx, trip.Id)
+		if err != nil {
+			return err
+		}
+		return c.emitEvent(ctx, models.EventTripCreated, created)
+	})
 }
 
+// DeleteTrip удаляет поездку одной транзакцией. Фото поездки остаются у авторов: внешний
+// ключ обнуляет у них trip_id, а партнеры получают photo.updated
 func (c *CoreService) DeleteTrip(ctx context.Context, tripId uuid.UUID) error {
-	return c.storage.DeleteTrip(ctx, tripId)
+	return c.storage.WithTx(ctx, func(ctx context.Context) error {
+		photoIds, err := c.storage.GetTripPhotoIds(ctx, tripId)
+		if err != nil {
+			return err
+		}
+		if err := c.storage.DeleteTrip(ctx, tripId); err != nil {
+			return err
+		}
+		for _, photoId := range photoIds {
+			photo, err := c.storage.GetPhoto(ctx, photoId, uuid.Nil)
+			if err != nil {
+				return err
+			}
+			if !isPublished(photo) {
+				continue
+			}
+			if err := c.emitEvent(ctx, models.EventPhotoUpdated, photo); err != nil {
+				return err
+			}
+		}
+		return c.emitEvent(ctx, models.EventTripDeleted, models.Trip{Id: tripId})
+	})
 }
 
 func (c *CoreService) GetTripById(ctx context.Context, tripId uuid.UUID) (models.Trip, error) {
-	return c.storage.GetTripById(ctx, tripId)
+	trip, err := c.storage.GetTripById(ctx, tripId)
+	if err != nil {
+		return trip, err
+	}
+	if trip.Hidden {
+		return models.Trip{}, fmt.Errorf("failed to get trip: %w", storage.ErrNotFound)
+	}
+	return trip, nil
 }
 
 func (c *CoreService) GetTripsByUserId(ctx context.Context, userId uuid.UUID) ([]models.Trip, error) {
@@ -34,5 +93,53 @@ func (c *CoreService) GetTripsByTag(ctx context.Context, tagId string) ([]models
 }
 
 func (c *CoreService) UpdateTrip(ctx context.Context, tripId uuid.UUID, data models.Trip) error {
-	return c.storage.UpdateTrip(ctx, tripId, data)
+	if err := validateTripDates(data.StartDate, data.EndDate); err != nil {
+		return err
+	}
+	if err := c.checkPlaceRegion(ctx, data.PlaceId, data.RegionId); err != nil {
+		return err
+	}
+	flagged, err := c.checkTripText(&data)
+	if err != nil {
+		return err
+	}
+	return c.storage.WithTx(ctx, func(ctx context.Context) error {
+		if err := c.storage.UpdateTrip(ctx, tripId, data); err != nil {
+			return err
+		}
+		if len(flagged) > 0 {
+			data.Id = tripId
+			if err := c.holdTrip(ctx, data, flagged); err != nil {
+				return err
+			}
+		}
+		trip, err := c.storage.GetTripById(ctx, tripId)
+		if err != nil {
+			return err
+		}
+		return c.emitEvent(ctx, models.EventTripUpdated, trip)
+	})
+}
+
+// checkTripText проверяет название и описание поездки и заменяет их текстом для сохранения
+func (c *CoreService) checkTripText(trip *models.Trip) ([]string, error) {
+	name, nameFlagged, err := c.checkText(TextTripName, trip.Name)
+	if err != nil {
+		return nil, err
+	}
+	description, descriptionFlagged, err := c.checkText(TextTripDescription, trip.Description)
+	if err != nil {
+		return nil, err
+	}
+	trip.Name = name
+	trip.Description = description
+	return append(nameFlagged, descriptionFlagged...), nil
+}
+
+// holdTrip скрывает поездку и отправляет ее модератору
+func (c *CoreService) holdTrip(ctx context.Context, trip models.Trip, rules []string) error {
+	if err := c.storage.SetTripHidden(ctx, trip.Id, true); err != nil {
+		return err
+	}
+	return c.reportText(ctx, models.ReportTargetTrip, trip.Id, trip.Name+"\n"+trip.Description, rules)
 }
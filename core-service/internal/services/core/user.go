@@ -10,7 +10,20 @@ import (
 
 // Работа с пользователями
 func (c *CoreService) CreateUser(ctx context.Context, user models.User) error {
-	return c.storage.CreateUser(ctx, user)
+	fullName, flagged, err := c.checkText(TextFullName, user.FullName)
+	if err != nil {
+		return err
+	}
+	user.FullName = fullName
+	return c.storage.WithTx(ctx, func(ctx context.Context) error {
+		if err := c.storage.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		if len(flagged) > 0 {
+			return c.reportText(ctx, models.ReportTargetUser, user.Id, user.FullName, flagged)
+		}
+		return nil
+	})
 }
 
 func (c *CoreService) DeleteUser(ctx context.Context, userId uuid.UUID) error {
@@ -21,14 +34,29 @@ func (c *CoreService) GetUserById(ctx context.Context, userId uuid.UUID) (models
 	return c.storage.GetUserById(ctx, userId)
 }
 
-func (c *CoreService) GetUsers(ctx context.Context) ([]models.User, error) {
-	return c.storage.GetUsers(ctx)
+// GetUsers возвращает пользователей, которых видит viewerId: без тех, с кем у него блокировка
+func (c *CoreService) GetUsers(ctx context.Context, viewerId uuid.UUID) ([]models.User, error) {
+	return c.storage.GetUsers(ctx, viewerId)
 }
 
-func (c *CoreService) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
-	return c.storage.GetUserByUsername(ctx, username)
+// GetUserByUsername ищет пользователя по имени; заблокированные в любую сторону не находятся
+func (c *CoreService) GetUserByUsername(ctx context.Context, username string, viewerId uuid.UUID) (models.User, error) {
+	return c.storage.GetUserByUsername(ctx, username, viewerId)
 }
 
 func (c *CoreService) UpdateUser(ctx context.Context, userId uuid.UUID, data models.User) error {
-	return c.storage.UpdateUser(ctx, userId, data)
+	fullName, flagged, err := c.checkText(TextFullName, data.FullName)
+	if err != nil {
+		return err
+	}
+	data.FullName = fullName
+	return c.storage.WithTx(ctx, func(ctx context.Context) error {
+		if err := c.storage.UpdateUser(ctx, userId, data); err != nil {
+			return err
+		}
+		if len(flagged) > 0 {
+			return c.reportText(ctx, models.ReportTargetUser, userId, data.FullName, flagged)
+		}
+		return nil
+	})
 }
@@ -0,0 +1,105 @@
+// Бизнес-логика учета просмотров фото
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/lib/logger/sl"
+)
+
+// ViewTracking - настройки учета просмотров
+type ViewTracking struct {
+	// Окно, в пределах которого повторный анонимный просмотр не учитывается
+	DedupWindow time.Duration
+	// Соль для хеширования отпечатка анонимного клиента
+	FingerprintSalt string
+	// Просмотры копятся в очереди и пишутся в БД пачками по BatchSize
+	// или раз в FlushInterval, если пачка еще не набралась
+	BatchSize     int
+	FlushInterval time.Duration
+	QueueSize     int
+}
+
+// RecordView ставит просмотр фото в очередь на запись. viewerId - авторизованный
+// пользователь или uuid.Nil, fingerprint - отпечаток анонимного клиента (адрес и User-Agent).
+// Учитываются только просмотры фото, которые viewerId может видеть: иначе просмотрами
+// можно накручивать рейтинг скрытых и не прошедших модерацию фото.
+// Если очередь переполнена, просмотр отбрасывается, чтобы не тормозить запрос
+func (c *CoreService) RecordView(ctx context.Context, photoId uuid.UUID, viewerId uuid.UUID, fingerprint string) error {
+	if _, err := c.GetPhoto(ctx, photoId, viewerId); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	view := models.View{
+		PhotoId:  photoId,
+		ViewedAt: now,
+	}
+	if viewerId != uuid.Nil {
+		view.UserId = uuid.NullUUID{UUID: viewerId, Valid: true}
+	} else {
+		sum := sha256.Sum256([]byte(c.views.FingerprintSalt + fingerprint))
+		view.Fingerprint = hex.EncodeToString(sum[:])
+		view.WindowStart = now.Truncate(c.views.DedupWindow)
+	}
+
+	select {
+	case c.viewQueue <- view:
+	default:
+		c.log.Warn("view queue is full, view dropped", slog.String("photo_id", photoId.String()))
+	}
+	return nil
+}
+
+// RunViewWriter пишет просмотры из очереди в БД, пока не отменен ctx.
+// После отмены записывает то, что осталось в очереди, и возвращает управление
+func (c *CoreService) RunViewWriter(ctx context.Context) {
+	ticker := time.NewTicker(c.views.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.View, 0, c.views.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		// Пачка дописывается и после отмены ctx при остановке сервиса
+		saved, err := c.storage.SaveViews(context.WithoutCancel(ctx), batch)
+		if err != nil {
+			c.log.Error("failed to save views", slog.Int("count", len(batch)), sl.Err(err))
+		} else {
+			c.log.Debug("views saved", slog.Int("count", len(batch)), slog.Int("new", saved))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case view := <-c.viewQueue:
+			batch = append(batch, view)
+			if len(batch) >= c.views.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			for {
+				select {
+				case view := <-c.viewQueue:
+					batch = append(batch, view)
+					if len(batch) >= c.views.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Запросы в БД, связанные с альбомами
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// albumVisibleTo возвращает условие, при котором альбом с псевдонимом alias виден пользователю viewer
+func albumVisibleTo(alias string, viewer string) string {
+	return notBlocked(alias+".user_id", viewer) + fmt.Sprintf(` AND (%[1]s.visibility = 'public' OR %[1]s.user_id = %[2]s
+            OR (%[1]s.visibility = 'followers' AND EXISTS (
+                SELECT 1 FROM user_follow vf WHERE vf.followee_id = %[1]s.user_id AND vf.follower_id = %[2]s)))`, alias, viewer)
+}
+
+// albumSelect выбирает альбомы для пользователя viewer: обложкой становится выбранное фото,
+// а если оно не видно пользователю - первое видимое фото альбома. В счетчик фото
+// попадают только видимые пользователю фото
+func albumSelect(viewer string) string {
+	visiblePhoto := photoApprovedFor("p", viewer) + ` AND ` + photoVisibleTo("p", viewer)
+	return `
+        SELECT a.id, a.user_id, a.title, coalesce(a.description, ''), a.visibility, a.cover_photo_id, coalesce(cover.url, ''),
+               (SELECT count(*) FROM album_photo ap INNER JOIN photo p ON p.id = ap.photo_id
+                WHERE ap.album_id = a.id AND ` + visiblePhoto + `),
+               a.created_at, a.updated_at
+        FROM album a
+        LEFT JOIN LATERAL (
+            SELECT coalesce(p.thumbnail_url, p.img_url) AS url
+            FROM album_photo ap
+            INNER JOIN photo p ON p.id = ap.photo_id
+            WHERE ap.album_id = a.id AND ` + visiblePhoto + `
+            ORDER BY coalesce(p.id = a.cover_photo_id, false) DESC, ap.position
+            LIMIT 1
+        ) cover ON true
+    `
+}
+
+func (s *Storage) CreateAlbum(ctx context.Context, album models.Album) error {
+	query := `
+        INSERT INTO album (id, user_id, title, description, visibility)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+	_, err := s.conn(ctx).ExecContext(ctx, query, album.Id, album.UserId, album.Title, album.Description, album.Visibility)
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("failed to create album: %w: user does not exist", storage.ErrNotFound)
+	}
+	if err != nil {
+		return wrapError("failed to create album", err)
+	}
+	return nil
+}
+
+// GetAlbum возвращает альбом, если он виден пользователю viewerId; иначе storage.ErrNotFound
+func (s *Storage) GetAlbum(ctx context.Context, albumId uuid.UUID, viewerId uuid.UUID) (models.Album, error) {
+	query := albumSelect("$2") + `WHERE a.id = $1 AND ` + albumVisibleTo("a", "$2")
+	album, err := scanAlbum(s.conn(ctx).QueryRowContext(ctx, query, albumId, viewerId))
+	if err != nil {
+		return album, wrapError("failed to get album", err)
+	}
+	return album, nil
+}
+
+// GetAlbums возвращает альбомы пользователя userId, видимые viewerId, начиная с последних
+func (s *Storage) GetAlbums(ctx context.Context, userId uuid.UUID, viewerId uuid.UUID) ([]models.Album, error) {
+	var albums []models.Album
+
+	query := albumSelect("$2") + `WHERE a.user_id = $1 AND ` + albumVisibleTo("a", "$2") + `
+        ORDER BY a.created_at DESC, a.id`
+	rows, err := s.conn(ctx).QueryContext(ctx, query, userId, viewerId)
+	if err != nil {
+		return nil, wrapError("failed to get albums", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		album, err := scanAlbum(rows)
+		if err != nil {
+			return nil, wrapError("failed to scan album", err)
+		}
+		albums = append(albums, album)
+	}
+
+	return albums, rows.Err()
+}
+
+func (s *Storage) UpdateAlbum(ctx context.Context, albumId uuid.UUID, data models.Album) error {
+	query := `
+        UPDATE album
+        SET title = $1, description = $2, visibility = $3, cover_photo_id = $4, updated_at = now()
+        WHERE id = $5
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, data.Title, data.Description, data.Visibility, data.CoverPhotoId, albumId)
+	if err != nil {
+		return wrapError("failed to update album", err)
+	}
+	return checkAffected(res, "failed to update album")
+}
+
+func (s *Storage) DeleteAlbum(ctx context.Context, albumId uuid.UUID) error {
+	res, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM album WHERE id = $1`, albumId)
+	if err != nil {
+		return wrapDeleteError("failed to delete album", err)
+	}
+	return checkAffected(res, "failed to delete album")
+}
+
+// GetAlbumPhotos возвращает фото альбома, видимые пользователю viewerId, в порядке альбома:
+// одобренные модератором и собственные фото пользователя в любом статусе
+func (s *Storage) GetAlbumPhotos(ctx context.Context, albumId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	query := `
+        SELECT p.id, coalesce(p.coords, ''), coalesce(p.description, ''), p.img_url, coalesce(p.thumbnail_url, ''), coalesce(p.place, ''), p.place_id, p.region_id, p.trip_id, p.user_id, p.likes, p.watched_amount, p.comments, p.created_at, p.moderation_status, coalesce(p.moderation_reason, ''), p.visibility, p.taken_at,
+               EXISTS (SELECT 1 FROM photo_likes pl WHERE pl.photo_id = p.id AND pl.user_id = $2)
+        FROM album_photo ap
+        INNER JOIN photo p ON p.id = ap.photo_id
+        WHERE ap.album_id = $1 AND ` + photoApprovedFor("p", "$2") + ` AND ` + photoVisibleTo("p", "$2") + `
+        ORDER BY ap.position
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, albumId, viewerId)
+	if err != nil {
+		return nil, wrapError("failed to get album photos", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var photo models.Photo
+		err := rows.Scan(&photo.Id, &photo.Coords, &photo.Description, &photo.ImgUrl, &photo.ThumbnailUrl, &photo.Place, &photo.PlaceId, &photo.RegionId, &photo.TripId, &photo.UserId, &photo.Likes, &photo.Views, &photo.Comments, &photo.CreatedAt, &photo.ModerationStatus, &photo.ModerationReason, &photo.Visibility, &photo.TakenAt, &photo.LikedByMe)
+		if err != nil {
+			return nil, wrapError("failed to scan photo", err)
+		}
+		photos = append(photos, photo)
+	}
+
+	return photos, rows.Err()
+}
+
+// GetAlbumPhotoIds возвращает все фото альбома в порядке альбома независимо от модерации и видимости
+func (s *Storage) GetAlbumPhotoIds(ctx context.Context, albumId uuid.UUID) ([]uuid.UUID, error) {
+	var photoIds []uuid.UUID
+
+	rows, err := s.conn(ctx).QueryContext(ctx, `SELECT photo_id FROM album_photo WHERE album_id = $1 ORDER BY position`, albumId)
+	if err != nil {
+		return nil, wrapError("failed to get album photos", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var photoId uuid.UUID
+		if err := rows.Scan(&photoId); err != nil {
+			return nil, wrapError("failed to scan photo id", err)
+		}
+		photoIds = append(photoIds, photoId)
+	}
+
+	return photoIds, rows.Err()
+}
+
+// AddAlbumPhoto добавляет фото в конец альбома
+func (s *Storage) AddAlbumPhoto(ctx context.Context, albumId uuid.UUID, photoId uuid.UUID) error {
+	query := `
+        WITH touch AS (
+            UPDATE album SET updated_at = now() WHERE id = $1
+        )
+        INSERT INTO album_photo (album_id, photo_id, position)
+        VALUES ($1, $2, (SELECT coalesce(max(position) + 1, 0) FROM album_photo WHERE album_id = $1))
+    `
+	_, err := s.conn(ctx).ExecContext(ctx, query, albumId, photoId)
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("failed to add photo to album: %w: album or photo does not exist", storage.ErrNotFound)
+	}
+	if err != nil {
+		return wrapError("failed to add photo to album", err)
+	}
+	return nil
+}
+
+// RemoveAlbumPhoto убирает фото из альбома; если оно было обложкой, обложка сбрасывается
+func (s *Storage) RemoveAlbumPhoto(ctx context.Context, albumId uuid.UUID, photoId uuid.UUID) error {
+	query := `
+        WITH touch AS (
+            UPDATE album
+            SET updated_at = now(), cover_photo_id = CASE WHEN cover_photo_id = $2 THEN NULL ELSE cover_photo_id END
+            WHERE id = $1
+        )
+        DELETE FROM album_photo WHERE album_id = $1 AND photo_id = $2
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, albumId, photoId)
+	if err != nil {
+		return wrapError("failed to remove photo from album", err)
+	}
+	return checkAffected(res, "failed to remove photo from album")
+}
+
+// ReorderAlbumPhotos расставляет фото альбома в порядке photoIds. Фото, которых нет в photoIds,
+// позиций не меняют, поэтому передавать нужно все фото альбома
+func (s *Storage) ReorderAlbumPhotos(ctx context.Context, albumId uuid.UUID, photoIds []uuid.UUID) error {
+	ids := make([]string, len(photoIds))
+	for i, photoId := range photoIds {
+		ids[i] = photoId.String()
+	}
+
+	query := `
+        WITH touch AS (
+            UPDATE album SET updated_at = now() WHERE id = $1
+        )
+        UPDATE album_photo ap
+        SET position = o.position - 1
+        FROM unnest(string_to_array($2, ',')::uuid[]) WITH ORDINALITY AS o(photo_id, position)
+        WHERE ap.album_id = $1 AND ap.photo_id = o.photo_id
+    `
+	_, err := s.conn(ctx).ExecContext(ctx, query, albumId, strings.Join(ids, ","))
+	if err != nil {
+		return wrapError("failed to reorder album photos", err)
+	}
+	return nil
+}
+
+func scanAlbum(row rowScanner) (models.Album, error) {
+	var album models.Album
+	err := row.Scan(&album.Id, &album.UserId, &album.Title, &album.Description, &album.Visibility, &album.CoverPhotoId,
+		&album.CoverUrl, &album.Photos, &album.CreatedAt, &album.UpdatedAt)
+	return album, err
+}
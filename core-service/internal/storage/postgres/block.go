@@ -0,0 +1,160 @@
+// Запросы в БД, связанные с блокировками и скрытием пользователей
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// notBlocked возвращает условие, при котором между автором userColumn и пользователем viewer
+// нет блокировки ни в одну сторону. Аргументы - выражения SQL, например "p.user_id" и "$1"
+func notBlocked(userColumn string, viewer string) string {
+	return fmt.Sprintf(`NOT EXISTS (
+            SELECT 1 FROM user_block ub
+            WHERE (ub.blocker_id = %[2]s AND ub.blocked_id = %[1]s) OR (ub.blocker_id = %[1]s AND ub.blocked_id = %[2]s))`,
+		userColumn, viewer)
+}
+
+// notMuted возвращает условие, при котором пользователь viewer не скрыл автора userColumn
+func notMuted(userColumn string, viewer string) string {
+	return fmt.Sprintf(`NOT EXISTS (SELECT 1 FROM user_mute um WHERE um.user_id = %s AND um.muted_id = %s)`, viewer, userColumn)
+}
+
+// BlockUser блокирует пользователя и удаляет подписки между пользователями в обе стороны
+func (s *Storage) BlockUser(ctx context.Context, blockerId uuid.UUID, blockedId uuid.UUID) error {
+	query := `
+        WITH unfollow AS (
+            DELETE FROM user_follow
+            WHERE (follower_id = $1 AND followee_id = $2) OR (follower_id = $2 AND followee_id = $1)
+        )
+        INSERT INTO user_block (blocker_id, blocked_id)
+        VALUES ($1, $2)
+        ON CONFLICT DO NOTHING
+    `
+	_, err := s.conn(ctx).ExecContext(ctx, query, blockerId, blockedId)
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("failed to block user: %w: user does not exist", storage.ErrNotFound)
+	}
+	if err != nil {
+		return wrapError("failed to block user", err)
+	}
+	return nil
+}
+
+func (s *Storage) UnblockUser(ctx context.Context, blockerId uuid.UUID, blockedId uuid.UUID) error {
+	_, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM user_block WHERE blocker_id = $1 AND blocked_id = $2`, blockerId, blockedId)
+	if err != nil {
+		return wrapError("failed to unblock user", err)
+	}
+	return nil
+}
+
+// IsBlocked сообщает, заблокировал ли кто-то из двух пользователей другого
+func (s *Storage) IsBlocked(ctx context.Context, userId uuid.UUID, otherId uuid.UUID) (bool, error) {
+	query := `SELECT NOT ` + notBlocked("$2", "$1")
+	var blocked bool
+	if err := s.conn(ctx).QueryRowContext(ctx, query, userId, otherId).Scan(&blocked); err != nil {
+		return false, wrapError("failed to check block", err)
+	}
+	return blocked, nil
+}
+
+// GetBlockedUsers возвращает пользователей, заблокированных userId, начиная с последних
+func (s *Storage) GetBlockedUsers(ctx context.Context, userId uuid.UUID) ([]models.UserRestriction, error) {
+	query := `
+        SELECT u.id, u.username, coalesce(u.avatar_url, ''), b.created_at
+        FROM user_block b
+        INNER JOIN user_account u ON u.id = b.blocked_id
+        WHERE b.blocker_id = $1
+        ORDER BY b.created_at DESC
+    `
+	return s.queryRestrictions(ctx, query, userId)
+}
+
+func (s *Storage) MuteUser(ctx context.Context, userId uuid.UUID, mutedId uuid.UUID) error {
+	query := `
+        INSERT INTO user_mute (user_id, muted_id)
+        VALUES ($1, $2)
+        ON CONFLICT DO NOTHING
+    `
+	_, err := s.conn(ctx).ExecContext(ctx, query, userId, mutedId)
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("failed to mute user: %w: user does not exist", storage.ErrNotFound)
+	}
+	if err != nil {
+		return wrapError("failed to mute user", err)
+	}
+	return nil
+}
+
+func (s *Storage) UnmuteUser(ctx context.Context, userId uuid.UUID, mutedId uuid.UUID) error {
+	_, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM user_mute WHERE user_id = $1 AND muted_id = $2`, userId, mutedId)
+	if err != nil {
+		return wrapError("failed to unmute user", err)
+	}
+	return nil
+}
+
+// GetMutedUsers возвращает пользователей, скрытых userId, начиная с последних
+func (s *Storage) GetMutedUsers(ctx context.Context, userId uuid.UUID) ([]models.UserRestriction, error) {
+	query := `
+        SELECT u.id, u.username, coalesce(u.avatar_url, ''), m.created_at
+        FROM user_mute m
+        INNER JOIN user_account u ON u.id = m.muted_id
+        WHERE m.user_id = $1
+        ORDER BY m.created_at DESC
+    `
+	return s.queryRestrictions(ctx, query, userId)
+}
+
+// GetHiddenUserIds возвращает пользователей, события которых userId не должен получать:
+// заблокированных в любую сторону и скрытых им
+func (s *Storage) GetHiddenUserIds(ctx context.Context, userId uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+        SELECT blocked_id FROM user_block WHERE blocker_id = $1
+        UNION
+        SELECT blocker_id FROM user_block WHERE blocked_id = $1
+        UNION
+        SELECT muted_id FROM user_mute WHERE user_id = $1
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, wrapError("failed to get hidden users", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, wrapError("failed to scan hidden user", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (s *Storage) queryRestrictions(ctx context.Context, query string, userId uuid.UUID) ([]models.UserRestriction, error) {
+	var users []models.UserRestriction
+
+	rows, err := s.conn(ctx).QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, wrapError("failed to get users", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user models.UserRestriction
+		if err := rows.Scan(&user.UserId, &user.UserName, &user.AvatarUrl, &user.CreatedAt); err != nil {
+			return nil, wrapError("failed to scan user", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
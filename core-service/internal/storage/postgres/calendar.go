@@ -0,0 +1,49 @@
+// Запросы в БД, связанные с подпиской на календарь поездок
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// SaveCalendarToken сохраняет хеш токена ленты пользователя; прежний токен перестает действовать
+func (s *Storage) SaveCalendarToken(ctx context.Context, userId uuid.UUID, tokenHash []byte) (time.Time, error) {
+	query := `
+        INSERT INTO calendar_token (user_id, token_hash)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = now()
+        RETURNING created_at
+    `
+	var createdAt time.Time
+	err := s.conn(ctx).QueryRowContext(ctx, query, userId, tokenHash).Scan(&createdAt)
+	if isForeignKeyViolation(err) {
+		return createdAt, fmt.Errorf("failed to save calendar token: %w: user does not exist", storage.ErrNotFound)
+	}
+	if err != nil {
+		return createdAt, wrapError("failed to save calendar token", err)
+	}
+	return createdAt, nil
+}
+
+// IsCalendarTokenValid сообщает, действует ли токен с хешем tokenHash для ленты пользователя
+func (s *Storage) IsCalendarTokenValid(ctx context.Context, userId uuid.UUID, tokenHash []byte) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM calendar_token WHERE user_id = $1 AND token_hash = $2)`
+	var valid bool
+	if err := s.conn(ctx).QueryRowContext(ctx, query, userId, tokenHash).Scan(&valid); err != nil {
+		return false, wrapError("failed to check calendar token", err)
+	}
+	return valid, nil
+}
+
+func (s *Storage) DeleteCalendarToken(ctx context.Context, userId uuid.UUID) error {
+	res, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM calendar_token WHERE user_id = $1`, userId)
+	if err != nil {
+		return wrapError("failed to delete calendar token", err)
+	}
+	return checkAffected(res, "failed to delete calendar token")
+}
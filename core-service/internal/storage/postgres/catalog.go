@@ -0,0 +1,112 @@
+// Запросы в БД для массового импорта справочников
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// Строки справочника импортируются upsert по object_key внутри WithTx. Каждая строка
+// выполняется под точкой сохранения, поэтому ошибка в ней откатывает только эту строку
+// и не прерывает транзакцию импорта. Методы возвращают действие из отчета импорта
+
+// ImportRegion создает или обновляет регион; родитель уже должен быть в region.ParentId
+func (s *Storage) ImportRegion(ctx context.Context, region models.ImportRegion) (string, error) {
+	var boundary, minLat, minLon, maxLat, maxLon interface{}
+	if region.Boundary != nil {
+		boundary = string(region.Boundary.GeoJSON)
+		minLat, minLon = region.Boundary.MinLat, region.Boundary.MinLon
+		maxLat, maxLon = region.Boundary.MaxLat, region.Boundary.MaxLon
+	}
+
+	// Строка возвращается только если она вставлена или действительно изменилась
+	query := `
+        INSERT INTO region (id, object_key, name, country, img_url, tag, kind, parent_id, boundary, min_lat, min_lon, max_lat, max_lon)
+        VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9, $10, $11, $12, $13)
+        ON CONFLICT (object_key) DO UPDATE
+        SET name = EXCLUDED.name, country = EXCLUDED.country, img_url = EXCLUDED.img_url, tag = EXCLUDED.tag,
+            kind = EXCLUDED.kind, parent_id = EXCLUDED.parent_id,
+            boundary = coalesce(EXCLUDED.boundary, region.boundary),
+            min_lat = coalesce(EXCLUDED.min_lat, region.min_lat), min_lon = coalesce(EXCLUDED.min_lon, region.min_lon),
+            max_lat = coalesce(EXCLUDED.max_lat, region.max_lat), max_lon = coalesce(EXCLUDED.max_lon, region.max_lon)
+        WHERE (region.name, region.country, region.img_url, region.tag, region.kind, region.parent_id)
+                  IS DISTINCT FROM (EXCLUDED.name, EXCLUDED.country, EXCLUDED.img_url, EXCLUDED.tag, EXCLUDED.kind, EXCLUDED.parent_id)
+           OR coalesce(EXCLUDED.boundary, region.boundary) IS DISTINCT FROM region.boundary
+        RETURNING (xmax = 0)
+    `
+	return s.importRow(ctx, "failed to import region", query, region.Id, region.ObjectKey, region.Name, region.Country, region.ImgUrl, region.Tag,
+		region.Kind, region.ParentId, boundary, minLat, minLon, maxLat, maxLon)
+}
+
+// ImportPlace создает или обновляет место в регионе с ключом place.RegionKey
+func (s *Storage) ImportPlace(ctx context.Context, place models.ImportPlace) (string, error) {
+	var regionId uuid.UUID
+	err := s.conn(ctx).QueryRowContext(ctx, `SELECT id FROM region WHERE object_key = $1`, place.RegionKey).Scan(&regionId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: region %q not found", storage.ErrValidation, place.RegionKey)
+	}
+	if err != nil {
+		return "", wrapError("failed to get region by key", err)
+	}
+
+	query := `
+        INSERT INTO place (object_key, name, description, region_id, latitude, longitude)
+        VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)
+        ON CONFLICT (object_key) DO UPDATE
+        SET name = EXCLUDED.name, description = EXCLUDED.description, region_id = EXCLUDED.region_id,
+            latitude = EXCLUDED.latitude, longitude = EXCLUDED.longitude
+        WHERE (place.name, place.description, place.region_id, place.latitude, place.longitude)
+                  IS DISTINCT FROM (EXCLUDED.name, EXCLUDED.description, EXCLUDED.region_id, EXCLUDED.latitude, EXCLUDED.longitude)
+        RETURNING (xmax = 0)
+    `
+	return s.importRow(ctx, "failed to import place", query, place.ObjectKey, place.Name, place.Description, regionId, place.Latitude, place.Longitude)
+}
+
+func (s *Storage) ImportTag(ctx context.Context, tag models.Tag) (string, error) {
+	query := `
+        INSERT INTO tag (id, object_key, name)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (object_key) DO UPDATE
+        SET name = EXCLUDED.name
+        WHERE tag.name IS DISTINCT FROM EXCLUDED.name
+        RETURNING (xmax = 0)
+    `
+	return s.importRow(ctx, "failed to import tag", query, tag.Id, tag.ObjectKey, tag.Name)
+}
+
+// importRow выполняет upsert под точкой сохранения и переводит результат в действие:
+// нет строки - запись не изменилась, xmax = 0 - строка вставлена, иначе обновлена
+func (s *Storage) importRow(ctx context.Context, msg string, query string, args ...any) (string, error) {
+	conn := s.conn(ctx)
+	if _, err := conn.ExecContext(ctx, `SAVEPOINT import_row`); err != nil {
+		return "", wrapError("failed to create import savepoint", err)
+	}
+
+	var inserted bool
+	err := conn.QueryRowContext(ctx, query, args...).Scan(&inserted)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		if _, rbErr := conn.ExecContext(ctx, `ROLLBACK TO SAVEPOINT import_row`); rbErr != nil {
+			return "", wrapError("failed to roll back import row", rbErr)
+		}
+		return "", wrapError(msg, err)
+	}
+	if _, err := conn.ExecContext(ctx, `RELEASE SAVEPOINT import_row`); err != nil {
+		return "", wrapError("failed to release import savepoint", err)
+	}
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return models.ImportUnchanged, nil
+	case inserted:
+		return models.ImportCreate, nil
+	default:
+		return models.ImportUpdate, nil
+	}
+}
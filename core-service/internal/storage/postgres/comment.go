@@ -0,0 +1,230 @@
+// Запросы в БД, связанные с комментариями
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// Колонки комментария в порядке scanComment; упоминания собираются в строку через запятую
+const commentColumns = `
+        c.id, c.photo_id, c.trip_id, c.parent_id, c.user_id, u.username,
+        CASE WHEN c.deleted_at IS NULL THEN c.body ELSE '' END,
+        array_to_string(ARRAY(SELECT m.user_id::text FROM comment_mention m WHERE m.comment_id = c.id), ','),
+        c.created_at, c.updated_at, c.deleted_at IS NOT NULL, c.hidden_at IS NOT NULL
+`
+
+func (s *Storage) CreateComment(ctx context.Context, comment models.Comment) error {
+	var photoId, tripId uuid.NullUUID
+	switch comment.Target.Kind {
+	case models.CommentTargetPhoto:
+		photoId = nullUUID(comment.Target.Id)
+	case models.CommentTargetTrip:
+		tripId = nullUUID(comment.Target.Id)
+	default:
+		return fmt.Errorf("failed to create comment: %w: unknown comment target %q", storage.ErrValidation, comment.Target.Kind)
+	}
+
+	// Комментарий не сохраняется, если у автора блокировка с автором фото, с участником
+	// поездки или с автором родительского комментария
+	query := `
+        INSERT INTO comment (id, photo_id, trip_id, parent_id, user_id, body)
+        SELECT $1::uuid, $2::uuid, $3::uuid, $4::uuid, $5::uuid, $6::text
+        WHERE NOT EXISTS (
+            SELECT 1 FROM photo p WHERE p.id = $2 AND NOT ` + notBlocked("p.user_id", "$5") + `
+        ) AND NOT EXISTS (
+            SELECT 1 FROM user_trip ut WHERE ut.trip_id = $3 AND NOT ` + notBlocked("ut.user_id", "$5") + `
+        ) AND NOT EXISTS (
+            SELECT 1 FROM comment pc WHERE pc.id = $4 AND NOT ` + notBlocked("pc.user_id", "$5") + `
+        )
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, comment.Id, photoId, tripId, comment.ParentId, comment.UserId, comment.Body)
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("failed to create comment: %w: %s or author does not exist", storage.ErrNotFound, comment.Target.Kind)
+	}
+	if err != nil {
+		return wrapError("failed to create comment", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("failed to create comment: %w: user is blocked", storage.ErrForbidden)
+	}
+	return nil
+}
+
+func (s *Storage) GetComment(ctx context.Context, commentId uuid.UUID) (models.Comment, error) {
+	query := `SELECT ` + commentColumns + `
+        FROM comment c
+        INNER JOIN user_account u ON u.id = c.user_id
+        WHERE c.id = $1
+    `
+	comment, err := scanComment(s.conn(ctx).QueryRowContext(ctx, query, commentId))
+	if err != nil {
+		return comment, wrapError("failed to get comment", err)
+	}
+	return comment, nil
+}
+
+// GetComments возвращает все комментарии и ответы к фото или поездке в порядке создания.
+// Комментарии пользователей, с которыми у viewerId есть блокировка, не возвращаются
+func (s *Storage) GetComments(ctx context.Context, target models.CommentTarget, viewerId uuid.UUID) ([]models.Comment, error) {
+	var comments []models.Comment
+
+	column, err := commentTargetColumn(target.Kind)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + commentColumns + `
+        FROM comment c
+        INNER JOIN user_account u ON u.id = c.user_id
+        WHERE c.` + column + ` = $1 AND ` + notBlocked("c.user_id", "$2") + `
+        ORDER BY c.created_at, c.id
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, target.Id, viewerId)
+	if err != nil {
+		return nil, wrapError("failed to get comments", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, wrapError("failed to scan comment", err)
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// UpdateComment сохраняет прежний текст в историю и заменяет его новым
+func (s *Storage) UpdateComment(ctx context.Context, commentId uuid.UUID, body string) error {
+	query := `
+        WITH old AS (
+            SELECT id, body FROM comment WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+        ), revision AS (
+            INSERT INTO comment_revision (comment_id, body) SELECT id, body FROM old
+        )
+        UPDATE comment SET body = $2, updated_at = now()
+        WHERE id IN (SELECT id FROM old)
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, commentId, body)
+	if err != nil {
+		return wrapError("failed to update comment", err)
+	}
+	return checkAffected(res, "failed to update comment")
+}
+
+// DeleteComment мягко удаляет комментарий: текст скрывается, ответы остаются
+func (s *Storage) DeleteComment(ctx context.Context, commentId uuid.UUID) error {
+	query := `UPDATE comment SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL`
+	res, err := s.conn(ctx).ExecContext(ctx, query, commentId)
+	if err != nil {
+		return wrapError("failed to delete comment", err)
+	}
+	return checkAffected(res, "failed to delete comment")
+}
+
+// SetCommentMentions заменяет список упомянутых в комментарии пользователей
+// SetCommentHidden скрывает комментарий или возвращает скрытый
+func (s *Storage) SetCommentHidden(ctx context.Context, commentId uuid.UUID, hidden bool) error {
+	query := `UPDATE comment SET hidden_at = CASE WHEN $2 THEN coalesce(hidden_at, now()) END WHERE id = $1`
+	res, err := s.conn(ctx).ExecContext(ctx, query, commentId, hidden)
+	if err != nil {
+		return wrapError("failed to set comment hidden", err)
+	}
+	return checkAffected(res, "failed to set comment hidden")
+}
+
+func (s *Storage) SetCommentMentions(ctx context.Context, commentId uuid.UUID, userIds []uuid.UUID) error {
+	if _, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM comment_mention WHERE comment_id = $1`, commentId); err != nil {
+		return wrapError("failed to clear comment mentions", err)
+	}
+	for _, userId := range userIds {
+		_, err := s.conn(ctx).ExecContext(ctx,
+			`INSERT INTO comment_mention (comment_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			commentId, userId)
+		if err != nil {
+			return wrapError("failed to save comment mention", err)
+		}
+	}
+	return nil
+}
+
+// GetCommentRevisions возвращает прежние версии комментария от старых к новым
+func (s *Storage) GetCommentRevisions(ctx context.Context, commentId uuid.UUID) ([]models.CommentRevision, error) {
+	var revisions []models.CommentRevision
+
+	query := `
+        SELECT comment_id, body, edited_at
+        FROM comment_revision
+        WHERE comment_id = $1
+        ORDER BY edited_at, id
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, commentId)
+	if err != nil {
+		return nil, wrapError("failed to get comment revisions", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var revision models.CommentRevision
+		if err := rows.Scan(&revision.CommentId, &revision.Body, &revision.EditedAt); err != nil {
+			return nil, wrapError("failed to scan comment revision", err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+func commentTargetColumn(kind string) (string, error) {
+	switch kind {
+	case models.CommentTargetPhoto:
+		return "photo_id", nil
+	case models.CommentTargetTrip:
+		return "trip_id", nil
+	}
+	return "", fmt.Errorf("%w: unknown comment target %q", storage.ErrValidation, kind)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanComment(row rowScanner) (models.Comment, error) {
+	var comment models.Comment
+	var photoId, tripId uuid.NullUUID
+	var mentions string
+	var updatedAt sql.NullTime
+
+	err := row.Scan(&comment.Id, &photoId, &tripId, &comment.ParentId, &comment.UserId, &comment.UserName,
+		&comment.Body, &mentions, &comment.CreatedAt, &updatedAt, &comment.Deleted, &comment.Hidden)
+	if err != nil {
+		return comment, err
+	}
+
+	if photoId.Valid {
+		comment.Target = models.CommentTarget{Kind: models.CommentTargetPhoto, Id: photoId.UUID}
+	} else {
+		comment.Target = models.CommentTarget{Kind: models.CommentTargetTrip, Id: tripId.UUID}
+	}
+	if updatedAt.Valid {
+		at := updatedAt.Time
+		comment.UpdatedAt = &at
+	}
+	if mentions != "" {
+		for _, id := range strings.Split(mentions, ",") {
+			if userId, err := uuid.Parse(id); err == nil {
+				comment.Mentions = append(comment.Mentions, userId)
+			}
+		}
+	}
+	return comment, nil
+}
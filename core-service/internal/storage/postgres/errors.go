@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// Коды ошибок PostgreSQL, которые переводятся в ошибки хранилища
+const (
+	pgUniqueViolation      = "23505"
+	pgForeignKeyViolation  = "23503"
+	pgNotNullViolation     = "23502"
+	pgCheckViolation       = "23514"
+	pgStringDataTruncation = "22001"
+	pgInvalidTextRepresent = "22P02"
+)
+
+// wrapError оборачивает ошибку драйвера в доменную ошибку хранилища:
+// отсутствие строки - storage.ErrNotFound, нарушение уникальности - storage.ErrConflict,
+// ссылка на несуществующую запись и некорректные значения - storage.ErrValidation
+func wrapError(msg string, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", msg, storage.ErrNotFound)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		return fmt.Errorf("%s: %w: %s", msg, storage.ErrConflict, describeConstraint(pgErr))
+	case pgForeignKeyViolation:
+		return fmt.Errorf("%s: %w: referenced record does not exist (%s)", msg, storage.ErrValidation, pgErr.ConstraintName)
+	case pgNotNullViolation:
+		return fmt.Errorf("%s: %w: %s is required", msg, storage.ErrValidation, pgErr.ColumnName)
+	case pgCheckViolation, pgStringDataTruncation, pgInvalidTextRepresent:
+		return fmt.Errorf("%s: %w: %s", msg, storage.ErrValidation, pgErr.Message)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// wrapDeleteError отличается от wrapError тем, что нарушение внешнего ключа при удалении
+// означает, что на запись еще ссылаются, и переводится в storage.ErrConflict
+func wrapDeleteError(msg string, err error) error {
+	if isForeignKeyViolation(err) {
+		var pgErr *pgconn.PgError
+		errors.As(err, &pgErr)
+		return fmt.Errorf("%s: %w: record is still referenced by %s", msg, storage.ErrConflict, pgErr.TableName)
+	}
+	return wrapError(msg, err)
+}
+
+// checkAffected возвращает storage.ErrNotFound, если запрос не затронул ни одной строки
+func checkAffected(res sql.Result, msg string) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", msg, storage.ErrNotFound)
+	}
+	return nil
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation
+}
+
+func describeConstraint(pgErr *pgconn.PgError) string {
+	if pgErr.Detail != "" {
+		return pgErr.Detail
+	}
+	return pgErr.ConstraintName
+}
+
+// nullUUID сохраняет нулевой UUID как NULL, чтобы необязательные ссылки не нарушали внешние ключи
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
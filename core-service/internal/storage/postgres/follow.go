@@ -0,0 +1,259 @@
+// Запросы в БД, связанные с подписками и домашней лентой
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+func (s *Storage) FollowUser(ctx context.Context, followerId uuid.UUID, followeeId uuid.UUID) error {
+	query := `
+        INSERT INTO user_follow (follower_id, followee_id)
+        VALUES ($1, $2)
+        ON CONFLICT DO NOTHING
+    `
+	_, err := s.conn(ctx).ExecContext(ctx, query, followerId, followeeId)
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("failed to follow user: %w: user does not exist", storage.ErrNotFound)
+	}
+	if err != nil {
+		return wrapError("failed to follow user", err)
+	}
+	return nil
+}
+
+func (s *Storage) UnfollowUser(ctx context.Context, followerId uuid.UUID, followeeId uuid.UUID) error {
+	query := `DELETE FROM user_follow WHERE follower_id = $1 AND followee_id = $2`
+	_, err := s.conn(ctx).ExecContext(ctx, query, followerId, followeeId)
+	if err != nil {
+		return wrapError("failed to unfollow user", err)
+	}
+	return nil
+}
+
+// GetFollowers возвращает подписчиков пользователя, начиная с последних
+func (s *Storage) GetFollowers(ctx context.Context, userId uuid.UUID) ([]models.Follow, error) {
+	query := `
+        SELECT u.id, u.username, coalesce(u.avatar_url, ''), f.created_at
+        FROM user_follow f
+        INNER JOIN user_account u ON u.id = f.follower_id
+        WHERE f.followee_id = $1
+        ORDER BY f.created_at DESC
+    `
+	return s.queryFollows(ctx, query, userId)
+}
+
+// GetFollowing возвращает пользователей, на которых подписан пользователь, начиная с последних
+func (s *Storage) GetFollowing(ctx context.Context, userId uuid.UUID) ([]models.Follow, error) {
+	query := `
+        SELECT u.id, u.username, coalesce(u.avatar_url, ''), f.created_at
+        FROM user_follow f
+        INNER JOIN user_account u ON u.id = f.followee_id
+        WHERE f.follower_id = $1
+        ORDER BY f.created_at DESC
+    `
+	return s.queryFollows(ctx, query, userId)
+}
+
+func (s *Storage) queryFollows(ctx context.Context, query string, userId uuid.UUID) ([]models.Follow, error) {
+	var follows []models.Follow
+
+	rows, err := s.conn(ctx).QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, wrapError("failed to get follows", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var follow models.Follow
+		if err := rows.Scan(&follow.UserId, &follow.UserName, &follow.AvatarUrl, &follow.FollowedAt); err != nil {
+			return nil, wrapError("failed to scan follow", err)
+		}
+		follows = append(follows, follow)
+	}
+
+	return follows, rows.Err()
+}
+
+func (s *Storage) FollowRegion(ctx context.Context, userId uuid.UUID, regionId uuid.UUID) error {
+	query := `
+        INSERT INTO region_follow (user_id, region_id)
+        VALUES ($1, $2)
+        ON CONFLICT DO NOTHING
+    `
+	_, err := s.conn(ctx).ExecContext(ctx, query, userId, regionId)
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("failed to follow region: %w: user or region does not exist", storage.ErrNotFound)
+	}
+	if err != nil {
+		return wrapError("failed to follow region", err)
+	}
+	return nil
+}
+
+func (s *Storage) UnfollowRegion(ctx context.Context, userId uuid.UUID, regionId uuid.UUID) error {
+	query := `DELETE FROM region_follow WHERE user_id = $1 AND region_id = $2`
+	_, err := s.conn(ctx).ExecContext(ctx, query, userId, regionId)
+	if err != nil {
+		return wrapError("failed to unfollow region", err)
+	}
+	return nil
+}
+
+// GetFollowedRegions возвращает регионы, на которые подписан пользователь
+func (s *Storage) GetFollowedRegions(ctx context.Context, userId uuid.UUID) ([]models.Region, error) {
+	var regions []models.Region
+
+	query := `
+        SELECT r.id, r.name, r.country, r.object_key, coalesce(r.img_url, ''), coalesce(r.tag, ''), r.kind, r.parent_id
+        FROM region_follow f
+        INNER JOIN region r ON r.id = f.region_id
+        WHERE f.user_id = $1
+        ORDER BY f.created_at DESC
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, wrapError("failed to get followed regions", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var region models.Region
+		err := rows.Scan(&region.Id, &region.Name, &region.Country, &region.ObjectKey, &region.ImgUrl, &region.Tag, &region.Kind, &region.ParentId)
+		if err != nil {
+			return nil, wrapError("failed to scan region", err)
+		}
+		regions = append(regions, region)
+	}
+
+	return regions, rows.Err()
+}
+
+// Начальная позиция ленты: раньше любой записи
+var feedStart = models.TimeCursor{At: time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), Id: uuid.Max}
+
+// GetHomePhotos возвращает до limit последних фото подписок пользователя (авторов и регионов)
+// раньше before без фото скрытых и заблокированных авторов. Для каждой подписки читается не больше limit фото по индексу, поэтому
+// запрос остается быстрым и при сотнях подписок
+func (s *Storage) GetHomePhotos(ctx context.Context, userId uuid.UUID, before *models.TimeCursor, limit int) ([]models.Photo, error) {
+	var photos []models.Photo
+	if before == nil {
+		before = &feedStart
+	}
+
+	query := `
+        WITH candidates AS (
+            SELECT fp.id
+            FROM user_follow f
+            CROSS JOIN LATERAL (
+                SELECT id FROM photo
+                WHERE user_id = f.followee_id AND moderation_status = 'approved' AND (created_at, id) < ($2, $3)
+                  AND ` + photoVisibleTo("photo", "$1") + ` AND ` + notMuted("photo.user_id", "$1") + `
+                ORDER BY created_at DESC, id DESC
+                LIMIT $4
+            ) fp
+            WHERE f.follower_id = $1
+            UNION
+            SELECT rp.id
+            FROM region_follow f
+            CROSS JOIN LATERAL (
+                SELECT id FROM photo
+                WHERE region_id = f.region_id AND moderation_status = 'approved' AND (created_at, id) < ($2, $3)
+                  AND ` + photoVisibleTo("photo", "$1") + ` AND ` + notMuted("photo.user_id", "$1") + `
+                ORDER BY created_at DESC, id DESC
+                LIMIT $4
+            ) rp
+            WHERE f.user_id = $1
+        )
+        SELECT p.id, coalesce(p.coords, ''), coalesce(p.description, ''), p.img_url, coalesce(p.thumbnail_url, ''), coalesce(p.place, ''), p.place_id, p.region_id, p.trip_id, p.user_id, p.likes, p.watched_amount, p.comments, p.created_at, p.moderation_status, coalesce(p.moderation_reason, ''), p.visibility, p.taken_at,
+               EXISTS (SELECT 1 FROM photo_likes pl WHERE pl.photo_id = p.id AND pl.user_id = $1)
+        FROM photo p
+        WHERE p.id IN (SELECT id FROM candidates)
+        ORDER BY p.created_at DESC, p.id DESC
+        LIMIT $4
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, userId, before.At, before.Id, limit)
+	if err != nil {
+		return nil, wrapError("failed to get home photos", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var photo models.Photo
+		err := rows.Scan(&photo.Id, &photo.Coords, &photo.Description, &photo.ImgUrl, &photo.ThumbnailUrl, &photo.Place, &photo.PlaceId, &photo.RegionId, &photo.TripId, &photo.UserId, &photo.Likes, &photo.Views, &photo.Comments, &photo.CreatedAt, &photo.ModerationStatus, &photo.ModerationReason, &photo.Visibility, &photo.TakenAt, &photo.LikedByMe)
+		if err != nil {
+			return nil, wrapError("failed to scan photo", err)
+		}
+		photos = append(photos, photo)
+	}
+
+	return photos, rows.Err()
+}
+
+// GetHomeTrips возвращает до limit последних поездок подписок пользователя раньше before
+func (s *Storage) GetHomeTrips(ctx context.Context, userId uuid.UUID, before *models.TimeCursor, limit int) ([]models.Trip, error) {
+	var trips []models.Trip
+	if before == nil {
+		before = &feedStart
+	}
+
+	// Поездка не попадает в ленту, если хотя бы один из ее авторов заблокирован или скрыт
+	// зрителем. Фильтр стоит внутри обеих веток до LIMIT, иначе лента окажется короче limit
+	authorsVisible := `NOT EXISTS (
+                    SELECT 1 FROM user_trip au WHERE au.trip_id = t.id
+                      AND NOT (` + notBlocked("au.user_id", "$1") + ` AND ` + notMuted("au.user_id", "$1") + `))`
+
+	query := `
+        WITH candidates AS (
+            SELECT ft.id
+            FROM user_follow f
+            CROSS JOIN LATERAL (
+                SELECT t.id FROM user_trip ut
+                INNER JOIN trip t ON t.id = ut.trip_id
+                WHERE ut.user_id = f.followee_id AND t.hidden_at IS NULL AND (t.created_at, t.id) < ($2, $3)
+                  AND ` + authorsVisible + `
+                ORDER BY t.created_at DESC, t.id DESC
+                LIMIT $4
+            ) ft
+            WHERE f.follower_id = $1
+            UNION
+            SELECT rt.id
+            FROM region_follow f
+            CROSS JOIN LATERAL (
+                SELECT t.id FROM trip t
+                WHERE t.region_id = f.region_id AND t.hidden_at IS NULL AND (t.created_at, t.id) < ($2, $3)
+                  AND ` + authorsVisible + `
+                ORDER BY t.created_at DESC, t.id DESC
+                LIMIT $4
+            ) rt
+            WHERE f.user_id = $1
+        )
+        SELECT t.id, t.name, coalesce(t.description, ''), t.region_id, coalesce(t.place, ''), t.place_id, t.comments, t.created_at, coalesce(to_char(t.start_date, 'YYYY-MM-DD'), ''), coalesce(to_char(t.end_date, 'YYYY-MM-DD'), '')
+        FROM trip t
+        WHERE t.id IN (SELECT id FROM candidates)
+        ORDER BY t.created_at DESC, t.id DESC
+        LIMIT $4
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, userId, before.At, before.Id, limit)
+	if err != nil {
+		return nil, wrapError("failed to get home trips", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var trip models.Trip
+		err := rows.Scan(&trip.Id, &trip.Name, &trip.Description, &trip.RegionId, &trip.Place, &trip.PlaceId, &trip.Comments, &trip.CreatedAt, &trip.StartDate, &trip.EndDate)
+		if err != nil {
+			return nil, wrapError("failed to scan trip", err)
+		}
+		trips = append(trips, trip)
+	}
+
+	return trips, rows.Err()
+}
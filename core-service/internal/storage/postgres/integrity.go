@@ -0,0 +1,77 @@
+// Проверка ссылочной целостности данных перед добавлением внешних ключей
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+type reference struct {
+	table    string
+	column   string
+	refTable string
+	// altKey - столбец refTable, по которому значение тоже может ссылаться на запись
+	altKey string
+}
+
+// references повторяет внешние ключи из миграции 0005_foreign_keys.sql
+var references = []reference{
+	{"region", "parent_id", "region", ""},
+	{"place", "region_id", "region", ""},
+	{"trip", "region_id", "region", ""},
+	{"trip", "place_id", "place", ""},
+	{"photo", "user_id", "user_account", ""},
+	{"photo", "region_id", "region", ""},
+	{"photo", "trip_id", "trip", ""},
+	{"photo", "place_id", "place", ""},
+	{"photo_tag", "photo_id", "photo", ""},
+	// Миграция 0005 сопоставляет photo_tag.tag_id с тегом и по id, и по object_key
+	{"photo_tag", "tag_id", "tag", "object_key"},
+	{"photo_likes", "photo_id", "photo", ""},
+	{"photo_likes", "user_id", "user_account", ""},
+	{"view", "photo_id", "photo", ""},
+	{"view", "user_id", "user_account", ""},
+	{"challenge", "user_id", "user_account", ""},
+	{"challenge", "trip_id", "trip", ""},
+	{"user_trip", "user_id", "user_account", ""},
+	{"user_trip", "trip_id", "trip", ""},
+}
+
+// danglingSampleSize - сколько примеров висячих значений попадает в отчет
+const danglingSampleSize = 10
+
+// CheckReferences находит строки со ссылками на несуществующие записи.
+// Сравнение идет по тексту, поэтому работает и до приведения photo_tag.tag_id к UUID
+func (s *Storage) CheckReferences(ctx context.Context) ([]models.DanglingReference, error) {
+	var result []models.DanglingReference
+
+	for _, ref := range references {
+		match := fmt.Sprintf("r.id::text = c.%s::text", ref.column)
+		if ref.altKey != "" {
+			match += fmt.Sprintf(" OR r.%s = c.%s::text", ref.altKey, ref.column)
+		}
+		query := fmt.Sprintf(`
+            SELECT count(*), coalesce(array_to_string((array_agg(DISTINCT c.%[2]s::text))[1:%[4]d], ','), '')
+            FROM %[1]s c
+            WHERE c.%[2]s IS NOT NULL
+              AND NOT EXISTS (SELECT 1 FROM %[3]s r WHERE %[5]s)
+        `, ref.table, ref.column, ref.refTable, danglingSampleSize, match)
+
+		dangling := models.DanglingReference{Table: ref.table, Column: ref.column, References: ref.refTable}
+		var sample string
+		err := s.conn(ctx).QueryRowContext(ctx, query).Scan(&dangling.Count, &sample)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s.%s: %w", ref.table, ref.column, err)
+		}
+		if dangling.Count > 0 {
+			dangling.Sample = strings.Split(sample, ",")
+			result = append(result, dangling)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,83 @@
+// Запросы в БД, связанные с маршрутом поездки
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// SetTripDates задает даты поездки; пустая строка сбрасывает дату
+func (s *Storage) SetTripDates(ctx context.Context, tripId uuid.UUID, startDate string, endDate string) error {
+	query := `UPDATE trip SET start_date = nullif($2, '')::date, end_date = nullif($3, '')::date WHERE id = $1`
+	res, err := s.conn(ctx).ExecContext(ctx, query, tripId, startDate, endDate)
+	if err != nil {
+		return wrapError("failed to set trip dates", err)
+	}
+	return checkAffected(res, "failed to set trip dates")
+}
+
+// GetTripStops возвращает остановки поездки в порядке маршрута
+func (s *Storage) GetTripStops(ctx context.Context, tripId uuid.UUID) ([]models.TripStop, error) {
+	var stops []models.TripStop
+
+	query := `
+        SELECT id, position, coalesce(place, ''), place_id, arrival_at, departure_at, coalesce(notes, '')
+        FROM trip_stop
+        WHERE trip_id = $1
+        ORDER BY position
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, tripId)
+	if err != nil {
+		return nil, wrapError("failed to get trip stops", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stop models.TripStop
+		var arrivalAt, departureAt sql.NullTime
+		err := rows.Scan(&stop.Id, &stop.Position, &stop.Place, &stop.PlaceId, &arrivalAt, &departureAt, &stop.Notes)
+		if err != nil {
+			return nil, wrapError("failed to scan trip stop", err)
+		}
+		if arrivalAt.Valid {
+			at := arrivalAt.Time
+			stop.ArrivalAt = &at
+		}
+		if departureAt.Valid {
+			at := departureAt.Time
+			stop.DepartureAt = &at
+		}
+		stops = append(stops, stop)
+	}
+
+	return stops, rows.Err()
+}
+
+// ReplaceTripStops заменяет маршрут поездки. Остановки сохраняют переданные Id, поэтому
+// вызывать нужно внутри транзакции
+func (s *Storage) ReplaceTripStops(ctx context.Context, tripId uuid.UUID, stops []models.TripStop) error {
+	if _, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM trip_stop WHERE trip_id = $1`, tripId); err != nil {
+		return wrapError("failed to clear trip stops", err)
+	}
+
+	query := `
+        INSERT INTO trip_stop (id, trip_id, position, place, place_id, arrival_at, departure_at, notes)
+        VALUES ($1, $2, $3, nullif($4, ''), $5, $6, $7, nullif($8, ''))
+    `
+	for _, stop := range stops {
+		_, err := s.conn(ctx).ExecContext(ctx, query, stop.Id, tripId, stop.Position, stop.Place, stop.PlaceId, stop.ArrivalAt, stop.DepartureAt, stop.Notes)
+		if isForeignKeyViolation(err) {
+			return fmt.Errorf("failed to save trip stop: %w: trip or place does not exist", storage.ErrNotFound)
+		}
+		if err != nil {
+			return wrapError("failed to save trip stop", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+// Запросы в БД, связанные с очередью фоновых задач
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+// EnqueueJob ставит задачу в очередь. Если MaxAttempts не задан, берется значение по умолчанию из схемы
+func (s *Storage) EnqueueJob(ctx context.Context, job models.Job) error {
+	query := `
+        INSERT INTO job (id, kind, payload, max_attempts, run_at)
+        VALUES ($1, $2, $3::jsonb, coalesce(nullif($4, 0), 5), coalesce($5, now()))
+    `
+	var runAt sql.NullTime
+	if !job.RunAt.IsZero() {
+		runAt = sql.NullTime{Time: job.RunAt, Valid: true}
+	}
+	_, err := s.conn(ctx).ExecContext(ctx, query, job.Id, job.Kind, string(job.Payload), job.MaxAttempts, runAt)
+	if err != nil {
+		return wrapError("failed to enqueue job", err)
+	}
+	return nil
+}
+
+// ClaimJobs забирает готовые задачи и задачи, воркер которых не уложился в lease.
+// Забранные задачи блокируются на lease, счетчик попыток увеличивается
+func (s *Storage) ClaimJobs(ctx context.Context, kinds []string, limit int, lease time.Duration) ([]models.Job, error) {
+	var jobs []models.Job
+
+	query := `
+        UPDATE job j
+        SET status = 'running', attempts = j.attempts + 1, locked_until = now() + make_interval(secs => $3)
+        WHERE j.id IN (
+            SELECT id FROM job
+            WHERE kind = ANY (string_to_array($1, ','))
+              AND ((status = 'queued' AND run_at <= now()) OR (status = 'running' AND locked_until < now()))
+            ORDER BY run_at
+            LIMIT $2
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING j.id, j.kind, j.payload, j.status, j.attempts, j.max_attempts, j.run_at,
+                  coalesce(j.last_error, ''), j.created_at
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, strings.Join(kinds, ","), limit, lease.Seconds())
+	if err != nil {
+		return nil, wrapError("failed to claim jobs", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, wrapError("failed to scan job", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+func (s *Storage) CompleteJob(ctx context.Context, jobId uuid.UUID) error {
+	query := `
+        UPDATE job SET status = 'done', locked_until = NULL, last_error = NULL, finished_at = now()
+        WHERE id = $1
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, jobId)
+	if err != nil {
+		return wrapError("failed to complete job", err)
+	}
+	return checkAffected(res, "failed to complete job")
+}
+
+// RetryJob возвращает задачу в очередь с запуском не раньше runAt
+func (s *Storage) RetryJob(ctx context.Context, jobId uuid.UUID, runAt time.Time, lastError string) error {
+	query := `
+        UPDATE job SET status = 'queued', run_at = $2, locked_until = NULL, last_error = $3
+        WHERE id = $1
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, jobId, runAt, lastError)
+	if err != nil {
+		return wrapError("failed to retry job", err)
+	}
+	return checkAffected(res, "failed to retry job")
+}
+
+// BuryJob переводит задачу в dead, где она остается до ручного перезапуска
+func (s *Storage) BuryJob(ctx context.Context, jobId uuid.UUID, lastError string) error {
+	query := `
+        UPDATE job SET status = 'dead', locked_until = NULL, last_error = $2, finished_at = now()
+        WHERE id = $1
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, jobId, lastError)
+	if err != nil {
+		return wrapError("failed to bury job", err)
+	}
+	return checkAffected(res, "failed to bury job")
+}
+
+// GetJobs возвращает последние задачи в состоянии status
+func (s *Storage) GetJobs(ctx context.Context, status string, limit int) ([]models.Job, error) {
+	var jobs []models.Job
+
+	query := `
+        SELECT id, kind, payload, status, attempts, max_attempts, run_at, coalesce(last_error, ''), created_at, finished_at
+        FROM job
+        WHERE status = $1
+        ORDER BY coalesce(finished_at, run_at) DESC, id
+        LIMIT $2
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, status, limit)
+	if err != nil {
+		return nil, wrapError("failed to get jobs", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var job models.Job
+		var payload []byte
+		var finishedAt sql.NullTime
+		err := rows.Scan(&job.Id, &job.Kind, &payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.RunAt,
+			&job.LastError, &job.CreatedAt, &finishedAt)
+		if err != nil {
+			return nil, wrapError("failed to scan job", err)
+		}
+		job.Payload = payload
+		if finishedAt.Valid {
+			at := finishedAt.Time
+			job.FinishedAt = &at
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// RequeueDeadJob возвращает задачу из dead в очередь с новым набором попыток
+func (s *Storage) RequeueDeadJob(ctx context.Context, jobId uuid.UUID) error {
+	query := `
+        UPDATE job SET status = 'queued', attempts = 0, run_at = now(), finished_at = NULL
+        WHERE id = $1 AND status = 'dead'
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, jobId)
+	if err != nil {
+		return wrapError("failed to requeue job", err)
+	}
+	return checkAffected(res, "failed to requeue job")
+}
+
+func scanJob(row rowScanner) (models.Job, error) {
+	var job models.Job
+	var payload []byte
+	err := row.Scan(&job.Id, &job.Kind, &payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.RunAt,
+		&job.LastError, &job.CreatedAt)
+	job.Payload = payload
+	return job, err
+}
@@ -0,0 +1,40 @@
+// Получение событий из PostgreSQL через LISTEN/NOTIFY
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+)
+
+// Listen подписывается на канал channel и вызывает handle для каждого полученного
+// сообщения, пока не отменен ctx или не оборвалось соединение. Под подписку
+// занимается отдельное соединение из пула, оно освобождается при выходе
+func (s *Storage) Listen(ctx context.Context, channel string, handle func(payload string)) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection for listen: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+
+		if _, err := pgxConn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return fmt.Errorf("failed to listen %s: %w", channel, err)
+		}
+		// Соединение вернется в пул, поэтому подписку нужно снять
+		defer pgxConn.Exec(context.WithoutCancel(ctx), "UNLISTEN *")
+
+		for {
+			notification, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to wait for notification: %w", err)
+			}
+			handle(notification.Payload)
+		}
+	})
+}
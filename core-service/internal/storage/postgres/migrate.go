@@ -0,0 +1,326 @@
+// Применение версионированных миграций из каталога migrations
+
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Файл миграции называется NNNN_name.sql. Все, что идет после строки downMarker,
+// откатывает миграцию; строка upMarker в начале файла необязательна
+const (
+	upMarker   = "-- +migrate Up"
+	downMarker = "-- +migrate Down"
+
+	// Ключ advisory lock, под которым миграции применяет только одна реплика
+	migrationLockKey = 7_241_530_218
+)
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// LoadMigrations читает миграции из каталога и сортирует их по версии
+func LoadMigrations(dir string) ([]Migration, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(files))
+	seen := make(map[int64]string, len(files))
+	for _, file := range files {
+		base := filepath.Base(file)
+		versionStr, name, ok := strings.Cut(strings.TrimSuffix(base, ".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q", base)
+		}
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", base, err)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %q and %q", version, other, base)
+		}
+		seen[version] = base
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", base, err)
+		}
+		sum := sha256.Sum256(content)
+
+		up, down, _ := strings.Cut(string(content), downMarker)
+		migrations = append(migrations, Migration{
+			Version:  version,
+			Name:     name,
+			Up:       strings.TrimSpace(strings.Replace(up, upMarker, "", 1)),
+			Down:     strings.TrimSpace(down),
+			Checksum: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrate применяет все еще не примененные миграции. Безопасно вызывается
+// одновременно с нескольких реплик: остальные ждут advisory lock
+func (s *Storage) Migrate(ctx context.Context, dir string) ([]Migration, error) {
+	const op = "storage.postgres.Migrate"
+
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var applied []Migration
+	err = s.withMigrationLock(ctx, migrations, func(conn *sql.Conn) error {
+		done, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if checksum, ok := done[m.Version]; ok {
+				if checksum != m.Checksum {
+					return fmt.Errorf("migration %d_%s was changed after it had been applied", m.Version, m.Name)
+				}
+				continue
+			}
+			if err := runMigration(ctx, conn, m.Up, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx,
+					`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+					m.Version, m.Name, m.Checksum)
+				return err
+			}); err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			applied = append(applied, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return applied, fmt.Errorf("%s: %w", op, err)
+	}
+	return applied, nil
+}
+
+// MigrateDown откатывает steps последних примененных миграций
+func (s *Storage) MigrateDown(ctx context.Context, dir string, steps int) ([]Migration, error) {
+	const op = "storage.postgres.MigrateDown"
+
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	byVersion := make(map[int64]Migration, len(migrations))
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	var reverted []Migration
+	err = s.withMigrationLock(ctx, migrations, func(conn *sql.Conn) error {
+		rows, err := conn.QueryContext(ctx, `SELECT version FROM schema_migrations ORDER BY version DESC LIMIT $1`, steps)
+		if err != nil {
+			return fmt.Errorf("failed to get applied migrations: %w", err)
+		}
+		var versions []int64
+		for rows.Next() {
+			var version int64
+			if err := rows.Scan(&version); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan migration version: %w", err)
+			}
+			versions = append(versions, version)
+		}
+		rows.Close()
+
+		for _, version := range versions {
+			m, ok := byVersion[version]
+			if !ok {
+				return fmt.Errorf("migration file for version %d not found", version)
+			}
+			if m.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down section", m.Version, m.Name)
+			}
+			if err := runMigration(ctx, conn, m.Down, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+				return err
+			}); err != nil {
+				return fmt.Errorf("failed to revert migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return reverted, fmt.Errorf("%s: %w", op, err)
+	}
+	return reverted, nil
+}
+
+// MigrationStatuses возвращает все миграции из каталога с отметкой о применении
+func (s *Storage) MigrationStatuses(ctx context.Context, dir string) ([]MigrationStatus, error) {
+	const op = "storage.postgres.MigrationStatuses"
+
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	var exists bool
+	err = s.db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to check schema_migrations: %w", op, err)
+	}
+	if !exists {
+		for _, m := range migrations {
+			statuses = append(statuses, MigrationStatus{Migration: m})
+		}
+		return statuses, nil
+	}
+
+	appliedAt := make(map[int64]time.Time)
+	rows, err := s.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get applied migrations: %w", op, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan migration: %w", op, err)
+		}
+		appliedAt[version] = at
+	}
+
+	for _, m := range migrations {
+		status := MigrationStatus{Migration: m}
+		if at, ok := appliedAt[m.Version]; ok {
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// withMigrationLock выполняет fn на выделенном соединении под session advisory lock
+func (s *Storage) withMigrationLock(ctx context.Context, migrations []Migration, fn func(conn *sql.Conn) error) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+
+	if err := ensureMigrationsTable(ctx, conn, migrations); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// ensureMigrationsTable создает таблицу schema_migrations. Если схема уже была
+// создана вручную из 0000_init_db.sql, начальная миграция отмечается примененной
+func ensureMigrationsTable(ctx context.Context, conn *sql.Conn, migrations []Migration) error {
+	var exists bool
+	err := conn.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check schema_migrations: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = conn.ExecContext(ctx, `
+        CREATE TABLE schema_migrations (
+            version BIGINT PRIMARY KEY,                      -- Версия миграции (префикс имени файла)
+            name VARCHAR(250) NOT NULL,                      -- Имя миграции
+            checksum VARCHAR(64) NOT NULL,                   -- sha256 файла миграции
+            applied_at TIMESTAMPTZ NOT NULL DEFAULT now()    -- Время применения
+        )
+    `)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var legacy bool
+	err = conn.QueryRowContext(ctx, `SELECT to_regclass('user_account') IS NOT NULL`).Scan(&legacy)
+	if err != nil {
+		return fmt.Errorf("failed to check existing schema: %w", err)
+	}
+	if legacy && len(migrations) > 0 && migrations[0].Version == 0 {
+		_, err = conn.ExecContext(ctx,
+			`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+			migrations[0].Version, migrations[0].Name, migrations[0].Checksum)
+		if err != nil {
+			return fmt.Errorf("failed to baseline existing schema: %w", err)
+		}
+	}
+	return nil
+}
+
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int64]string, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, checksum FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]string)
+	for rows.Next() {
+		var version int64
+		var checksum string
+		if err := rows.Scan(&version, &checksum); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		applied[version] = checksum
+	}
+	return applied, rows.Err()
+}
+
+// runMigration выполняет SQL миграции и запись в schema_migrations одной транзакцией
+func runMigration(ctx context.Context, conn *sql.Conn, script string, record func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if script != "" {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,158 @@
+// Запросы в БД, связанные с модерацией фото
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// photoApprovedFor возвращает условие, при котором статус модерации фото с псевдонимом alias
+// позволяет показать его пользователю viewer: фото одобрено или viewer - его автор
+func photoApprovedFor(alias string, viewer string) string {
+	return fmt.Sprintf(`(%[1]s.moderation_status = 'approved' OR %[1]s.user_id = %[2]s)`, alias, viewer)
+}
+
+// GetModerationQueue возвращает до limit фото, ожидающих модерации, начиная с самых старых
+func (s *Storage) GetModerationQueue(ctx context.Context, regionId uuid.UUID, limit int) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	query := `
+        SELECT id, coalesce(coords, ''), coalesce(description, ''), img_url, coalesce(thumbnail_url, ''), coalesce(place, ''), place_id, region_id, trip_id, user_id, likes, watched_amount, comments, created_at, moderation_status, coalesce(moderation_reason, ''), visibility, taken_at
+        FROM photo
+        WHERE moderation_status = 'pending' AND ($1::uuid IS NULL OR region_id = $1)
+        ORDER BY created_at, id
+        LIMIT $2
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, nullUUID(regionId), limit)
+	if err != nil {
+		return nil, wrapError("failed to get moderation queue", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var photo models.Photo
+		err := rows.Scan(&photo.Id, &photo.Coords, &photo.Description, &photo.ImgUrl, &photo.ThumbnailUrl, &photo.Place, &photo.PlaceId, &photo.RegionId, &photo.TripId, &photo.UserId, &photo.Likes, &photo.Views, &photo.Comments, &photo.CreatedAt, &photo.ModerationStatus, &photo.ModerationReason, &photo.Visibility, &photo.TakenAt)
+		if err != nil {
+			return nil, wrapError("failed to scan photo", err)
+		}
+		photos = append(photos, photo)
+	}
+
+	return photos, rows.Err()
+}
+
+// ModeratePhoto сохраняет решение модератора
+func (s *Storage) ModeratePhoto(ctx context.Context, photoId uuid.UUID, decision models.ModerationDecision) error {
+	query := `
+        UPDATE photo
+        SET moderation_status = $2, moderation_reason = nullif($3, ''), moderated_by = $4, moderated_at = now()
+        WHERE id = $1
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, photoId, decision.Status, decision.Reason, nullUUID(decision.ModeratorId))
+	if err != nil {
+		return wrapError("failed to moderate photo", err)
+	}
+	return checkAffected(res, "failed to moderate photo")
+}
+
+// IsModerator сообщает, является ли пользователь модератором; неизвестный пользователь им не является
+func (s *Storage) IsModerator(ctx context.Context, userId uuid.UUID) (bool, error) {
+	var moderator bool
+	err := s.conn(ctx).QueryRowContext(ctx, `SELECT is_moderator FROM user_account WHERE id = $1`, userId).Scan(&moderator)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, wrapError("failed to check moderator", err)
+	}
+	return moderator, nil
+}
+
+func (s *Storage) SetModerator(ctx context.Context, userId uuid.UUID, moderator bool) error {
+	res, err := s.conn(ctx).ExecContext(ctx, `UPDATE user_account SET is_moderator = $2 WHERE id = $1`, userId, moderator)
+	if err != nil {
+		return wrapError("failed to set moderator", err)
+	}
+	return checkAffected(res, "failed to set moderator")
+}
+
+// IsTrustedInRegion сообщает, доверен ли пользователь в регионе или в одном из его родительских регионов
+func (s *Storage) IsTrustedInRegion(ctx context.Context, userId uuid.UUID, regionId uuid.UUID) (bool, error) {
+	query := `
+        WITH RECURSIVE ancestors AS (
+            SELECT id, parent_id FROM region WHERE id = $2
+            UNION
+            SELECT r.id, r.parent_id FROM region r INNER JOIN ancestors a ON r.id = a.parent_id
+        )
+        SELECT EXISTS (
+            SELECT 1 FROM region_trusted_user t
+            INNER JOIN ancestors a ON a.id = t.region_id
+            WHERE t.user_id = $1
+        )
+    `
+	var trusted bool
+	if err := s.conn(ctx).QueryRowContext(ctx, query, userId, regionId).Scan(&trusted); err != nil {
+		return false, wrapError("failed to check trusted user", err)
+	}
+	return trusted, nil
+}
+
+// GetTrustedUsers возвращает доверенных пользователей региона
+func (s *Storage) GetTrustedUsers(ctx context.Context, regionId uuid.UUID) ([]models.TrustedUser, error) {
+	var users []models.TrustedUser
+
+	query := `
+        SELECT t.region_id, t.user_id, u.username, t.granted_by, t.created_at
+        FROM region_trusted_user t
+        INNER JOIN user_account u ON u.id = t.user_id
+        WHERE t.region_id = $1
+        ORDER BY t.created_at
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, regionId)
+	if err != nil {
+		return nil, wrapError("failed to get trusted users", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user models.TrustedUser
+		if err := rows.Scan(&user.RegionId, &user.UserId, &user.UserName, &user.GrantedBy, &user.CreatedAt); err != nil {
+			return nil, wrapError("failed to scan trusted user", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (s *Storage) AddTrustedUser(ctx context.Context, user models.TrustedUser) error {
+	query := `
+        INSERT INTO region_trusted_user (region_id, user_id, granted_by)
+        VALUES ($1, $2, $3)
+        ON CONFLICT DO NOTHING
+    `
+	_, err := s.conn(ctx).ExecContext(ctx, query, user.RegionId, user.UserId, user.GrantedBy)
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("failed to add trusted user: %w: region or user does not exist", storage.ErrNotFound)
+	}
+	if err != nil {
+		return wrapError("failed to add trusted user", err)
+	}
+	return nil
+}
+
+func (s *Storage) RemoveTrustedUser(ctx context.Context, regionId uuid.UUID, userId uuid.UUID) error {
+	query := `DELETE FROM region_trusted_user WHERE region_id = $1 AND user_id = $2`
+	_, err := s.conn(ctx).ExecContext(ctx, query, regionId, userId)
+	if err != nil {
+		return wrapError("failed to remove trusted user", err)
+	}
+	return nil
+}
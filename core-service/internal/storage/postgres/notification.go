@@ -0,0 +1,173 @@
+// Запросы в БД, связанные с уведомлениями
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// AddNotification складывает событие в непрочитанное уведомление того же типа о
+// том же объекте или создает новое. Если получатель отключил этот тип, ничего не делает
+func (s *Storage) AddNotification(ctx context.Context, event models.NotificationEvent) error {
+	query := `
+        WITH enabled AS (
+            SELECT coalesce(
+                (SELECT enabled FROM notification_preference WHERE user_id = $1 AND type = $2),
+                true
+            ) AS enabled
+        ), n AS (
+            INSERT INTO notification (user_id, type, subject_id, last_actor_id)
+            SELECT $1, $2, $3, $4 FROM enabled WHERE enabled AND ` + notBlocked("$4", "$1") + `
+            ON CONFLICT (user_id, type, subject_id) WHERE read_at IS NULL
+            DO UPDATE SET last_actor_id = EXCLUDED.last_actor_id, updated_at = now()
+            RETURNING id
+        )
+        INSERT INTO notification_actor (notification_id, actor_id)
+        SELECT id, $4 FROM n
+        ON CONFLICT DO NOTHING
+    `
+	_, err := s.conn(ctx).ExecContext(ctx, query, event.UserId, event.Type, event.SubjectId, event.ActorId)
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("failed to add notification: %w: user does not exist", storage.ErrNotFound)
+	}
+	if err != nil {
+		return wrapError("failed to add notification", err)
+	}
+	return nil
+}
+
+// GetNotifications возвращает последние уведомления пользователя, сначала свежие
+func (s *Storage) GetNotifications(ctx context.Context, userId uuid.UUID, unreadOnly bool, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+
+	query := `
+        SELECT n.id, n.type, n.subject_id,
+               (SELECT count(*) FROM notification_actor a WHERE a.notification_id = n.id),
+               n.last_actor_id, coalesce(u.username, ''), n.created_at, n.updated_at, n.read_at IS NOT NULL
+        FROM notification n
+        LEFT JOIN user_account u ON u.id = n.last_actor_id
+        WHERE n.user_id = $1 AND (NOT $2 OR n.read_at IS NULL)
+        ORDER BY n.updated_at DESC, n.id
+        LIMIT $3
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, userId, unreadOnly, limit)
+	if err != nil {
+		return nil, wrapError("failed to get notifications", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var n models.Notification
+		// Последний пользователь мог удалить аккаунт
+		var lastActorId uuid.NullUUID
+		err := rows.Scan(&n.Id, &n.Type, &n.SubjectId, &n.ActorCount, &lastActorId, &n.LastActorName,
+			&n.CreatedAt, &n.UpdatedAt, &n.Read)
+		if err != nil {
+			return nil, wrapError("failed to scan notification", err)
+		}
+		n.LastActorId = lastActorId.UUID
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (s *Storage) CountUnreadNotifications(ctx context.Context, userId uuid.UUID) (int, error) {
+	var count int
+	query := `SELECT count(*) FROM notification WHERE user_id = $1 AND read_at IS NULL`
+	if err := s.conn(ctx).QueryRowContext(ctx, query, userId).Scan(&count); err != nil {
+		return 0, wrapError("failed to count unread notifications", err)
+	}
+	return count, nil
+}
+
+// MarkNotificationRead отмечает уведомление прочитанным. Чужое уведомление считается несуществующим
+func (s *Storage) MarkNotificationRead(ctx context.Context, userId uuid.UUID, notificationId uuid.UUID) error {
+	query := `
+        UPDATE notification SET read_at = coalesce(read_at, now())
+        WHERE id = $1 AND user_id = $2
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, notificationId, userId)
+	if err != nil {
+		return wrapError("failed to mark notification read", err)
+	}
+	return checkAffected(res, "failed to mark notification read")
+}
+
+// MarkAllNotificationsRead отмечает прочитанными все уведомления пользователя и возвращает их число
+func (s *Storage) MarkAllNotificationsRead(ctx context.Context, userId uuid.UUID) (int, error) {
+	query := `UPDATE notification SET read_at = now() WHERE user_id = $1 AND read_at IS NULL`
+	res, err := s.conn(ctx).ExecContext(ctx, query, userId)
+	if err != nil {
+		return 0, wrapError("failed to mark notifications read", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, wrapError("failed to mark notifications read", err)
+	}
+	return int(affected), nil
+}
+
+// GetNotificationPreferences возвращает только явно заданные настройки пользователя
+func (s *Storage) GetNotificationPreferences(ctx context.Context, userId uuid.UUID) ([]models.NotificationPreference, error) {
+	var preferences []models.NotificationPreference
+
+	query := `SELECT type, enabled FROM notification_preference WHERE user_id = $1`
+	rows, err := s.conn(ctx).QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, wrapError("failed to get notification preferences", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var preference models.NotificationPreference
+		if err := rows.Scan(&preference.Type, &preference.Enabled); err != nil {
+			return nil, wrapError("failed to scan notification preference", err)
+		}
+		preferences = append(preferences, preference)
+	}
+
+	return preferences, rows.Err()
+}
+
+func (s *Storage) SetNotificationPreference(ctx context.Context, userId uuid.UUID, preference models.NotificationPreference) error {
+	query := `
+        INSERT INTO notification_preference (user_id, type, enabled)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled
+    `
+	_, err := s.conn(ctx).ExecContext(ctx, query, userId, preference.Type, preference.Enabled)
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("failed to set notification preference: %w: user does not exist", storage.ErrNotFound)
+	}
+	if err != nil {
+		return wrapError("failed to set notification preference", err)
+	}
+	return nil
+}
+
+// GetTripMembers возвращает участников поездки
+func (s *Storage) GetTripMembers(ctx context.Context, tripId uuid.UUID) ([]uuid.UUID, error) {
+	var userIds []uuid.UUID
+
+	rows, err := s.conn(ctx).QueryContext(ctx, `SELECT user_id FROM user_trip WHERE trip_id = $1`, tripId)
+	if err != nil {
+		return nil, wrapError("failed to get trip members", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userId uuid.UUID
+		if err := rows.Scan(&userId); err != nil {
+			return nil, wrapError("failed to scan trip member", err)
+		}
+		userIds = append(userIds, userId)
+	}
+
+	return userIds, rows.Err()
+}
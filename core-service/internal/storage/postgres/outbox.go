@@ -0,0 +1,251 @@
+// Запросы в БД, связанные с доменными событиями и доставкой вебхуков
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+// AddOutboxEvent сохраняет доменное событие. Вызывается внутри WithTx вместе с
+// изменением, чтобы событие появлялось тогда и только тогда, когда изменение зафиксировано
+func (s *Storage) AddOutboxEvent(ctx context.Context, event models.OutboxEvent) error {
+	query := `INSERT INTO outbox_event (id, type, payload) VALUES ($1, $2, $3::jsonb)`
+	_, err := s.conn(ctx).ExecContext(ctx, query, event.Id, event.Type, string(event.Payload))
+	if err != nil {
+		return wrapError("failed to add outbox event", err)
+	}
+	return nil
+}
+
+// FanOutOutboxEvents создает доставки для еще не разложенных событий по активным
+// вебхукам, подписанным на их тип, и возвращает число обработанных событий.
+// Несколько реплик могут выполнять запрос одновременно, не мешая друг другу
+func (s *Storage) FanOutOutboxEvents(ctx context.Context, limit int) (int, error) {
+	query := `
+        WITH events AS (
+            SELECT id, type FROM outbox_event
+            WHERE dispatched_at IS NULL
+            ORDER BY created_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        ), deliveries AS (
+            INSERT INTO webhook_delivery (webhook_id, event_id)
+            SELECT w.id, e.id
+            FROM events e
+            INNER JOIN webhook w ON w.active AND (cardinality(w.event_types) = 0 OR e.type = ANY (w.event_types))
+            ON CONFLICT DO NOTHING
+        )
+        UPDATE outbox_event SET dispatched_at = now()
+        WHERE id IN (SELECT id FROM events)
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, limit)
+	if err != nil {
+		return 0, wrapError("failed to fan out outbox events", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, wrapError("failed to fan out outbox events", err)
+	}
+	return int(affected), nil
+}
+
+// ClaimWebhookDeliveries забирает доставки, которым пора выполняться, и откладывает
+// их следующую попытку на lease, чтобы другие реплики не отправили их повторно,
+// пока идет запрос. Счетчик попыток увеличивается сразу
+func (s *Storage) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	query := `
+        UPDATE webhook_delivery d
+        SET attempts = d.attempts + 1, next_attempt_at = now() + make_interval(secs => $2)
+        FROM webhook w, outbox_event e
+        WHERE d.id IN (
+            SELECT pd.id
+            FROM webhook_delivery pd
+            INNER JOIN webhook pw ON pw.id = pd.webhook_id AND pw.active
+            WHERE pd.status = 'pending' AND pd.next_attempt_at <= now()
+            ORDER BY pd.next_attempt_at
+            LIMIT $1
+            FOR UPDATE OF pd SKIP LOCKED
+        ) AND w.id = d.webhook_id AND e.id = d.event_id
+        RETURNING d.id, d.webhook_id, w.url, w.secret, e.id, e.type, e.payload, e.created_at,
+                  d.status, d.attempts, d.next_attempt_at
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, wrapError("failed to claim webhook deliveries", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var payload []byte
+		err := rows.Scan(&d.Id, &d.WebhookId, &d.Url, &d.Secret, &d.Event.Id, &d.Event.Type, &payload,
+			&d.Event.CreatedAt, &d.Status, &d.Attempts, &d.NextAttemptAt)
+		if err != nil {
+			return nil, wrapError("failed to scan webhook delivery", err)
+		}
+		d.Event.Payload = payload
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// CompleteWebhookAttempt записывает попытку в журнал и переводит доставку в status.
+// Для status == pending следующая попытка назначается на nextAttemptAt
+func (s *Storage) CompleteWebhookAttempt(ctx context.Context, attempt models.WebhookAttempt, status string, nextAttemptAt time.Time) error {
+	query := `
+        INSERT INTO webhook_delivery_attempt (delivery_id, attempted_at, status_code, response, error, duration_ms)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `
+	_, err := s.conn(ctx).ExecContext(ctx, query, attempt.DeliveryId, attempt.AttemptedAt,
+		sql.NullInt32{Int32: int32(attempt.StatusCode), Valid: attempt.StatusCode != 0},
+		sql.NullString{String: attempt.Response, Valid: attempt.Response != ""},
+		sql.NullString{String: attempt.Error, Valid: attempt.Error != ""},
+		attempt.Duration.Milliseconds())
+	if err != nil {
+		return wrapError("failed to save webhook attempt", err)
+	}
+
+	query = `
+        UPDATE webhook_delivery
+        SET status = $2,
+            next_attempt_at = $3,
+            delivered_at = CASE WHEN $2 = 'delivered' THEN now() END
+        WHERE id = $1
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, attempt.DeliveryId, status, nextAttemptAt)
+	if err != nil {
+		return wrapError("failed to update webhook delivery", err)
+	}
+	return checkAffected(res, "failed to update webhook delivery")
+}
+
+func (s *Storage) CreateWebhook(ctx context.Context, webhook models.Webhook) error {
+	query := `
+        INSERT INTO webhook (id, url, secret, event_types, active)
+        VALUES ($1, $2, $3, string_to_array($4, ','), $5)
+    `
+	_, err := s.conn(ctx).ExecContext(ctx, query, webhook.Id, webhook.Url, webhook.Secret,
+		strings.Join(webhook.EventTypes, ","), webhook.Active)
+	if err != nil {
+		return wrapError("failed to create webhook", err)
+	}
+	return nil
+}
+
+// GetWebhooks возвращает зарегистрированные вебхуки без секретов
+func (s *Storage) GetWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+
+	query := `
+        SELECT id, url, array_to_string(event_types, ','), active, created_at
+        FROM webhook
+        ORDER BY created_at
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return nil, wrapError("failed to get webhooks", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var webhook models.Webhook
+		var eventTypes string
+		if err := rows.Scan(&webhook.Id, &webhook.Url, &eventTypes, &webhook.Active, &webhook.CreatedAt); err != nil {
+			return nil, wrapError("failed to scan webhook", err)
+		}
+		if eventTypes != "" {
+			webhook.EventTypes = strings.Split(eventTypes, ",")
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func (s *Storage) DeleteWebhook(ctx context.Context, webhookId uuid.UUID) error {
+	res, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM webhook WHERE id = $1`, webhookId)
+	if err != nil {
+		return wrapDeleteError("failed to delete webhook", err)
+	}
+	return checkAffected(res, "failed to delete webhook")
+}
+
+// GetWebhookDeliveries возвращает последние доставки вебхука, сначала новые
+func (s *Storage) GetWebhookDeliveries(ctx context.Context, webhookId uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	query := `
+        SELECT d.id, d.webhook_id, e.id, e.type, e.payload, e.created_at,
+               d.status, d.attempts, d.next_attempt_at, d.delivered_at
+        FROM webhook_delivery d
+        INNER JOIN outbox_event e ON e.id = d.event_id
+        WHERE d.webhook_id = $1
+        ORDER BY d.created_at DESC, d.id
+        LIMIT $2
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, webhookId, limit)
+	if err != nil {
+		return nil, wrapError("failed to get webhook deliveries", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var payload []byte
+		var deliveredAt sql.NullTime
+		err := rows.Scan(&d.Id, &d.WebhookId, &d.Event.Id, &d.Event.Type, &payload, &d.Event.CreatedAt,
+			&d.Status, &d.Attempts, &d.NextAttemptAt, &deliveredAt)
+		if err != nil {
+			return nil, wrapError("failed to scan webhook delivery", err)
+		}
+		d.Event.Payload = payload
+		if deliveredAt.Valid {
+			at := deliveredAt.Time
+			d.DeliveredAt = &at
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// GetWebhookAttempts возвращает журнал попыток доставки в порядке выполнения
+func (s *Storage) GetWebhookAttempts(ctx context.Context, deliveryId uuid.UUID) ([]models.WebhookAttempt, error) {
+	var attempts []models.WebhookAttempt
+
+	query := `
+        SELECT delivery_id, attempted_at, coalesce(status_code, 0), coalesce(response, ''),
+               coalesce(error, ''), duration_ms
+        FROM webhook_delivery_attempt
+        WHERE delivery_id = $1
+        ORDER BY attempted_at, id
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, deliveryId)
+	if err != nil {
+		return nil, wrapError("failed to get webhook attempts", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var attempt models.WebhookAttempt
+		var durationMs int64
+		err := rows.Scan(&attempt.DeliveryId, &attempt.AttemptedAt, &attempt.StatusCode, &attempt.Response,
+			&attempt.Error, &durationMs)
+		if err != nil {
+			return nil, wrapError("failed to scan webhook attempt", err)
+		}
+		attempt.Duration = time.Duration(durationMs) * time.Millisecond
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts, rows.Err()
+}
@@ -0,0 +1,93 @@
+// Запросы в БД, связанные со справочником мест
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+func (s *Storage) CreatePlace(ctx context.Context, place models.Place) error {
+	query := `
+        INSERT INTO place (object_key, name, description, region_id, latitude, longitude)
+        VALUES (NULLIF($1, ''), $2, $3, $4, $5, $6)
+    `
+	_, err := s.conn(ctx).ExecContext(ctx, query, place.ObjectKey, place.Name, place.Description, place.RegionId, place.Latitude, place.Longitude)
+	if err != nil {
+		return wrapError("failed to create place", err)
+	}
+	return nil
+}
+
+func (s *Storage) DeletePlace(ctx context.Context, placeId uuid.UUID) error {
+	query := `DELETE FROM place WHERE id = $1`
+	res, err := s.conn(ctx).ExecContext(ctx, query, placeId)
+	if err != nil {
+		return wrapDeleteError("failed to delete place", err)
+	}
+	return checkAffected(res, "failed to delete place")
+}
+
+func (s *Storage) GetPlaceById(ctx context.Context, placeId uuid.UUID) (models.Place, error) {
+	var place models.Place
+
+	query := `
+        SELECT id, coalesce(object_key, ''), name, coalesce(description, ''), region_id, latitude, longitude
+        FROM place
+        WHERE id = $1
+    `
+	row := s.conn(ctx).QueryRowContext(ctx, query, placeId)
+
+	err := row.Scan(&place.Id, &place.ObjectKey, &place.Name, &place.Description, &place.RegionId, &place.Latitude, &place.Longitude)
+	if err != nil {
+		return place, wrapError("failed to get place", err)
+	}
+
+	return place, nil
+}
+
+// GetPlaces возвращает места региона или все места, если regionId не задан
+func (s *Storage) GetPlaces(ctx context.Context, regionId uuid.UUID) ([]models.Place, error) {
+	var places []models.Place
+
+	query := `SELECT id, coalesce(object_key, ''), name, coalesce(description, ''), region_id, latitude, longitude FROM place`
+	args := []interface{}{}
+
+	if regionId != uuid.Nil {
+		query += " WHERE region_id = $1"
+		args = append(args, regionId)
+	}
+	query += " ORDER BY name"
+
+	rows, err := s.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError("failed to get places", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var place models.Place
+		err := rows.Scan(&place.Id, &place.ObjectKey, &place.Name, &place.Description, &place.RegionId, &place.Latitude, &place.Longitude)
+		if err != nil {
+			return nil, wrapError("failed to scan place", err)
+		}
+		places = append(places, place)
+	}
+
+	return places, nil
+}
+
+func (s *Storage) UpdatePlace(ctx context.Context, placeId uuid.UUID, data models.Place) error {
+	query := `
+        UPDATE place
+        SET object_key = NULLIF($1, ''), name = $2, description = $3, region_id = $4, latitude = $5, longitude = $6
+        WHERE id = $7
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, data.ObjectKey, data.Name, data.Description, data.RegionId, data.Latitude, data.Longitude, placeId)
+	if err != nil {
+		return wrapError("failed to update place", err)
+	}
+	return checkAffected(res, "failed to update place")
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
 	// "github.com/shameoff/more-than-trip/core/internal/jaeger"
 )
 
@@ -34,33 +35,32 @@ func New(dsn string) (*Storage, error) {
 func (s *Storage) SavePhoto(ctx context.Context, data models.Photo) error {
 	// Сохранение информации о фотографии в базу данных
 	query := `
-        INSERT INTO photo (coords, description, img_url, place, region_id, trip_id, user_id)
-        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        INSERT INTO photo (id, coords, description, img_url, place, place_id, region_id, trip_id, user_id, moderation_status, visibility, taken_at)
+        VALUES (coalesce($1, gen_random_uuid()), $2, $3, $4, $5, $6, $7, $8, $9, coalesce(nullif($10, ''), 'pending'), coalesce(nullif($11, ''), 'public'), $12)
     `
-	_, err := s.db.ExecContext(ctx, query, data.Coords, data.Description, data.ImgUrl, data.Place, data.RegionId, data.TripId, data.UserId)
+	_, err := s.conn(ctx).ExecContext(ctx, query, nullUUID(data.Id), data.Coords, data.Description, data.ImgUrl, data.Place, data.PlaceId, data.RegionId, nullUUID(data.TripId), data.UserId, data.ModerationStatus, data.Visibility, data.TakenAt)
 	if err != nil {
-		return fmt.Errorf("failed to save photo data: %w", err)
+		return wrapError("failed to save photo data", err)
 	}
 
 	return nil
 }
 
-func (s *Storage) GetPhoto(ctx context.Context, photoId uuid.UUID) (models.Photo, error) {
+// GetPhoto возвращает фото; LikedByMe заполняется для пользователя viewerId
+func (s *Storage) GetPhoto(ctx context.Context, photoId uuid.UUID, viewerId uuid.UUID) (models.Photo, error) {
 	var photo models.Photo
 
 	query := `
-        SELECT id, coords, description, img_url, place, region_id, trip_id, user_id
+        SELECT id, coalesce(coords, ''), coalesce(description, ''), img_url, coalesce(thumbnail_url, ''), coalesce(place, ''), place_id, region_id, trip_id, user_id, likes, watched_amount, comments, created_at, moderation_status, coalesce(moderation_reason, ''), visibility, taken_at,
+               EXISTS (SELECT 1 FROM photo_likes pl WHERE pl.photo_id = photo.id AND pl.user_id = $2)
         FROM photo
         WHERE id = $1
     `
-	row := s.db.QueryRowContext(ctx, query, photoId)
+	row := s.conn(ctx).QueryRowContext(ctx, query, photoId, viewerId)
 
-	err := row.Scan(&photo.Id, &photo.Coords, &photo.Description, &photo.ImgUrl, &photo.Place, &photo.RegionId, &photo.TripId, &photo.UserId)
+	err := row.Scan(&photo.Id, &photo.Coords, &photo.Description, &photo.ImgUrl, &photo.ThumbnailUrl, &photo.Place, &photo.PlaceId, &photo.RegionId, &photo.TripId, &photo.UserId, &photo.Likes, &photo.Views, &photo.Comments, &photo.CreatedAt, &photo.ModerationStatus, &photo.ModerationReason, &photo.Visibility, &photo.TakenAt, &photo.LikedByMe)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return photo, fmt.Errorf("photo not found")
-		}
-		return photo, fmt.Errorf("failed to get photo: %w", err)
+		return photo, wrapError("failed to get photo", err)
 	}
 
 	return photo, nil
@@ -70,30 +70,26 @@ func (s *Storage) GetPhotos(ctx context.Context, filters models.PhotoFiltersDTO)
 	var photos []models.Photo
 
 	// Строим запрос в зависимости от фильтров
-	query := `SELECT id, coords, description, img_url, place, region_id, trip_id, user_id FROM photo WHERE 1=1`
-	args := []interface{}{}
-
-	if filters.RegionId != uuid.Nil {
-		query += " AND region_id = $1"
-		args = append(args, filters.RegionId)
-	}
-
-	if filters.TagKey != "" {
-		query += " AND id IN (SELECT photo_id FROM photo_tags WHERE tag_id = $2)"
-		args = append(args, filters.TagKey)
-	}
+	args := []interface{}{filters.ViewerId}
+	query := `
+        SELECT id, coalesce(coords, ''), coalesce(description, ''), img_url, coalesce(thumbnail_url, ''), coalesce(place, ''), place_id, region_id, trip_id, user_id, likes, watched_amount, comments, created_at, moderation_status, coalesce(moderation_reason, ''), visibility, taken_at,
+               EXISTS (SELECT 1 FROM photo_likes pl WHERE pl.photo_id = photo.id AND pl.user_id = $1)
+        FROM photo WHERE 1=1`
+	query, args = appendPhotoFilters(query, args, "photo", filters)
+	// Фото скрытых пользователем авторов в список не попадают
+	query += " AND " + notMuted("photo.user_id", "$1")
 
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	rows, err := s.conn(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get photos: %w", err)
+		return nil, wrapError("failed to get photos", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var photo models.Photo
-		err := rows.Scan(&photo.Id, &photo.Coords, &photo.Description, &photo.ImgUrl, &photo.Place, &photo.RegionId, &photo.TripId, &photo.UserId)
+		err := rows.Scan(&photo.Id, &photo.Coords, &photo.Description, &photo.ImgUrl, &photo.ThumbnailUrl, &photo.Place, &photo.PlaceId, &photo.RegionId, &photo.TripId, &photo.UserId, &photo.Likes, &photo.Views, &photo.Comments, &photo.CreatedAt, &photo.ModerationStatus, &photo.ModerationReason, &photo.Visibility, &photo.TakenAt, &photo.LikedByMe)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan photo: %w", err)
+			return nil, wrapError("failed to scan photo", err)
 		}
 		photos = append(photos, photo)
 	}
@@ -101,46 +97,93 @@ func (s *Storage) GetPhotos(ctx context.Context, filters models.PhotoFiltersDTO)
 	return photos, nil
 }
 
+// appendPhotoFilters дописывает к запросу условия фильтров фото; alias - имя или псевдоним таблицы photo
+func appendPhotoFilters(query string, args []interface{}, alias string, filters models.PhotoFiltersDTO) (string, []interface{}) {
+	// В общих списках показываются только одобренные модератором фото, видимые пользователю
+	query += fmt.Sprintf(" AND %s.moderation_status = 'approved'", alias)
+	args = append(args, filters.ViewerId)
+	query += " AND " + photoVisibleTo(alias, fmt.Sprintf("$%d", len(args)))
+
+	if filters.RegionId != uuid.Nil {
+		args = append(args, filters.RegionId)
+		query += fmt.Sprintf(" AND %s.region_id = $%d", alias, len(args))
+	}
+
+	if filters.TripId != uuid.Nil {
+		args = append(args, filters.TripId)
+		query += fmt.Sprintf(" AND %s.trip_id = $%d", alias, len(args))
+	}
+
+	if filters.TagKey != "" {
+		args = append(args, filters.TagKey)
+		query += fmt.Sprintf(" AND %s.id IN (SELECT pt.photo_id FROM photo_tag pt INNER JOIN tag t ON t.id = pt.tag_id WHERE t.object_key = $%d)", alias, len(args))
+	}
+
+	return query, args
+}
+
 func (s *Storage) UpdatePhoto(ctx context.Context, photoId uuid.UUID, data models.Photo) error {
 	query := `
         UPDATE photo
-        SET coords = $1, description = $2, img_url = $3, place = $4, region_id = $5, trip_id = $6, user_id = $7
+        SET coords = $1, description = $2, img_url = $3, place = $4, place_id = $5, region_id = $6, trip_id = $7,
+            thumbnail_url = CASE WHEN img_url = $3 THEN thumbnail_url END,
+            moderation_status = CASE
+                WHEN img_url <> $3 THEN coalesce(nullif($9, ''), 'pending')
+                WHEN region_id <> $6 AND moderation_status = 'approved' THEN coalesce(nullif($9, ''), 'pending')
+                ELSE moderation_status END,
+            moderation_reason = CASE WHEN img_url = $3 THEN moderation_reason END,
+            visibility = coalesce(nullif($10, ''), visibility),
+            taken_at = coalesce($11, taken_at)
         WHERE id = $8
     `
-	_, err := s.db.ExecContext(ctx, query, data.Coords, data.Description, data.ImgUrl, data.Place, data.RegionId, data.TripId, data.UserId, photoId)
+	// Замененный файл снова проходит модерацию со статусом data.ModerationStatus. Одобренное фото,
+	// перенесенное в другой регион, тоже получает этот статус, а отклоненное и скрытое остаются
+	// такими. Пустая видимость и время съемки оставляют прежние, автор фото не меняется
+	res, err := s.conn(ctx).ExecContext(ctx, query, data.Coords, data.Description, data.ImgUrl, data.Place, data.PlaceId, data.RegionId, nullUUID(data.TripId), photoId, data.ModerationStatus, data.Visibility, data.TakenAt)
 	if err != nil {
-		return fmt.Errorf("failed to update photo: %w", err)
+		return wrapError("failed to update photo", err)
 	}
-	return nil
+	return checkAffected(res, "failed to update photo")
+}
+
+// SetPhotoThumbnail сохраняет уменьшенную копию, если фото не заменили, пока она строилась
+func (s *Storage) SetPhotoThumbnail(ctx context.Context, photoId uuid.UUID, imgUrl string, thumbnailUrl string) error {
+	query := `UPDATE photo SET thumbnail_url = $3 WHERE id = $1 AND img_url = $2`
+	res, err := s.conn(ctx).ExecContext(ctx, query, photoId, imgUrl, thumbnailUrl)
+	if err != nil {
+		return wrapError("failed to set photo thumbnail", err)
+	}
+	return checkAffected(res, "failed to set photo thumbnail")
 }
 
 func (s *Storage) DeletePhoto(ctx context.Context, photoId uuid.UUID) error {
 	query := `DELETE FROM photo WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, photoId)
+	res, err := s.conn(ctx).ExecContext(ctx, query, photoId)
 	if err != nil {
-		return fmt.Errorf("failed to delete photo: %w", err)
+		return wrapDeleteError("failed to delete photo", err)
 	}
-	return nil
+	return checkAffected(res, "failed to delete photo")
 }
-func (s *Storage) GetPhotosByTripId(ctx context.Context, tripId uuid.UUID) ([]models.Photo, error) {
+func (s *Storage) GetPhotosByTripId(ctx context.Context, tripId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error) {
 	var photos []models.Photo
 
 	query := `
-        SELECT id, coords, description, img_url, place, region_id, trip_id, user_id
+        SELECT id, coalesce(coords, ''), coalesce(description, ''), img_url, coalesce(thumbnail_url, ''), coalesce(place, ''), place_id, region_id, trip_id, user_id, likes, watched_amount, comments, created_at, moderation_status, coalesce(moderation_reason, ''), visibility, taken_at,
+               EXISTS (SELECT 1 FROM photo_likes pl WHERE pl.photo_id = photo.id AND pl.user_id = $2)
         FROM photo
-        WHERE trip_id = $1
+        WHERE trip_id = $1 AND ` + photoApprovedFor("photo", "$2") + ` AND ` + photoVisibleTo("photo", "$2") + `
     `
-	rows, err := s.db.QueryContext(ctx, query, tripId)
+	rows, err := s.conn(ctx).QueryContext(ctx, query, tripId, viewerId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get photos by trip id: %w", err)
+		return nil, wrapError("failed to get photos by trip id", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var photo models.Photo
-		err := rows.Scan(&photo.Id, &photo.Coords, &photo.Description, &photo.ImgUrl, &photo.Place, &photo.RegionId, &photo.TripId, &photo.UserId)
+		err := rows.Scan(&photo.Id, &photo.Coords, &photo.Description, &photo.ImgUrl, &photo.ThumbnailUrl, &photo.Place, &photo.PlaceId, &photo.RegionId, &photo.TripId, &photo.UserId, &photo.Likes, &photo.Views, &photo.Comments, &photo.CreatedAt, &photo.ModerationStatus, &photo.ModerationReason, &photo.Visibility, &photo.TakenAt, &photo.LikedByMe)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan photo: %w", err)
+			return nil, wrapError("failed to scan photo", err)
 		}
 		photos = append(photos, photo)
 	}
@@ -148,25 +191,26 @@ func (s *Storage) GetPhotosByTripId(ctx context.Context, tripId uuid.UUID) ([]mo
 	return photos, nil
 }
 
-func (s *Storage) GetPhotosByUserId(ctx context.Context, userId uuid.UUID) ([]models.Photo, error) {
+func (s *Storage) GetPhotosByUserId(ctx context.Context, userId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error) {
 	var photos []models.Photo
 
 	query := `
-        SELECT id, coords, description, img_url, place, region_id, trip_id, user_id
+        SELECT id, coalesce(coords, ''), coalesce(description, ''), img_url, coalesce(thumbnail_url, ''), coalesce(place, ''), place_id, region_id, trip_id, user_id, likes, watched_amount, comments, created_at, moderation_status, coalesce(moderation_reason, ''), visibility, taken_at,
+               EXISTS (SELECT 1 FROM photo_likes pl WHERE pl.photo_id = photo.id AND pl.user_id = $2)
         FROM photo
-        WHERE user_id = $1
+        WHERE user_id = $1 AND ` + photoApprovedFor("photo", "$2") + ` AND ` + photoVisibleTo("photo", "$2") + `
     `
-	rows, err := s.db.QueryContext(ctx, query, userId)
+	rows, err := s.conn(ctx).QueryContext(ctx, query, userId, viewerId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get photos by user id: %w", err)
+		return nil, wrapError("failed to get photos by user id", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var photo models.Photo
-		err := rows.Scan(&photo.Id, &photo.Coords, &photo.Description, &photo.ImgUrl, &photo.Place, &photo.RegionId, &photo.TripId, &photo.UserId)
+		err := rows.Scan(&photo.Id, &photo.Coords, &photo.Description, &photo.ImgUrl, &photo.ThumbnailUrl, &photo.Place, &photo.PlaceId, &photo.RegionId, &photo.TripId, &photo.UserId, &photo.Likes, &photo.Views, &photo.Comments, &photo.CreatedAt, &photo.ModerationStatus, &photo.ModerationReason, &photo.Visibility, &photo.TakenAt, &photo.LikedByMe)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan photo: %w", err)
+			return nil, wrapError("failed to scan photo", err)
 		}
 		photos = append(photos, photo)
 	}
@@ -174,25 +218,26 @@ func (s *Storage) GetPhotosByUserId(ctx context.Context, userId uuid.UUID) ([]mo
 	return photos, nil
 }
 
-func (s *Storage) GetPhotosByRegionId(ctx context.Context, regionId uuid.UUID) ([]models.Photo, error) {
+func (s *Storage) GetPhotosByRegionId(ctx context.Context, regionId uuid.UUID, viewerId uuid.UUID) ([]models.Photo, error) {
 	var photos []models.Photo
 
 	query := `
-        SELECT id, coords, description, img_url, place, region_id, trip_id, user_id
+        SELECT id, coalesce(coords, ''), coalesce(description, ''), img_url, coalesce(thumbnail_url, ''), coalesce(place, ''), place_id, region_id, trip_id, user_id, likes, watched_amount, comments, created_at, moderation_status, coalesce(moderation_reason, ''), visibility, taken_at,
+               EXISTS (SELECT 1 FROM photo_likes pl WHERE pl.photo_id = photo.id AND pl.user_id = $2)
         FROM photo
-        WHERE region_id = $1
+        WHERE region_id = $1 AND ` + photoApprovedFor("photo", "$2") + ` AND ` + photoVisibleTo("photo", "$2") + `
     `
-	rows, err := s.db.QueryContext(ctx, query, regionId)
+	rows, err := s.conn(ctx).QueryContext(ctx, query, regionId, viewerId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get photos by region id: %w", err)
+		return nil, wrapError("failed to get photos by region id", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var photo models.Photo
-		err := rows.Scan(&photo.Id, &photo.Coords, &photo.Description, &photo.ImgUrl, &photo.Place, &photo.RegionId, &photo.TripId, &photo.UserId)
+		err := rows.Scan(&photo.Id, &photo.Coords, &photo.Description, &photo.ImgUrl, &photo.ThumbnailUrl, &photo.Place, &photo.PlaceId, &photo.RegionId, &photo.TripId, &photo.UserId, &photo.Likes, &photo.Views, &photo.Comments, &photo.CreatedAt, &photo.ModerationStatus, &photo.ModerationReason, &photo.Visibility, &photo.TakenAt, &photo.LikedByMe)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan photo: %w", err)
+			return nil, wrapError("failed to scan photo", err)
 		}
 		photos = append(photos, photo)
 	}
@@ -200,66 +245,106 @@ func (s *Storage) GetPhotosByRegionId(ctx context.Context, regionId uuid.UUID) (
 	return photos, nil
 }
 
-func (s *Storage) LikePhoto(ctx context.Context, photoId uuid.UUID, userId uuid.UUID) error {
+// LikePhoto ставит лайк, если у пользователя нет блокировки с автором фото, и сообщает,
+// поставлен ли он: повторный лайк ничего не меняет. Счетчики лайков фото и автора обновляет триггер на photo_likes
+func (s *Storage) LikePhoto(ctx context.Context, photoId uuid.UUID, userId uuid.UUID) (bool, error) {
 	query := `
-        INSERT INTO likes (photo_id, user_id)
-        VALUES ($1, $2)
+        INSERT INTO photo_likes (photo_id, user_id)
+        SELECT $1::uuid, $2::uuid
+        WHERE NOT EXISTS (SELECT 1 FROM photo p WHERE p.id = $1 AND NOT ` + notBlocked("p.user_id", "$2") + `)
         ON CONFLICT DO NOTHING
     `
-	_, err := s.db.ExecContext(ctx, query, photoId, userId)
+	res, err := s.conn(ctx).ExecContext(ctx, query, photoId, userId)
+	if isForeignKeyViolation(err) {
+		return false, fmt.Errorf("failed to like photo: %w: photo or user does not exist", storage.ErrNotFound)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to like photo: %w", err)
+		return false, wrapError("failed to like photo", err)
 	}
-	return nil
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to like photo: %w", err)
+	}
+	return inserted > 0, nil
 }
 
 func (s *Storage) DislikePhoto(ctx context.Context, photoId uuid.UUID, userId uuid.UUID) error {
 	query := `
-        DELETE FROM likes WHERE photo_id = $1 AND user_id = $2
+        DELETE FROM photo_likes WHERE photo_id = $1 AND user_id = $2
     `
-	_, err := s.db.ExecContext(ctx, query, photoId, userId)
+	_, err := s.conn(ctx).ExecContext(ctx, query, photoId, userId)
 	if err != nil {
-		return fmt.Errorf("failed to dislike photo: %w", err)
+		return wrapError("failed to dislike photo", err)
 	}
 	return nil
 }
 
+// GetPhotoLikes возвращает пользователей, лайкнувших фото, начиная с последних,
+// кроме тех, с кем у viewerId есть блокировка
+func (s *Storage) GetPhotoLikes(ctx context.Context, photoId uuid.UUID, viewerId uuid.UUID) ([]models.Like, error) {
+	var likes []models.Like
+
+	query := `
+        SELECT pl.photo_id, pl.user_id, u.username, coalesce(u.avatar_url, ''), pl.created_at
+        FROM photo_likes pl
+        INNER JOIN user_account u ON u.id = pl.user_id
+        WHERE pl.photo_id = $1 AND ` + notBlocked("pl.user_id", "$2") + `
+        ORDER BY pl.created_at DESC
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, photoId, viewerId)
+	if err != nil {
+		return nil, wrapError("failed to get photo likes", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var like models.Like
+		err := rows.Scan(&like.PhotoId, &like.UserId, &like.UserName, &like.AvatarUrl, &like.CreatedAt)
+		if err != nil {
+			return nil, wrapError("failed to scan photo like", err)
+		}
+		likes = append(likes, like)
+	}
+
+	return likes, rows.Err()
+}
+
 func (s *Storage) CreateTag(ctx context.Context, tag models.Tag) error {
 	query := `
-        INSERT INTO tag (name)
-        VALUES ($1, $2)
+        INSERT INTO tag (id, object_key, name)
+        VALUES ($1, NULLIF($2, ''), $3)
     `
-	_, err := s.db.ExecContext(ctx, query, tag.Id, tag.Name)
+	_, err := s.conn(ctx).ExecContext(ctx, query, tag.Id, tag.ObjectKey, tag.Name)
 	if err != nil {
-		return fmt.Errorf("failed to create tag: %w", err)
+		return wrapError("failed to create tag", err)
 	}
 	return nil
 }
 
 func (s *Storage) DeleteTag(ctx context.Context, tagId string) error {
 	query := `DELETE FROM tag WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, tagId)
+	res, err := s.conn(ctx).ExecContext(ctx, query, tagId)
 	if err != nil {
-		return fmt.Errorf("failed to delete tag: %w", err)
+		return wrapDeleteError("failed to delete tag", err)
 	}
-	return nil
+	return checkAffected(res, "failed to delete tag")
 }
 
 func (s *Storage) GetTags(ctx context.Context) ([]models.Tag, error) {
 	var tags []models.Tag
 
-	query := `SELECT id, name FROM tag`
-	rows, err := s.db.QueryContext(ctx, query)
+	query := `SELECT id, coalesce(object_key, ''), name FROM tag`
+	rows, err := s.conn(ctx).QueryContext(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get tags: %w", err)
+		return nil, wrapError("failed to get tags", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var tag models.Tag
-		err := rows.Scan(&tag.Id, &tag.Name)
+		err := rows.Scan(&tag.Id, &tag.ObjectKey, &tag.Name)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan tag: %w", err)
+			return nil, wrapError("failed to scan tag", err)
 		}
 		tags = append(tags, tag)
 	}
@@ -271,22 +356,22 @@ func (s *Storage) GetTagsByPhotoId(ctx context.Context, photoId uuid.UUID) ([]mo
 	var tags []models.Tag
 
 	query := `
-        SELECT t.id, t.name
+        SELECT t.id, coalesce(t.object_key, ''), t.name
         FROM tag t
-        INNER JOIN photo_tags pt ON t.id = pt.tag_id
+        INNER JOIN photo_tag pt ON t.id = pt.tag_id
         WHERE pt.photo_id = $1
     `
-	rows, err := s.db.QueryContext(ctx, query, photoId)
+	rows, err := s.conn(ctx).QueryContext(ctx, query, photoId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get tags by photo id: %w", err)
+		return nil, wrapError("failed to get tags by photo id", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var tag models.Tag
-		err := rows.Scan(&tag.Id, &tag.Name)
+		err := rows.Scan(&tag.Id, &tag.ObjectKey, &tag.Name)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan tag: %w", err)
+			return nil, wrapError("failed to scan tag", err)
 		}
 		tags = append(tags, tag)
 	}
@@ -296,33 +381,34 @@ func (s *Storage) GetTagsByPhotoId(ctx context.Context, photoId uuid.UUID) ([]mo
 
 func (s *Storage) CreateRegion(ctx context.Context, region models.Region) error {
 	query := `
-        INSERT INTO region (id, name, country)
-        VALUES ($1, $2, $3)
+        INSERT INTO region (id, name, country, object_key, img_url, tag, kind, parent_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
     `
-	_, err := s.db.ExecContext(ctx, query, region.Id, region.Name, region.Country)
+	_, err := s.conn(ctx).ExecContext(ctx, query, region.Id, region.Name, region.Country, region.ObjectKey, region.ImgUrl, region.Tag, region.Kind, region.ParentId)
 	if err != nil {
-		return fmt.Errorf("failed to create region: %w", err)
+		return wrapError("failed to create region", err)
 	}
 	return nil
 }
 func (s *Storage) DeleteRegion(ctx context.Context, regionId uuid.UUID) error {
 	query := `DELETE FROM region WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, regionId)
+	res, err := s.conn(ctx).ExecContext(ctx, query, regionId)
 	if err != nil {
-		return fmt.Errorf("failed to delete region: %w", err)
+		return wrapDeleteError("failed to delete region", err)
 	}
-	return nil
+	return checkAffected(res, "failed to delete region")
 }
 func (s *Storage) GetRegionById(ctx context.Context, regionId uuid.UUID) (models.Region, error) {
 	var region models.Region
 
-	query := `SELECT id, name, country FROM region WHERE id = $1`
-	err := s.db.QueryRowContext(ctx, query, regionId).Scan(&region.Id, &region.Name, &region.Country)
+	query := `
+        SELECT id, name, country, object_key, coalesce(img_url, ''), coalesce(tag, ''), kind, parent_id
+        FROM region
+        WHERE id = $1
+    `
+	err := s.conn(ctx).QueryRowContext(ctx, query, regionId).Scan(&region.Id, &region.Name, &region.Country, &region.ObjectKey, &region.ImgUrl, &region.Tag, &region.Kind, &region.ParentId)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return region, fmt.Errorf("region not found")
-		}
-		return region, fmt.Errorf("failed to get region: %w", err)
+		return region, wrapError("failed to get region", err)
 	}
 
 	return region, nil
@@ -331,13 +417,14 @@ func (s *Storage) GetRegionById(ctx context.Context, regionId uuid.UUID) (models
 func (s *Storage) GetRegionByKey(ctx context.Context, regionKey string) (models.Region, error) {
 	var region models.Region
 
-	query := `SELECT id, name, country FROM region WHERE name = $1`
-	err := s.db.QueryRowContext(ctx, query, regionKey).Scan(&region.Id, &region.Name, &region.Country)
+	query := `
+        SELECT id, name, country, object_key, coalesce(img_url, ''), coalesce(tag, ''), kind, parent_id
+        FROM region
+        WHERE object_key = $1
+    `
+	err := s.conn(ctx).QueryRowContext(ctx, query, regionKey).Scan(&region.Id, &region.Name, &region.Country, &region.ObjectKey, &region.ImgUrl, &region.Tag, &region.Kind, &region.ParentId)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return region, fmt.Errorf("region not found")
-		}
-		return region, fmt.Errorf("failed to get region by key: %w", err)
+		return region, wrapError("failed to get region by key", err)
 	}
 
 	return region, nil
@@ -345,18 +432,46 @@ func (s *Storage) GetRegionByKey(ctx context.Context, regionKey string) (models.
 func (s *Storage) GetRegions(ctx context.Context) ([]models.Region, error) {
 	var regions []models.Region
 
-	query := `SELECT id, name, country FROM region`
-	rows, err := s.db.QueryContext(ctx, query)
+	query := `SELECT id, name, country, object_key, coalesce(img_url, ''), coalesce(tag, ''), kind, parent_id FROM region ORDER BY name`
+	rows, err := s.conn(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return nil, wrapError("failed to get regions", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var region models.Region
+		err := rows.Scan(&region.Id, &region.Name, &region.Country, &region.ObjectKey, &region.ImgUrl, &region.Tag, &region.Kind, &region.ParentId)
+		if err != nil {
+			return nil, wrapError("failed to scan region", err)
+		}
+		regions = append(regions, region)
+	}
+
+	return regions, nil
+}
+
+// GetRegionChildren возвращает непосредственные дочерние регионы
+func (s *Storage) GetRegionChildren(ctx context.Context, parentId uuid.UUID) ([]models.Region, error) {
+	var regions []models.Region
+
+	query := `
+        SELECT id, name, country, object_key, coalesce(img_url, ''), coalesce(tag, ''), kind, parent_id
+        FROM region
+        WHERE parent_id = $1
+        ORDER BY name
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, parentId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get regions: %w", err)
+		return nil, wrapError("failed to get region children", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var region models.Region
-		err := rows.Scan(&region.Id, &region.Name, &region.Country)
+		err := rows.Scan(&region.Id, &region.Name, &region.Country, &region.ObjectKey, &region.ImgUrl, &region.Tag, &region.Kind, &region.ParentId)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan region: %w", err)
+			return nil, wrapError("failed to scan region", err)
 		}
 		regions = append(regions, region)
 	}
@@ -366,52 +481,49 @@ func (s *Storage) GetRegions(ctx context.Context) ([]models.Region, error) {
 func (s *Storage) UpdateRegion(ctx context.Context, regionId uuid.UUID, data models.Region) error {
 	query := `
         UPDATE region
-        SET name = $1, country = $2
-        WHERE id = $3
+        SET name = $1, country = $2, object_key = $3, img_url = $4, tag = $5, kind = $6, parent_id = $7
+        WHERE id = $8
     `
-	_, err := s.db.ExecContext(ctx, query, data.Name, data.Country, regionId)
+	res, err := s.conn(ctx).ExecContext(ctx, query, data.Name, data.Country, data.ObjectKey, data.ImgUrl, data.Tag, data.Kind, data.ParentId, regionId)
 	if err != nil {
-		return fmt.Errorf("failed to update region: %w", err)
+		return wrapError("failed to update region", err)
 	}
-	return nil
+	return checkAffected(res, "failed to update region")
 }
 
 func (s *Storage) CreateTrip(ctx context.Context, trip models.Trip) error {
 	query := `
-        INSERT INTO trip (name, description, region_id)
-        VALUES ($1, $2, $3, $4)
+        INSERT INTO trip (id, name, description, region_id, place, place_id, start_date, end_date)
+        VALUES (coalesce($1, gen_random_uuid()), $2, $3, $4, $5, $6, nullif($7, '')::date, nullif($8, '')::date)
     `
-	_, err := s.db.ExecContext(ctx, query, trip.Name, trip.Description, trip.RegionId)
+	_, err := s.conn(ctx).ExecContext(ctx, query, nullUUID(trip.Id), trip.Name, trip.Description, trip.RegionId, trip.Place, trip.PlaceId, trip.StartDate, trip.EndDate)
 	if err != nil {
-		return fmt.Errorf("failed to create trip: %w", err)
+		return wrapError("failed to create trip", err)
 	}
 	return nil
 }
 
 func (s *Storage) DeleteTrip(ctx context.Context, tripId uuid.UUID) error {
 	query := `DELETE FROM trip WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, tripId)
+	res, err := s.conn(ctx).ExecContext(ctx, query, tripId)
 	if err != nil {
-		return fmt.Errorf("failed to delete trip: %w", err)
+		return wrapDeleteError("failed to delete trip", err)
 	}
-	return nil
+	return checkAffected(res, "failed to delete trip")
 }
 func (s *Storage) GetTripById(ctx context.Context, tripId uuid.UUID) (models.Trip, error) {
 	var trip models.Trip
 
 	query := `
-        SELECT id, name, description, region_id
+        SELECT id, name, coalesce(description, ''), region_id, coalesce(place, ''), place_id, comments, created_at, coalesce(to_char(start_date, 'YYYY-MM-DD'), ''), coalesce(to_char(end_date, 'YYYY-MM-DD'), ''), hidden_at IS NOT NULL
         FROM trip
         WHERE id = $1
     `
-	row := s.db.QueryRowContext(ctx, query, tripId)
+	row := s.conn(ctx).QueryRowContext(ctx, query, tripId)
 
-	err := row.Scan(&trip.Id, &trip.Name, &trip.Description, &trip.RegionId)
+	err := row.Scan(&trip.Id, &trip.Name, &trip.Description, &trip.RegionId, &trip.Place, &trip.PlaceId, &trip.Comments, &trip.CreatedAt, &trip.StartDate, &trip.EndDate, &trip.Hidden)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return trip, fmt.Errorf("trip not found")
-		}
-		return trip, fmt.Errorf("failed to get trip: %w", err)
+		return trip, wrapError("failed to get trip", err)
 	}
 
 	return trip, nil
@@ -420,22 +532,22 @@ func (s *Storage) GetTripsByUserId(ctx context.Context, userId uuid.UUID) ([]mod
 	var trips []models.Trip
 
 	query := `
-        SELECT t.id, t.name, t.description, t.region_id
+        SELECT t.id, t.name, coalesce(t.description, ''), t.region_id, coalesce(t.place, ''), t.place_id, t.comments, t.created_at, coalesce(to_char(t.start_date, 'YYYY-MM-DD'), ''), coalesce(to_char(t.end_date, 'YYYY-MM-DD'), '')
         FROM trip t
-        INNER JOIN user_trips ut ON t.id = ut.trip_id
-        WHERE ut.user_id = $1
+        INNER JOIN user_trip ut ON t.id = ut.trip_id
+        WHERE ut.user_id = $1 AND t.hidden_at IS NULL
     `
-	rows, err := s.db.QueryContext(ctx, query, userId)
+	rows, err := s.conn(ctx).QueryContext(ctx, query, userId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get trips by user: %w", err)
+		return nil, wrapError("failed to get trips by user", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var trip models.Trip
-		err := rows.Scan(&trip.Id, &trip.Name, &trip.Description, &trip.RegionId)
+		err := rows.Scan(&trip.Id, &trip.Name, &trip.Description, &trip.RegionId, &trip.Place, &trip.PlaceId, &trip.Comments, &trip.CreatedAt, &trip.StartDate, &trip.EndDate)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan trip: %w", err)
+			return nil, wrapError("failed to scan trip", err)
 		}
 		trips = append(trips, trip)
 	}
@@ -446,47 +558,56 @@ func (s *Storage) GetTripsByRegionId(ctx context.Context, regionId uuid.UUID) ([
 	var trips []models.Trip
 
 	query := `
-        SELECT id, name, description, region_id
+        SELECT id, name, coalesce(description, ''), region_id, coalesce(place, ''), place_id, comments, created_at, coalesce(to_char(start_date, 'YYYY-MM-DD'), ''), coalesce(to_char(end_date, 'YYYY-MM-DD'), '')
         FROM trip
-        WHERE region_id = $1
+        WHERE region_id = $1 AND hidden_at IS NULL
     `
-	rows, err := s.db.QueryContext(ctx, query, regionId)
+	rows, err := s.conn(ctx).QueryContext(ctx, query, regionId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get trips by region: %w", err)
+		return nil, wrapError("failed to get trips by region", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var trip models.Trip
-		err := rows.Scan(&trip.Id, &trip.Name, &trip.Description, &trip.RegionId)
+		err := rows.Scan(&trip.Id, &trip.Name, &trip.Description, &trip.RegionId, &trip.Place, &trip.PlaceId, &trip.Comments, &trip.CreatedAt, &trip.StartDate, &trip.EndDate)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan trip: %w", err)
+			return nil, wrapError("failed to scan trip", err)
 		}
 		trips = append(trips, trip)
 	}
 
 	return trips, nil
 }
+
+// GetTripsByTag возвращает поездки, в которых есть опубликованные фото с тегом. Тег задается
+// идентификатором или ключом object_key, как в photo_tag до миграции 0005
 func (s *Storage) GetTripsByTag(ctx context.Context, tagId string) ([]models.Trip, error) {
 	var trips []models.Trip
 
 	query := `
-        SELECT t.id, t.name, t.description, t.region_id
+        SELECT t.id, t.name, coalesce(t.description, ''), t.region_id, coalesce(t.place, ''), t.place_id, t.comments, t.created_at, coalesce(to_char(t.start_date, 'YYYY-MM-DD'), ''), coalesce(to_char(t.end_date, 'YYYY-MM-DD'), '')
         FROM trip t
-        INNER JOIN trip_tags tt ON t.id = tt.trip_id
-        WHERE tt.tag_id = $1
+        WHERE t.hidden_at IS NULL AND EXISTS (
+            SELECT 1
+            FROM photo p
+            INNER JOIN photo_tag pt ON pt.photo_id = p.id
+            INNER JOIN tag tg ON tg.id = pt.tag_id
+            WHERE p.trip_id = t.id AND p.moderation_status = 'approved' AND p.visibility = 'public'
+              AND (tg.id::text = $1 OR tg.object_key = $1)
+        )
     `
-	rows, err := s.db.QueryContext(ctx, query, tagId)
+	rows, err := s.conn(ctx).QueryContext(ctx, query, tagId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get trips by tag: %w", err)
+		return nil, wrapError("failed to get trips by tag", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var trip models.Trip
-		err := rows.Scan(&trip.Id, &trip.Name, &trip.Description, &trip.RegionId)
+		err := rows.Scan(&trip.Id, &trip.Name, &trip.Description, &trip.RegionId, &trip.Place, &trip.PlaceId, &trip.Comments, &trip.CreatedAt, &trip.StartDate, &trip.EndDate)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan trip: %w", err)
+			return nil, wrapError("failed to scan trip", err)
 		}
 		trips = append(trips, trip)
 	}
@@ -494,37 +615,48 @@ func (s *Storage) GetTripsByTag(ctx context.Context, tagId string) ([]models.Tri
 	return trips, nil
 }
 
+// SetTripHidden скрывает поездку из списков или возвращает скрытую
+func (s *Storage) SetTripHidden(ctx context.Context, tripId uuid.UUID, hidden bool) error {
+	query := `UPDATE trip SET hidden_at = CASE WHEN $2 THEN coalesce(hidden_at, now()) END WHERE id = $1`
+	res, err := s.conn(ctx).ExecContext(ctx, query, tripId, hidden)
+	if err != nil {
+		return wrapError("failed to set trip hidden", err)
+	}
+	return checkAffected(res, "failed to set trip hidden")
+}
+
 func (s *Storage) UpdateTrip(ctx context.Context, tripId uuid.UUID, data models.Trip) error {
 	query := `
         UPDATE trip
-        SET name = $1, description = $2, region_id = $3
-        WHERE id = $4
+        SET name = $1, description = $2, region_id = $3, place = $4, place_id = $5,
+            start_date = nullif($7, '')::date, end_date = nullif($8, '')::date
+        WHERE id = $6
     `
-	_, err := s.db.ExecContext(ctx, query, data.Name, data.Description, data.RegionId, tripId)
+	res, err := s.conn(ctx).ExecContext(ctx, query, data.Name, data.Description, data.RegionId, data.Place, data.PlaceId, tripId, data.StartDate, data.EndDate)
 	if err != nil {
-		return fmt.Errorf("failed to update trip: %w", err)
+		return wrapError("failed to update trip", err)
 	}
-	return nil
+	return checkAffected(res, "failed to update trip")
 }
 
 func (s *Storage) CreateUser(ctx context.Context, user models.User) error {
 	query := `
-        INSERT INTO users (id, username, full_name, birth_date, education, city)
+        INSERT INTO user_account (id, username, full_name, birth_date, education, city)
         VALUES ($1, $2, $3, $4, $5, $6)
     `
-	_, err := s.db.ExecContext(ctx, query, user.Id, user.UserName, user.FullName, user.BirthDate, user.Education, user.City)
+	_, err := s.conn(ctx).ExecContext(ctx, query, user.Id, user.UserName, user.FullName, user.BirthDate, user.Education, user.City)
 	if err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
+		return wrapError("failed to create user", err)
 	}
 	return nil
 }
 func (s *Storage) DeleteUser(ctx context.Context, userId uuid.UUID) error {
-	query := `DELETE FROM users WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, userId)
+	query := `DELETE FROM user_account WHERE id = $1`
+	res, err := s.conn(ctx).ExecContext(ctx, query, userId)
 	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
+		return wrapDeleteError("failed to delete user", err)
 	}
-	return nil
+	return checkAffected(res, "failed to delete user")
 }
 
 func (s *Storage) GetUserById(ctx context.Context, userId uuid.UUID) (models.User, error) {
@@ -532,29 +664,31 @@ func (s *Storage) GetUserById(ctx context.Context, userId uuid.UUID) (models.Use
 
 	query := `
         SELECT id, username, full_name, birth_date, education, city
-        FROM users
+        FROM user_account
         WHERE id = $1
     `
-	row := s.db.QueryRowContext(ctx, query, userId)
+	row := s.conn(ctx).QueryRowContext(ctx, query, userId)
 
 	err := row.Scan(&user.Id, &user.UserName, &user.FullName, &user.BirthDate, &user.Education, &user.City)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return user, fmt.Errorf("user not found")
-		}
-		return user, fmt.Errorf("failed to get user: %w", err)
+		return user, wrapError("failed to get user", err)
 	}
 
 	return user, nil
 }
 
-func (s *Storage) GetUsers(ctx context.Context) ([]models.User, error) {
+// GetUsers возвращает пользователей, кроме тех, с кем у viewerId есть блокировка
+func (s *Storage) GetUsers(ctx context.Context, viewerId uuid.UUID) ([]models.User, error) {
 	var users []models.User
 
-	query := `SELECT id, username, full_name, birth_date, education, city FROM users`
-	rows, err := s.db.QueryContext(ctx, query)
+	query := `
+        SELECT id, username, full_name, birth_date, education, city
+        FROM user_account
+        WHERE ` + notBlocked("user_account.id", "$1") + `
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, viewerId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get users: %w", err)
+		return nil, wrapError("failed to get users", err)
 	}
 	defer rows.Close()
 
@@ -562,42 +696,42 @@ func (s *Storage) GetUsers(ctx context.Context) ([]models.User, error) {
 		var user models.User
 		err := rows.Scan(&user.Id, &user.UserName, &user.FullName, &user.BirthDate, &user.Education, &user.City)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan user: %w", err)
+			return nil, wrapError("failed to scan user", err)
 		}
 		users = append(users, user)
 	}
 
 	return users, nil
 }
-func (s *Storage) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
+
+// GetUserByUsername ищет пользователя по имени. Пользователь, с которым у viewerId
+// есть блокировка, не находится
+func (s *Storage) GetUserByUsername(ctx context.Context, username string, viewerId uuid.UUID) (models.User, error) {
 	var user models.User
 
 	query := `
         SELECT id, username, full_name, birth_date, education, city
-        FROM users
-        WHERE username = $1
+        FROM user_account
+        WHERE username = $1 AND ` + notBlocked("user_account.id", "$2") + `
     `
-	row := s.db.QueryRowContext(ctx, query, username)
+	row := s.conn(ctx).QueryRowContext(ctx, query, username, viewerId)
 
 	err := row.Scan(&user.Id, &user.UserName, &user.FullName, &user.BirthDate, &user.Education, &user.City)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return user, fmt.Errorf("user not found")
-		}
-		return user, fmt.Errorf("failed to get user: %w", err)
+		return user, wrapError("failed to get user", err)
 	}
 
 	return user, nil
 }
 func (s *Storage) UpdateUser(ctx context.Context, userId uuid.UUID, data models.User) error {
 	query := `
-        UPDATE users
+        UPDATE user_account
         SET username = $1, full_name = $2, birth_date = $3, education = $4, city = $5
         WHERE id = $6
     `
-	_, err := s.db.ExecContext(ctx, query, data.UserName, data.FullName, data.BirthDate, data.Education, data.City, userId)
+	res, err := s.conn(ctx).ExecContext(ctx, query, data.UserName, data.FullName, data.BirthDate, data.Education, data.City, userId)
 	if err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
+		return wrapError("failed to update user", err)
 	}
-	return nil
+	return checkAffected(res, "failed to update user")
 }
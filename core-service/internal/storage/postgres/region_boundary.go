@@ -0,0 +1,109 @@
+// Запросы в БД, связанные с границами регионов
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+func (s *Storage) SetRegionBoundary(ctx context.Context, boundary models.RegionBoundary) error {
+	query := `
+        UPDATE region
+        SET boundary = $1, min_lat = $2, min_lon = $3, max_lat = $4, max_lon = $5
+        WHERE id = $6
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, string(boundary.GeoJSON), boundary.MinLat, boundary.MinLon, boundary.MaxLat, boundary.MaxLon, boundary.RegionId)
+	if err != nil {
+		return wrapError("failed to set region boundary", err)
+	}
+	return checkAffected(res, "failed to set region boundary")
+}
+
+// GetRegionBoundariesAt возвращает границы регионов, в прямоугольник которых попадает точка
+func (s *Storage) GetRegionBoundariesAt(ctx context.Context, lat float64, lon float64) ([]models.RegionBoundary, error) {
+	query := `
+        SELECT id, kind, boundary, min_lat, min_lon, max_lat, max_lon
+        FROM region
+        WHERE boundary IS NOT NULL
+          AND min_lat <= $1 AND max_lat >= $1
+          AND min_lon <= $2 AND max_lon >= $2
+    `
+	return s.queryRegionBoundaries(ctx, query, lat, lon)
+}
+
+// GetRegionBoundaries возвращает границы всех регионов, для которых они заданы
+func (s *Storage) GetRegionBoundaries(ctx context.Context) ([]models.RegionBoundary, error) {
+	query := `
+        SELECT id, kind, boundary, min_lat, min_lon, max_lat, max_lon
+        FROM region
+        WHERE boundary IS NOT NULL
+    `
+	return s.queryRegionBoundaries(ctx, query)
+}
+
+func (s *Storage) queryRegionBoundaries(ctx context.Context, query string, args ...interface{}) ([]models.RegionBoundary, error) {
+	var boundaries []models.RegionBoundary
+
+	rows, err := s.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError("failed to get region boundaries", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b models.RegionBoundary
+		err := rows.Scan(&b.RegionId, &b.Kind, &b.GeoJSON, &b.MinLat, &b.MinLon, &b.MaxLat, &b.MaxLon)
+		if err != nil {
+			return nil, wrapError("failed to scan region boundary", err)
+		}
+		boundaries = append(boundaries, b)
+	}
+
+	return boundaries, rows.Err()
+}
+
+// GetPhotosWithCoords возвращает фото, у которых указаны координаты съемки
+func (s *Storage) GetPhotosWithCoords(ctx context.Context) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	query := `SELECT id, coords, region_id FROM photo WHERE coalesce(coords, '') <> ''`
+	rows, err := s.conn(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return nil, wrapError("failed to get photos with coords", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var photo models.Photo
+		err := rows.Scan(&photo.Id, &photo.Coords, &photo.RegionId)
+		if err != nil {
+			return nil, wrapError("failed to scan photo", err)
+		}
+		photos = append(photos, photo)
+	}
+
+	return photos, rows.Err()
+}
+
+// UpdatePhotoRegion переносит фото в регион regionId. Одобренное фото получает статус
+// moderationStatus, отклоненное и скрытое остаются такими. Место, которое относится
+// к другому региону, с фото снимается
+func (s *Storage) UpdatePhotoRegion(ctx context.Context, photoId uuid.UUID, regionId uuid.UUID, moderationStatus string) error {
+	query := `
+        UPDATE photo
+        SET region_id = $1,
+            moderation_status = CASE WHEN moderation_status = 'approved' THEN $3 ELSE moderation_status END,
+            place_id = CASE
+                WHEN EXISTS (SELECT 1 FROM place WHERE place.id = photo.place_id AND place.region_id = $1) THEN place_id
+            END
+        WHERE id = $2
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, regionId, photoId, moderationStatus)
+	if err != nil {
+		return wrapError("failed to update photo region", err)
+	}
+	return checkAffected(res, "failed to update photo region")
+}
@@ -0,0 +1,141 @@
+// Запросы в БД, связанные с жалобами пользователей
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+// SaveReport сохраняет жалобу. Если у пользователя уже есть открытая жалоба на объект,
+// в ней обновляются причина и пояснение; created сообщает, создана ли новая жалоба.
+// Жалоба с пустым ReporterId подана системой
+func (s *Storage) SaveReport(ctx context.Context, report models.Report) (saved models.Report, created bool, err error) {
+	query := `
+        INSERT INTO report (id, target_type, target_id, reporter_id, reason, details, content)
+        VALUES ($1, $2, $3, $4, $5, nullif($6, ''), nullif($7, ''))
+        ON CONFLICT (target_type, target_id, reporter_id) WHERE status = 'open'
+        DO UPDATE SET reason = EXCLUDED.reason, details = EXCLUDED.details,
+                      content = coalesce(EXCLUDED.content, report.content), updated_at = now()
+        RETURNING id, status, created_at, updated_at, xmax = 0
+    `
+	row := s.conn(ctx).QueryRowContext(ctx, query, report.Id, report.TargetType, report.TargetId, nullUUID(report.ReporterId),
+		report.Reason, report.Details, report.Content)
+	err = row.Scan(&report.Id, &report.Status, &report.CreatedAt, &report.UpdatedAt, &created)
+	if isForeignKeyViolation(err) {
+		return report, false, fmt.Errorf("failed to save report: %w: reporter does not exist", storage.ErrNotFound)
+	}
+	if err != nil {
+		return report, false, wrapError("failed to save report", err)
+	}
+	return report, created, nil
+}
+
+// CountOpenReports возвращает число открытых жалоб на объект
+func (s *Storage) CountOpenReports(ctx context.Context, targetType string, targetId uuid.UUID) (int, error) {
+	query := `SELECT count(*) FROM report WHERE target_type = $1 AND target_id = $2 AND status = 'open'`
+	var count int
+	if err := s.conn(ctx).QueryRowContext(ctx, query, targetType, targetId).Scan(&count); err != nil {
+		return 0, wrapError("failed to count reports", err)
+	}
+	return count, nil
+}
+
+// GetReportSummaries возвращает объекты с открытыми жалобами: сначала те, на которые жалуются чаще.
+// Пустой targetType - объекты всех типов
+func (s *Storage) GetReportSummaries(ctx context.Context, targetType string, limit int) ([]models.ReportSummary, error) {
+	var summaries []models.ReportSummary
+
+	query := `
+        SELECT target_type, target_id, count(*), string_agg(reason, ','), min(created_at), max(updated_at)
+        FROM report
+        WHERE status = 'open' AND ($1 = '' OR target_type = $1)
+        GROUP BY target_type, target_id
+        ORDER BY count(*) DESC, max(updated_at) DESC
+        LIMIT $2
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, targetType, limit)
+	if err != nil {
+		return nil, wrapError("failed to get report summaries", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var summary models.ReportSummary
+		var reasons string
+		err := rows.Scan(&summary.TargetType, &summary.TargetId, &summary.Reports, &reasons,
+			&summary.FirstReportedAt, &summary.LastReportedAt)
+		if err != nil {
+			return nil, wrapError("failed to scan report summary", err)
+		}
+		summary.Reasons = make(map[string]int)
+		for _, reason := range strings.Split(reasons, ",") {
+			summary.Reasons[reason]++
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, rows.Err()
+}
+
+// GetReports возвращает все жалобы на объект, начиная с последних
+func (s *Storage) GetReports(ctx context.Context, targetType string, targetId uuid.UUID) ([]models.Report, error) {
+	var reports []models.Report
+
+	query := `
+        SELECT id, target_type, target_id, reporter_id, reason, coalesce(details, ''), coalesce(content, ''), status,
+               coalesce(resolution_note, ''), resolved_by, resolved_at, created_at, updated_at
+        FROM report
+        WHERE target_type = $1 AND target_id = $2
+        ORDER BY created_at DESC
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, targetType, targetId)
+	if err != nil {
+		return nil, wrapError("failed to get reports", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var report models.Report
+		var reporterId uuid.NullUUID
+		var resolvedAt sql.NullTime
+		err := rows.Scan(&report.Id, &report.TargetType, &report.TargetId, &reporterId, &report.Reason,
+			&report.Details, &report.Content, &report.Status, &report.ResolutionNote, &report.ResolvedBy,
+			&resolvedAt, &report.CreatedAt, &report.UpdatedAt)
+		if err != nil {
+			return nil, wrapError("failed to scan report", err)
+		}
+		report.ReporterId = reporterId.UUID
+		if resolvedAt.Valid {
+			at := resolvedAt.Time
+			report.ResolvedAt = &at
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, rows.Err()
+}
+
+// CloseReports закрывает открытые жалобы на объект со статусом status и возвращает их число
+func (s *Storage) CloseReports(ctx context.Context, targetType string, targetId uuid.UUID, status string, resolution models.ReportResolution) (int, error) {
+	query := `
+        UPDATE report
+        SET status = $3, resolution_note = nullif($4, ''), resolved_by = $5, resolved_at = now()
+        WHERE target_type = $1 AND target_id = $2 AND status = 'open'
+    `
+	res, err := s.conn(ctx).ExecContext(ctx, query, targetType, targetId, status, resolution.Note, nullUUID(resolution.ModeratorId))
+	if err != nil {
+		return 0, wrapError("failed to close reports", err)
+	}
+	closed, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to close reports: %w", err)
+	}
+	return int(closed), nil
+}
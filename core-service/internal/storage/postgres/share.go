@@ -0,0 +1,163 @@
+// Запросы в БД, связанные с видимостью фото и ссылками для доступа
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+// photoVisibleTo возвращает условие, при котором фото с псевдонимом alias видно пользователю,
+// переданному параметром viewer (например, "$1"): автор фото не заблокирован и видимость
+// разрешает показ. Статус модерации условие не проверяет
+func photoVisibleTo(alias string, viewer string) string {
+	return notBlocked(alias+".user_id", viewer) + fmt.Sprintf(` AND (%[1]s.visibility = 'public' OR %[1]s.user_id = %[2]s
+            OR (%[1]s.visibility = 'followers' AND EXISTS (
+                SELECT 1 FROM user_follow vf WHERE vf.followee_id = %[1]s.user_id AND vf.follower_id = %[2]s))
+            OR (%[1]s.visibility = 'trip-members' AND EXISTS (
+                SELECT 1 FROM user_trip vt WHERE vt.trip_id = %[1]s.trip_id AND vt.user_id = %[2]s)))`, alias, viewer)
+}
+
+// IsPhotoVisibleTo сообщает, разрешает ли видимость фото показать его пользователю viewerId
+func (s *Storage) IsPhotoVisibleTo(ctx context.Context, photoId uuid.UUID, viewerId uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM photo WHERE id = $1 AND ` + photoVisibleTo("photo", "$2") + `)`
+	var visible bool
+	if err := s.conn(ctx).QueryRowContext(ctx, query, photoId, viewerId).Scan(&visible); err != nil {
+		return false, wrapError("failed to check photo visibility", err)
+	}
+	return visible, nil
+}
+
+// GetTripPhotoIds возвращает все фото поездки независимо от модерации и видимости
+func (s *Storage) GetTripPhotoIds(ctx context.Context, tripId uuid.UUID) ([]uuid.UUID, error) {
+	var photoIds []uuid.UUID
+
+	rows, err := s.conn(ctx).QueryContext(ctx, `SELECT id FROM photo WHERE trip_id = $1`, tripId)
+	if err != nil {
+		return nil, wrapError("failed to get trip photos", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var photoId uuid.UUID
+		if err := rows.Scan(&photoId); err != nil {
+			return nil, wrapError("failed to scan photo id", err)
+		}
+		photoIds = append(photoIds, photoId)
+	}
+
+	return photoIds, rows.Err()
+}
+
+// GetSharedTripPhotos возвращает одобренные фото поездки, открытые по ссылке пользователя sharerId:
+// публичные, для участников поездки и все фото самого sharerId
+func (s *Storage) GetSharedTripPhotos(ctx context.Context, tripId uuid.UUID, sharerId uuid.UUID) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	query := `
+        SELECT id, coalesce(coords, ''), coalesce(description, ''), img_url, coalesce(thumbnail_url, ''), coalesce(place, ''), place_id, region_id, trip_id, user_id, likes, watched_amount, comments, created_at, moderation_status, coalesce(moderation_reason, ''), visibility, taken_at
+        FROM photo
+        WHERE trip_id = $1 AND moderation_status = 'approved'
+          AND (visibility IN ('public', 'trip-members') OR user_id = $2)
+        ORDER BY created_at, id
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, tripId, sharerId)
+	if err != nil {
+		return nil, wrapError("failed to get shared trip photos", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var photo models.Photo
+		err := rows.Scan(&photo.Id, &photo.Coords, &photo.Description, &photo.ImgUrl, &photo.ThumbnailUrl, &photo.Place, &photo.PlaceId, &photo.RegionId, &photo.TripId, &photo.UserId, &photo.Likes, &photo.Views, &photo.Comments, &photo.CreatedAt, &photo.ModerationStatus, &photo.ModerationReason, &photo.Visibility, &photo.TakenAt)
+		if err != nil {
+			return nil, wrapError("failed to scan photo", err)
+		}
+		photos = append(photos, photo)
+	}
+
+	return photos, rows.Err()
+}
+
+// SaveShareLink сохраняет ссылку; в БД хранится только хеш токена
+func (s *Storage) SaveShareLink(ctx context.Context, link models.ShareLink, tokenHash []byte) (models.ShareLink, error) {
+	query := `
+        INSERT INTO share_link (id, token_hash, target_type, target_id, created_by, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING created_at
+    `
+	var expiresAt sql.NullTime
+	if link.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *link.ExpiresAt, Valid: true}
+	}
+	err := s.conn(ctx).QueryRowContext(ctx, query, link.Id, tokenHash, link.TargetType, link.TargetId, link.CreatedBy, expiresAt).Scan(&link.CreatedAt)
+	if err != nil {
+		return link, wrapError("failed to save share link", err)
+	}
+	return link, nil
+}
+
+// GetShareLinkByToken возвращает ссылку по хешу токена, в том числе истекшую
+func (s *Storage) GetShareLinkByToken(ctx context.Context, tokenHash []byte) (models.ShareLink, error) {
+	query := `
+        SELECT id, target_type, target_id, created_by, expires_at, created_at
+        FROM share_link
+        WHERE token_hash = $1
+    `
+	link, err := scanShareLink(s.conn(ctx).QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		return link, wrapError("failed to get share link", err)
+	}
+	return link, nil
+}
+
+// GetShareLinks возвращает ссылки, созданные пользователем, начиная с последних
+func (s *Storage) GetShareLinks(ctx context.Context, userId uuid.UUID) ([]models.ShareLink, error) {
+	var links []models.ShareLink
+
+	query := `
+        SELECT id, target_type, target_id, created_by, expires_at, created_at
+        FROM share_link
+        WHERE created_by = $1
+        ORDER BY created_at DESC
+    `
+	rows, err := s.conn(ctx).QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, wrapError("failed to get share links", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, wrapError("failed to scan share link", err)
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// DeleteShareLink отзывает ссылку. Отозвать можно только свою ссылку
+func (s *Storage) DeleteShareLink(ctx context.Context, linkId uuid.UUID, userId uuid.UUID) error {
+	res, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM share_link WHERE id = $1 AND created_by = $2`, linkId, userId)
+	if err != nil {
+		return wrapError("failed to delete share link", err)
+	}
+	return checkAffected(res, "failed to delete share link")
+}
+
+func scanShareLink(row rowScanner) (models.ShareLink, error) {
+	var link models.ShareLink
+	var expiresAt sql.NullTime
+	err := row.Scan(&link.Id, &link.TargetType, &link.TargetId, &link.CreatedBy, &expiresAt, &link.CreatedAt)
+	if expiresAt.Valid {
+		at := expiresAt.Time
+		link.ExpiresAt = &at
+	}
+	return link, err
+}
@@ -0,0 +1,88 @@
+// Запросы в БД для ленты популярных фото
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+// RefreshPhotoRanking пересчитывает рейтинг фото за окно window одной транзакцией:
+// каждый лайк и просмотр за окно дает likeWeight или viewWeight, затухающий с HalfLife
+func (s *Storage) RefreshPhotoRanking(ctx context.Context, window models.TrendingWindow, likeWeight float64, viewWeight float64) (int, error) {
+	query := `
+        INSERT INTO photo_ranking (window_name, photo_id, score, computed_at)
+        SELECT $1, e.photo_id, sum(e.weight * exp(-ln(2) * extract(epoch FROM now() - e.at) / $3)), now()
+        FROM (
+            SELECT photo_id, created_at AS at, $4::double precision AS weight
+            FROM photo_likes
+            WHERE created_at > now() - make_interval(secs => $2)
+            UNION ALL
+            SELECT photo_id, viewed_at, $5::double precision
+            FROM view
+            WHERE viewed_at > now() - make_interval(secs => $2)
+        ) e
+        GROUP BY e.photo_id
+    `
+	var ranked int64
+	err := s.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM photo_ranking WHERE window_name = $1`, window.Name); err != nil {
+			return wrapError("failed to clear photo ranking", err)
+		}
+		res, err := s.conn(ctx).ExecContext(ctx, query, window.Name, window.Period.Seconds(), window.HalfLife.Seconds(), likeWeight, viewWeight)
+		if err != nil {
+			return wrapError("failed to compute photo ranking", err)
+		}
+		ranked, _ = res.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(ranked), nil
+}
+
+// GetTrendingPhotos возвращает фото из рейтинга окна window по убыванию рейтинга,
+// начиная после cursor, если он задан
+func (s *Storage) GetTrendingPhotos(ctx context.Context, window string, filters models.PhotoFiltersDTO, cursor *models.FeedCursor, limit int) ([]models.TrendingPhoto, error) {
+	var photos []models.TrendingPhoto
+
+	args := []interface{}{filters.ViewerId, window}
+	query := `
+        SELECT p.id, coalesce(p.coords, ''), coalesce(p.description, ''), p.img_url, coalesce(p.thumbnail_url, ''), coalesce(p.place, ''), p.place_id, p.region_id, p.trip_id, p.user_id, p.likes, p.watched_amount, p.comments, p.created_at, p.moderation_status, coalesce(p.moderation_reason, ''), p.visibility, p.taken_at,
+               EXISTS (SELECT 1 FROM photo_likes pl WHERE pl.photo_id = p.id AND pl.user_id = $1),
+               r.score
+        FROM photo_ranking r
+        INNER JOIN photo p ON p.id = r.photo_id
+        WHERE r.window_name = $2`
+	query, args = appendPhotoFilters(query, args, "p", filters)
+	// Фото скрытых пользователем авторов в ленту не попадают
+	query += " AND " + notMuted("p.user_id", "$1")
+
+	if cursor != nil {
+		args = append(args, cursor.Score, cursor.PhotoId)
+		query += fmt.Sprintf(" AND (r.score, r.photo_id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY r.score DESC, r.photo_id DESC LIMIT $%d", len(args))
+
+	rows, err := s.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError("failed to get trending photos", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var photo models.TrendingPhoto
+		err := rows.Scan(&photo.Id, &photo.Coords, &photo.Description, &photo.ImgUrl, &photo.ThumbnailUrl, &photo.Place, &photo.PlaceId, &photo.RegionId, &photo.TripId, &photo.UserId, &photo.Likes, &photo.Views, &photo.Comments, &photo.CreatedAt, &photo.ModerationStatus, &photo.ModerationReason, &photo.Visibility, &photo.TakenAt, &photo.LikedByMe, &photo.Score)
+		if err != nil {
+			return nil, wrapError("failed to scan trending photo", err)
+		}
+		photos = append(photos, photo)
+	}
+
+	return photos, rows.Err()
+}
@@ -0,0 +1,87 @@
+// Выполнение нескольких запросов одной транзакцией
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/shameoff/more-than-trip/core/internal/storage"
+)
+
+const (
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+
+	// Сколько раз транзакция перезапускается после конфликта сериализации
+	maxTxAttempts = 3
+	txRetryDelay  = 20 * time.Millisecond
+)
+
+// querier - общие методы *sql.DB и *sql.Tx, через которые выполняются запросы хранилища
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// txKey - ключ, под которым открытая транзакция лежит в context
+type txKey struct{}
+
+// conn возвращает транзакцию из ctx, если запрос выполняется внутри WithTx, иначе пул соединений
+func (s *Storage) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return s.db
+}
+
+// WithTx выполняет fn в транзакции с уровнем изоляции SERIALIZABLE: все вызовы хранилища
+// с ctx, переданным в fn, работают в ней, при ошибке fn транзакция откатывается.
+// При конфликте сериализации или взаимной блокировке fn выполняется заново, поэтому она
+// не должна иметь побочных эффектов вне БД. Вложенный вызов WithTx использует уже открытую транзакцию
+func (s *Storage) WithTx(ctx context.Context, fn storage.TxFunc) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = s.runTx(ctx, fn)
+		if err == nil || !isRetryable(err) || attempt == maxTxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("transaction cancelled: %w", ctx.Err())
+		case <-time.After(time.Duration(attempt) * txRetryDelay):
+		}
+	}
+	return err
+}
+
+func (s *Storage) runTx(ctx context.Context, fn storage.TxFunc) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return wrapError("failed to commit transaction", err)
+	}
+	return nil
+}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == pgSerializationFailure || pgErr.Code == pgDeadlockDetected)
+}
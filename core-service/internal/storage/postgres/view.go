@@ -0,0 +1,55 @@
+// Запросы в БД, связанные с просмотрами фото
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/shameoff/more-than-trip/core/internal/domain/models"
+)
+
+// SaveViews сохраняет пачку просмотров одной транзакцией и увеличивает watched_amount
+// на число действительно новых просмотров. Повторы и просмотры удаленных фото пропускаются.
+// Возвращает количество сохраненных просмотров
+func (s *Storage) SaveViews(ctx context.Context, views []models.View) (int, error) {
+	query := `
+        INSERT INTO view (photo_id, user_id, fingerprint, window_start, viewed_at)
+        SELECT $1, $2, NULLIF($3, ''), $4, $5
+        WHERE EXISTS (SELECT 1 FROM photo WHERE id = $1)
+          AND ($2::uuid IS NULL OR EXISTS (SELECT 1 FROM user_account WHERE id = $2))
+        ON CONFLICT DO NOTHING
+    `
+	var saved int
+	err := s.WithTx(ctx, func(ctx context.Context) error {
+		// При повторе транзакции подсчет начинается заново
+		added := make(map[uuid.UUID]int)
+		saved = 0
+		for _, view := range views {
+			var windowStart interface{}
+			if !view.UserId.Valid {
+				windowStart = view.WindowStart
+			}
+			res, err := s.conn(ctx).ExecContext(ctx, query, view.PhotoId, view.UserId, view.Fingerprint, windowStart, view.ViewedAt)
+			if err != nil {
+				return wrapError("failed to save view", err)
+			}
+			if affected, err := res.RowsAffected(); err == nil && affected > 0 {
+				added[view.PhotoId]++
+				saved++
+			}
+		}
+
+		for photoId, count := range added {
+			_, err := s.conn(ctx).ExecContext(ctx, `UPDATE photo SET watched_amount = watched_amount + $1 WHERE id = $2`, count, photoId)
+			if err != nil {
+				return wrapError("failed to update photo views", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return saved, nil
+}
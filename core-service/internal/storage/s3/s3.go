@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"mime/multipart"
+	"path"
 	"path/filepath"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -51,3 +53,37 @@ func (s *S3Storage) UploadPhoto(ctx context.Context, file multipart.File, fileSi
 	fileURL := fmt.Sprintf("https://s3-minio.shameoff.ru/%s/%s", s.bucket, fileName)
 	return fileURL, nil
 }
+
+// PutObject загружает готовые данные на S3 и возвращает URL файла
+func (s *S3Storage) PutObject(ctx context.Context, data []byte, fileName string, contentType string) (string, error) {
+	_, err := s.s3Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(filepath.Base(fileName)),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file to S3: %w", err)
+	}
+
+	fileURL := fmt.Sprintf("https://s3-minio.shameoff.ru/%s/%s", s.bucket, fileName)
+	return fileURL, nil
+}
+
+// DownloadPhoto скачивает с S3 фото по URL, который вернул UploadPhoto
+func (s *S3Storage) DownloadPhoto(ctx context.Context, fileURL string) ([]byte, error) {
+	out, err := s.s3Client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(path.Base(fileURL)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file from S3: %w", err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file from S3: %w", err)
+	}
+	return data, nil
+}
@@ -1,9 +1,19 @@
 package storage
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
+// Доменные ошибки. Хранилище оборачивает в них ошибки драйвера,
+// сервисы - ошибки бизнес-правил, а HTTP-слой переводит их в коды ответа
 var (
-	ErrAccountExists    = errors.New("account with this id already exists")
-	ErrAccountNotFound  = errors.New("account not found")
-	ErrCurrencyNotFound = errors.New("currency not found")
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict with existing data")
+	ErrValidation = errors.New("validation failed")
+	ErrForbidden  = errors.New("forbidden")
 )
+
+// TxFunc - действия, которые хранилище выполняет в одной транзакции. Транзакция
+// передается через ctx: все вызовы хранилища с этим ctx выполняются в ней
+type TxFunc func(ctx context.Context) error
@@ -112,4 +112,17 @@ CREATE TABLE user_trip (
     PRIMARY KEY (user_id, trip_id)
     -- CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES user(id),
     -- CONSTRAINT fk_trip FOREIGN KEY (trip_id) REFERENCES trip(id)
-);
\ No newline at end of file
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS user_trip;
+DROP TABLE IF EXISTS challenge;
+DROP TABLE IF EXISTS view;
+DROP TABLE IF EXISTS photo_likes;
+DROP TABLE IF EXISTS photo_tag;
+DROP TABLE IF EXISTS photo;
+DROP TABLE IF EXISTS trip;
+DROP TABLE IF EXISTS place;
+DROP TABLE IF EXISTS tag;
+DROP TABLE IF EXISTS region;
+DROP TABLE IF EXISTS user_account;
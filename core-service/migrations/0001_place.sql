@@ -0,0 +1,45 @@
+-- Справочник мест: координаты, описание и ссылки из фото и поездок
+
+-- Дополнительные поля места
+ALTER TABLE place
+    ALTER COLUMN name TYPE VARCHAR(250),
+    ADD COLUMN description TEXT NULL,          -- Описание места
+    ADD COLUMN latitude DOUBLE PRECISION NULL, -- Широта
+    ADD COLUMN longitude DOUBLE PRECISION NULL; -- Долгота
+
+-- Одно и то же место не должно заводиться в регионе дважды
+CREATE UNIQUE INDEX place_region_name_idx ON place (region_id, lower(name));
+
+-- Ссылки на место из фото и поездок
+ALTER TABLE photo ADD COLUMN place_id UUID NULL; -- Ссылка на место съемки
+ALTER TABLE trip ADD COLUMN place_id UUID NULL;  -- Ссылка на место поездки
+
+-- Переносим строковые места из фото и поездок в справочник без дублей
+INSERT INTO place (name, region_id)
+SELECT DISTINCT ON (src.region_id, lower(src.name)) src.name, src.region_id
+FROM (
+    SELECT btrim(place) AS name, region_id FROM photo WHERE btrim(coalesce(place, '')) <> ''
+    UNION ALL
+    SELECT btrim(place) AS name, region_id FROM trip WHERE btrim(coalesce(place, '')) <> ''
+) src
+ORDER BY src.region_id, lower(src.name), src.name
+ON CONFLICT DO NOTHING;
+
+UPDATE photo ph
+SET place_id = p.id
+FROM place p
+WHERE p.region_id = ph.region_id AND lower(p.name) = lower(btrim(ph.place));
+
+UPDATE trip t
+SET place_id = p.id
+FROM place p
+WHERE p.region_id = t.region_id AND lower(p.name) = lower(btrim(t.place));
+
+-- +migrate Down
+ALTER TABLE trip DROP COLUMN place_id;
+ALTER TABLE photo DROP COLUMN place_id;
+DROP INDEX IF EXISTS place_region_name_idx;
+ALTER TABLE place
+    DROP COLUMN longitude,
+    DROP COLUMN latitude,
+    DROP COLUMN description;
@@ -0,0 +1,13 @@
+-- Иерархия регионов: страна → федеральный округ → регион
+
+ALTER TABLE region
+    ADD COLUMN kind VARCHAR(50) NOT NULL DEFAULT 'region', -- Уровень региона (country, federal_district, region)
+    ADD COLUMN parent_id UUID NULL;                        -- Ссылка на родительский регион
+
+CREATE INDEX region_parent_id_idx ON region (parent_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS region_parent_id_idx;
+ALTER TABLE region
+    DROP COLUMN parent_id,
+    DROP COLUMN kind;
@@ -0,0 +1,19 @@
+-- Границы регионов для автоматического определения региона фото по координатам
+
+ALTER TABLE region
+    ADD COLUMN boundary JSONB NULL,            -- Граница региона в формате GeoJSON
+    ADD COLUMN min_lat DOUBLE PRECISION NULL,  -- Ограничивающий прямоугольник границы
+    ADD COLUMN min_lon DOUBLE PRECISION NULL,
+    ADD COLUMN max_lat DOUBLE PRECISION NULL,
+    ADD COLUMN max_lon DOUBLE PRECISION NULL;
+
+CREATE INDEX region_bbox_idx ON region (min_lat, max_lat, min_lon, max_lon) WHERE boundary IS NOT NULL;
+
+-- +migrate Down
+DROP INDEX IF EXISTS region_bbox_idx;
+ALTER TABLE region
+    DROP COLUMN max_lon,
+    DROP COLUMN max_lat,
+    DROP COLUMN min_lon,
+    DROP COLUMN min_lat,
+    DROP COLUMN boundary;
@@ -0,0 +1,6 @@
+-- Ключи мест для массового импорта справочников (upsert по object_key)
+
+ALTER TABLE place ADD COLUMN object_key VARCHAR(250) UNIQUE NULL; -- Ключ места (короткое уникальное имя)
+
+-- +migrate Down
+ALTER TABLE place DROP COLUMN object_key;
@@ -0,0 +1,93 @@
+-- Внешние ключи между таблицами и правила каскадного удаления.
+-- Перед применением проверьте висячие ссылки: ./main -config dev.yml migrate check
+
+-- photo_tag.tag_id хранил строку, приводим к UUID тега. Значения, которые не являются
+-- UUID, считаются ключом тега (object_key); не найденные связи удаляются
+ALTER TABLE photo_tag ADD COLUMN tag_uuid UUID NULL;
+
+UPDATE photo_tag pt
+SET tag_uuid = t.id
+FROM tag t
+WHERE t.id::text = pt.tag_id OR t.object_key = pt.tag_id;
+
+DELETE FROM photo_tag WHERE tag_uuid IS NULL;
+
+ALTER TABLE photo_tag DROP CONSTRAINT photo_tag_pkey;
+ALTER TABLE photo_tag DROP COLUMN tag_id;
+ALTER TABLE photo_tag RENAME COLUMN tag_uuid TO tag_id;
+ALTER TABLE photo_tag ALTER COLUMN tag_id SET NOT NULL;
+ALTER TABLE photo_tag ADD PRIMARY KEY (photo_id, tag_id);
+
+-- Пустая поездка раньше сохранялась нулевым UUID вместо NULL
+UPDATE photo SET trip_id = NULL WHERE trip_id = '00000000-0000-0000-0000-000000000000';
+
+-- Справочники: нельзя удалить регион, пока на него ссылаются
+ALTER TABLE region
+    ADD CONSTRAINT fk_region_parent FOREIGN KEY (parent_id) REFERENCES region(id) ON DELETE RESTRICT;
+ALTER TABLE place
+    ADD CONSTRAINT fk_place_region FOREIGN KEY (region_id) REFERENCES region(id) ON DELETE RESTRICT;
+
+-- Поездки
+ALTER TABLE trip
+    ADD CONSTRAINT fk_trip_region FOREIGN KEY (region_id) REFERENCES region(id) ON DELETE RESTRICT,
+    ADD CONSTRAINT fk_trip_place FOREIGN KEY (place_id) REFERENCES place(id) ON DELETE SET NULL;
+
+-- Фото удаляются вместе с автором, а поездка или место при удалении просто отвязываются
+ALTER TABLE photo
+    ADD CONSTRAINT fk_photo_user FOREIGN KEY (user_id) REFERENCES user_account(id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_photo_region FOREIGN KEY (region_id) REFERENCES region(id) ON DELETE RESTRICT,
+    ADD CONSTRAINT fk_photo_trip FOREIGN KEY (trip_id) REFERENCES trip(id) ON DELETE SET NULL,
+    ADD CONSTRAINT fk_photo_place FOREIGN KEY (place_id) REFERENCES place(id) ON DELETE SET NULL;
+
+-- Связи фото удаляются вместе с фото
+ALTER TABLE photo_tag
+    ADD CONSTRAINT fk_photo_tag_photo FOREIGN KEY (photo_id) REFERENCES photo(id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_photo_tag_tag FOREIGN KEY (tag_id) REFERENCES tag(id) ON DELETE CASCADE;
+ALTER TABLE photo_likes
+    ADD CONSTRAINT fk_photo_likes_photo FOREIGN KEY (photo_id) REFERENCES photo(id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_photo_likes_user FOREIGN KEY (user_id) REFERENCES user_account(id) ON DELETE CASCADE;
+ALTER TABLE view
+    ADD CONSTRAINT fk_view_photo FOREIGN KEY (photo_id) REFERENCES photo(id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_view_user FOREIGN KEY (user_id) REFERENCES user_account(id) ON DELETE CASCADE;
+
+-- Вызовы и участие в поездках
+ALTER TABLE challenge
+    ADD CONSTRAINT fk_challenge_user FOREIGN KEY (user_id) REFERENCES user_account(id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_challenge_trip FOREIGN KEY (trip_id) REFERENCES trip(id) ON DELETE CASCADE;
+ALTER TABLE user_trip
+    ADD CONSTRAINT fk_user_trip_user FOREIGN KEY (user_id) REFERENCES user_account(id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_user_trip_trip FOREIGN KEY (trip_id) REFERENCES trip(id) ON DELETE CASCADE;
+
+-- Индексы для проверок внешних ключей и каскадного удаления
+CREATE INDEX photo_user_id_idx ON photo (user_id);
+CREATE INDEX photo_region_id_idx ON photo (region_id);
+CREATE INDEX photo_trip_id_idx ON photo (trip_id);
+CREATE INDEX photo_place_id_idx ON photo (place_id);
+CREATE INDEX trip_region_id_idx ON trip (region_id);
+CREATE INDEX photo_tag_tag_id_idx ON photo_tag (tag_id);
+CREATE INDEX photo_likes_user_id_idx ON photo_likes (user_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS photo_likes_user_id_idx;
+DROP INDEX IF EXISTS photo_tag_tag_id_idx;
+DROP INDEX IF EXISTS trip_region_id_idx;
+DROP INDEX IF EXISTS photo_place_id_idx;
+DROP INDEX IF EXISTS photo_trip_id_idx;
+DROP INDEX IF EXISTS photo_region_id_idx;
+DROP INDEX IF EXISTS photo_user_id_idx;
+
+ALTER TABLE user_trip DROP CONSTRAINT fk_user_trip_trip, DROP CONSTRAINT fk_user_trip_user;
+ALTER TABLE challenge DROP CONSTRAINT fk_challenge_trip, DROP CONSTRAINT fk_challenge_user;
+ALTER TABLE view DROP CONSTRAINT fk_view_user, DROP CONSTRAINT fk_view_photo;
+ALTER TABLE photo_likes DROP CONSTRAINT fk_photo_likes_user, DROP CONSTRAINT fk_photo_likes_photo;
+ALTER TABLE photo_tag DROP CONSTRAINT fk_photo_tag_tag, DROP CONSTRAINT fk_photo_tag_photo;
+ALTER TABLE photo
+    DROP CONSTRAINT fk_photo_place,
+    DROP CONSTRAINT fk_photo_trip,
+    DROP CONSTRAINT fk_photo_region,
+    DROP CONSTRAINT fk_photo_user;
+ALTER TABLE trip DROP CONSTRAINT fk_trip_place, DROP CONSTRAINT fk_trip_region;
+ALTER TABLE place DROP CONSTRAINT fk_place_region;
+ALTER TABLE region DROP CONSTRAINT fk_region_parent;
+
+ALTER TABLE photo_tag ALTER COLUMN tag_id TYPE VARCHAR(50) USING tag_id::text;
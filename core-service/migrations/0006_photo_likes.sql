@@ -0,0 +1,81 @@
+-- Лайки хранятся в photo_likes, счетчики photo.likes и user_account.likes
+-- поддерживает триггер, поэтому они остаются верными и при каскадном удалении
+
+ALTER TABLE photo_likes ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now(); -- Время лайка
+
+CREATE INDEX photo_likes_photo_created_idx ON photo_likes (photo_id, created_at DESC);
+
+-- Пересчитываем счетчики по уже сохраненным лайкам
+UPDATE photo p
+SET likes = (SELECT count(*) FROM photo_likes pl WHERE pl.photo_id = p.id);
+
+UPDATE user_account u
+SET likes = (
+    SELECT count(*)
+    FROM photo_likes pl
+    INNER JOIN photo p ON p.id = pl.photo_id
+    WHERE p.user_id = u.id
+);
+
+ALTER TABLE photo ALTER COLUMN likes SET NOT NULL;
+ALTER TABLE user_account ALTER COLUMN likes SET NOT NULL;
+
+CREATE FUNCTION photo_likes_count() RETURNS trigger AS $$
+DECLARE
+    delta INTEGER;
+    like_photo_id UUID;
+    author_id UUID;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        delta := 1;
+        like_photo_id := NEW.photo_id;
+    ELSE
+        delta := -1;
+        like_photo_id := OLD.photo_id;
+    END IF;
+
+    UPDATE photo SET likes = likes + delta WHERE id = like_photo_id RETURNING user_id INTO author_id;
+    IF author_id IS NOT NULL THEN
+        UPDATE user_account SET likes = likes + delta WHERE id = author_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER photo_likes_count
+    AFTER INSERT OR DELETE ON photo_likes
+    FOR EACH ROW EXECUTE FUNCTION photo_likes_count();
+
+-- Лайки удаленного фото или фото, переданного другому автору, переносятся в счетчик автора
+CREATE FUNCTION photo_author_likes() RETURNS trigger AS $$
+BEGIN
+    IF OLD.likes <> 0 THEN
+        UPDATE user_account SET likes = likes - OLD.likes WHERE id = OLD.user_id;
+    END IF;
+    IF TG_OP = 'UPDATE' AND NEW.likes <> 0 THEN
+        UPDATE user_account SET likes = likes + NEW.likes WHERE id = NEW.user_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER photo_author_likes_delete
+    AFTER DELETE ON photo
+    FOR EACH ROW EXECUTE FUNCTION photo_author_likes();
+
+CREATE TRIGGER photo_author_likes_update
+    AFTER UPDATE OF user_id ON photo
+    FOR EACH ROW
+    WHEN (OLD.user_id IS DISTINCT FROM NEW.user_id)
+    EXECUTE FUNCTION photo_author_likes();
+
+-- +migrate Down
+DROP TRIGGER IF EXISTS photo_author_likes_update ON photo;
+DROP TRIGGER IF EXISTS photo_author_likes_delete ON photo;
+DROP FUNCTION IF EXISTS photo_author_likes();
+DROP TRIGGER IF EXISTS photo_likes_count ON photo_likes;
+DROP FUNCTION IF EXISTS photo_likes_count();
+ALTER TABLE user_account ALTER COLUMN likes DROP NOT NULL;
+ALTER TABLE photo ALTER COLUMN likes DROP NOT NULL;
+DROP INDEX IF EXISTS photo_likes_photo_created_idx;
+ALTER TABLE photo_likes DROP COLUMN created_at;
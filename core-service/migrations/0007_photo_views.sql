@@ -0,0 +1,32 @@
+-- Просмотры фото: авторизованный пользователь учитывается один раз,
+-- анонимный - один раз за окно времени по хешу отпечатка клиента
+
+ALTER TABLE view DROP CONSTRAINT view_pkey;
+ALTER TABLE view
+    ALTER COLUMN user_id DROP NOT NULL,
+    ADD COLUMN fingerprint VARCHAR(64) NULL,                  -- sha256 отпечатка анонимного клиента
+    ADD COLUMN window_start TIMESTAMPTZ NULL,                 -- Начало окна дедупликации анонимного просмотра
+    ADD COLUMN viewed_at TIMESTAMPTZ NOT NULL DEFAULT now(),  -- Время просмотра
+    ADD CONSTRAINT view_viewer_check CHECK (user_id IS NOT NULL OR (fingerprint IS NOT NULL AND window_start IS NOT NULL));
+
+CREATE UNIQUE INDEX view_photo_user_idx ON view (photo_id, user_id) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX view_photo_fingerprint_idx ON view (photo_id, fingerprint, window_start) WHERE user_id IS NULL;
+
+-- Пересчитываем счетчик просмотров по уже сохраненным просмотрам
+UPDATE photo p
+SET watched_amount = (SELECT count(*) FROM view v WHERE v.photo_id = p.id);
+
+ALTER TABLE photo ALTER COLUMN watched_amount SET NOT NULL;
+
+-- +migrate Down
+ALTER TABLE photo ALTER COLUMN watched_amount DROP NOT NULL;
+DROP INDEX IF EXISTS view_photo_fingerprint_idx;
+DROP INDEX IF EXISTS view_photo_user_idx;
+DELETE FROM view WHERE user_id IS NULL;
+ALTER TABLE view
+    DROP CONSTRAINT view_viewer_check,
+    DROP COLUMN viewed_at,
+    DROP COLUMN window_start,
+    DROP COLUMN fingerprint,
+    ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE view ADD PRIMARY KEY (photo_id, user_id);
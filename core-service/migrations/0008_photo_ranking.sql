@@ -0,0 +1,21 @@
+-- Рейтинг фото для ленты популярного. Пересчитывается фоновым воркером
+-- отдельно для каждого окна (24h, 7d, 30d)
+CREATE TABLE photo_ranking (
+    window_name VARCHAR(10) NOT NULL,           -- Окно рейтинга
+    photo_id UUID NOT NULL,                     -- Ссылка на фото
+    score DOUBLE PRECISION NOT NULL,            -- Затухающая со временем сумма лайков и просмотров
+    computed_at TIMESTAMPTZ NOT NULL,           -- Время пересчета
+    PRIMARY KEY (window_name, photo_id),
+    CONSTRAINT fk_photo_ranking_photo FOREIGN KEY (photo_id) REFERENCES photo(id) ON DELETE CASCADE
+);
+
+CREATE INDEX photo_ranking_score_idx ON photo_ranking (window_name, score DESC, photo_id DESC);
+
+-- Индексы для выборки лайков и просмотров за окно
+CREATE INDEX photo_likes_created_at_idx ON photo_likes (created_at);
+CREATE INDEX view_viewed_at_idx ON view (viewed_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS view_viewed_at_idx;
+DROP INDEX IF EXISTS photo_likes_created_at_idx;
+DROP TABLE IF EXISTS photo_ranking;
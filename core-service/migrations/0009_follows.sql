@@ -0,0 +1,70 @@
+-- Подписки на пользователей и регионы для домашней ленты
+
+-- Время создания фото и поездок для сортировки ленты. Столбцы добавляются без DEFAULT,
+-- иначе все существующие записи получили бы время этой миграции и поднялись бы в начало лент.
+-- Настоящее время создания старых записей неизвестно. Фото получают время самого раннего
+-- лайка или просмотра: позже создания оно быть не может. Фото без таких событий и поездки
+-- без фото получают время самого раннего события в базе и оказываются в лентах после
+-- датированных записей. Поездка получает время самого раннего своего фото
+ALTER TABLE photo ADD COLUMN created_at TIMESTAMPTZ NULL;
+ALTER TABLE trip ADD COLUMN created_at TIMESTAMPTZ NULL;
+
+CREATE TEMPORARY TABLE photo_first_event ON COMMIT DROP AS
+SELECT photo_id, min(t) AS first_at
+FROM (
+    SELECT photo_id, created_at AS t FROM photo_likes
+    UNION ALL
+    SELECT photo_id, viewed_at FROM view
+) events
+GROUP BY photo_id;
+
+UPDATE photo p
+SET created_at = coalesce(
+    (SELECT e.first_at FROM photo_first_event e WHERE e.photo_id = p.id),
+    (SELECT min(first_at) FROM photo_first_event),
+    now()
+);
+
+UPDATE trip t
+SET created_at = coalesce(
+    (SELECT min(p.created_at) FROM photo p WHERE p.trip_id = t.id),
+    (SELECT min(first_at) FROM photo_first_event),
+    now()
+);
+
+ALTER TABLE photo ALTER COLUMN created_at SET DEFAULT now(), ALTER COLUMN created_at SET NOT NULL;
+ALTER TABLE trip ALTER COLUMN created_at SET DEFAULT now(), ALTER COLUMN created_at SET NOT NULL;
+
+CREATE TABLE user_follow (
+    follower_id UUID NOT NULL,                        -- Кто подписан
+    followee_id UUID NOT NULL,                        -- На кого подписан
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),    -- Время подписки
+    PRIMARY KEY (follower_id, followee_id),
+    CONSTRAINT user_follow_self_check CHECK (follower_id <> followee_id),
+    CONSTRAINT fk_user_follow_follower FOREIGN KEY (follower_id) REFERENCES user_account(id) ON DELETE CASCADE,
+    CONSTRAINT fk_user_follow_followee FOREIGN KEY (followee_id) REFERENCES user_account(id) ON DELETE CASCADE
+);
+CREATE INDEX user_follow_followee_idx ON user_follow (followee_id, created_at DESC);
+
+CREATE TABLE region_follow (
+    user_id UUID NOT NULL,                            -- Кто подписан
+    region_id UUID NOT NULL,                          -- На какой регион
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),    -- Время подписки
+    PRIMARY KEY (user_id, region_id),
+    CONSTRAINT fk_region_follow_user FOREIGN KEY (user_id) REFERENCES user_account(id) ON DELETE CASCADE,
+    CONSTRAINT fk_region_follow_region FOREIGN KEY (region_id) REFERENCES region(id) ON DELETE CASCADE
+);
+
+-- Лента читает последние фото и поездки каждой подписки по этим индексам
+CREATE INDEX photo_user_created_idx ON photo (user_id, created_at DESC, id DESC);
+CREATE INDEX photo_region_created_idx ON photo (region_id, created_at DESC, id DESC);
+CREATE INDEX trip_region_created_idx ON trip (region_id, created_at DESC, id DESC);
+
+-- +migrate Down
+DROP INDEX IF EXISTS trip_region_created_idx;
+DROP INDEX IF EXISTS photo_region_created_idx;
+DROP INDEX IF EXISTS photo_user_created_idx;
+DROP TABLE IF EXISTS region_follow;
+DROP TABLE IF EXISTS user_follow;
+ALTER TABLE trip DROP COLUMN created_at;
+ALTER TABLE photo DROP COLUMN created_at;
@@ -0,0 +1,93 @@
+-- Комментарии к фото и поездкам с одним уровнем ответов, упоминаниями,
+-- историей правок и мягким удалением
+
+CREATE TABLE comment (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),    -- Идентификатор комментария
+    photo_id UUID NULL,                               -- Фото, к которому оставлен комментарий
+    trip_id UUID NULL,                                -- Поездка, к которой оставлен комментарий
+    parent_id UUID NULL,                              -- Комментарий, на который это ответ
+    user_id UUID NOT NULL,                            -- Автор
+    body TEXT NOT NULL,                               -- Текст
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),    -- Время создания
+    updated_at TIMESTAMPTZ NULL,                      -- Время последней правки
+    deleted_at TIMESTAMPTZ NULL,                      -- Время мягкого удаления
+    CONSTRAINT comment_target_check CHECK ((photo_id IS NULL) <> (trip_id IS NULL)),
+    CONSTRAINT fk_comment_photo FOREIGN KEY (photo_id) REFERENCES photo(id) ON DELETE CASCADE,
+    CONSTRAINT fk_comment_trip FOREIGN KEY (trip_id) REFERENCES trip(id) ON DELETE CASCADE,
+    CONSTRAINT fk_comment_parent FOREIGN KEY (parent_id) REFERENCES comment(id) ON DELETE CASCADE,
+    CONSTRAINT fk_comment_user FOREIGN KEY (user_id) REFERENCES user_account(id) ON DELETE CASCADE
+);
+CREATE INDEX comment_photo_idx ON comment (photo_id, created_at) WHERE photo_id IS NOT NULL;
+CREATE INDEX comment_trip_idx ON comment (trip_id, created_at) WHERE trip_id IS NOT NULL;
+CREATE INDEX comment_parent_idx ON comment (parent_id) WHERE parent_id IS NOT NULL;
+
+-- Прежние версии текста комментария
+CREATE TABLE comment_revision (
+    id BIGSERIAL PRIMARY KEY,
+    comment_id UUID NOT NULL,                         -- Комментарий
+    body TEXT NOT NULL,                               -- Текст до правки
+    edited_at TIMESTAMPTZ NOT NULL DEFAULT now(),     -- Время правки
+    CONSTRAINT fk_comment_revision_comment FOREIGN KEY (comment_id) REFERENCES comment(id) ON DELETE CASCADE
+);
+CREATE INDEX comment_revision_comment_idx ON comment_revision (comment_id, edited_at);
+
+-- Пользователи, упомянутые в комментарии через @username
+CREATE TABLE comment_mention (
+    comment_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    PRIMARY KEY (comment_id, user_id),
+    CONSTRAINT fk_comment_mention_comment FOREIGN KEY (comment_id) REFERENCES comment(id) ON DELETE CASCADE,
+    CONSTRAINT fk_comment_mention_user FOREIGN KEY (user_id) REFERENCES user_account(id) ON DELETE CASCADE
+);
+
+-- Счетчики неудаленных комментариев поддерживает триггер
+ALTER TABLE photo ADD COLUMN comments INTEGER NOT NULL DEFAULT 0; -- Количество комментариев
+ALTER TABLE trip ADD COLUMN comments INTEGER NOT NULL DEFAULT 0;  -- Количество комментариев
+
+CREATE FUNCTION comment_count() RETURNS trigger AS $$
+DECLARE
+    delta INTEGER := 0;
+    target comment;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        target := NEW;
+        IF NEW.deleted_at IS NULL THEN
+            delta := 1;
+        END IF;
+    ELSIF TG_OP = 'DELETE' THEN
+        target := OLD;
+        IF OLD.deleted_at IS NULL THEN
+            delta := -1;
+        END IF;
+    ELSE
+        target := NEW;
+        IF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+            delta := -1;
+        ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+            delta := 1;
+        END IF;
+    END IF;
+
+    IF delta <> 0 THEN
+        IF target.photo_id IS NOT NULL THEN
+            UPDATE photo SET comments = comments + delta WHERE id = target.photo_id;
+        ELSE
+            UPDATE trip SET comments = comments + delta WHERE id = target.trip_id;
+        END IF;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER comment_count
+    AFTER INSERT OR DELETE OR UPDATE OF deleted_at ON comment
+    FOR EACH ROW EXECUTE FUNCTION comment_count();
+
+-- +migrate Down
+DROP TRIGGER IF EXISTS comment_count ON comment;
+DROP FUNCTION IF EXISTS comment_count();
+ALTER TABLE trip DROP COLUMN comments;
+ALTER TABLE photo DROP COLUMN comments;
+DROP TABLE IF EXISTS comment_mention;
+DROP TABLE IF EXISTS comment_revision;
+DROP TABLE IF EXISTS comment;
@@ -0,0 +1,41 @@
+-- Уведомления о лайках, комментариях, упоминаниях и подписках.
+-- Однотипные непрочитанные события об одном объекте складываются в одну запись
+
+CREATE TABLE notification (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),    -- Идентификатор уведомления
+    user_id UUID NOT NULL,                            -- Получатель
+    type VARCHAR(32) NOT NULL,                        -- Тип события (photo_like, photo_comment, ...)
+    subject_id UUID NOT NULL,                         -- Объект события: фото, поездка, комментарий или пользователь
+    last_actor_id UUID NOT NULL,                      -- Последний пользователь, вызвавший событие
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),    -- Время первого события
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),    -- Время последнего события
+    read_at TIMESTAMPTZ NULL,                         -- Время прочтения
+    CONSTRAINT fk_notification_user FOREIGN KEY (user_id) REFERENCES user_account(id) ON DELETE CASCADE,
+    CONSTRAINT fk_notification_last_actor FOREIGN KEY (last_actor_id) REFERENCES user_account(id) ON DELETE CASCADE
+);
+-- Непрочитанное уведомление одного типа об одном объекте может быть только одно
+CREATE UNIQUE INDEX notification_unread_uniq ON notification (user_id, type, subject_id) WHERE read_at IS NULL;
+CREATE INDEX notification_user_idx ON notification (user_id, updated_at DESC);
+
+-- Пользователи, вызвавшие событие; по ним считается "5 человек лайкнули ваше фото"
+CREATE TABLE notification_actor (
+    notification_id UUID NOT NULL,                    -- Уведомление
+    actor_id UUID NOT NULL,                           -- Пользователь
+    PRIMARY KEY (notification_id, actor_id),
+    CONSTRAINT fk_notification_actor_notification FOREIGN KEY (notification_id) REFERENCES notification(id) ON DELETE CASCADE,
+    CONSTRAINT fk_notification_actor_user FOREIGN KEY (actor_id) REFERENCES user_account(id) ON DELETE CASCADE
+);
+
+-- Настройки уведомлений. Отсутствие строки означает, что тип включен
+CREATE TABLE notification_preference (
+    user_id UUID NOT NULL,                            -- Пользователь
+    type VARCHAR(32) NOT NULL,                        -- Тип события
+    enabled BOOLEAN NOT NULL,                         -- Присылать ли уведомления этого типа
+    PRIMARY KEY (user_id, type),
+    CONSTRAINT fk_notification_preference_user FOREIGN KEY (user_id) REFERENCES user_account(id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS notification_preference;
+DROP TABLE IF EXISTS notification_actor;
+DROP TABLE IF EXISTS notification;
@@ -0,0 +1,59 @@
+-- События для потока обновлений (/api/stream). Триггеры отправляют их через
+-- NOTIFY в канал stream_events, и каждая реплика сервиса получает все события,
+-- независимо от того, какая реплика изменила данные. Ключи JSON совпадают с полями models.StreamEvent
+
+CREATE FUNCTION stream_photo_event() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM pg_notify('stream_events', json_build_object(
+            'Type', 'photo.created',
+            'PhotoId', NEW.id,
+            'RegionId', NEW.region_id,
+            'TripId', NEW.trip_id,
+            'UserId', NEW.user_id
+        )::text);
+    ELSIF NEW.likes IS DISTINCT FROM OLD.likes THEN
+        PERFORM pg_notify('stream_events', json_build_object(
+            'Type', 'photo.likes',
+            'PhotoId', NEW.id,
+            'RegionId', NEW.region_id,
+            'TripId', NEW.trip_id,
+            'UserId', NEW.user_id,
+            'Likes', NEW.likes
+        )::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stream_photo_created
+    AFTER INSERT ON photo
+    FOR EACH ROW EXECUTE FUNCTION stream_photo_event();
+
+CREATE TRIGGER stream_photo_likes
+    AFTER UPDATE OF likes ON photo
+    FOR EACH ROW EXECUTE FUNCTION stream_photo_event();
+
+-- Новое или дополненное уведомление, а также отметка о прочтении
+CREATE FUNCTION stream_notification_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('stream_events', json_build_object(
+        'Type', 'notification',
+        'UserId', NEW.user_id,
+        'NotificationId', NEW.id,
+        'UnreadCount', (SELECT count(*) FROM notification WHERE user_id = NEW.user_id AND read_at IS NULL)
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stream_notification
+    AFTER INSERT OR UPDATE ON notification
+    FOR EACH ROW EXECUTE FUNCTION stream_notification_event();
+
+-- +migrate Down
+DROP TRIGGER IF EXISTS stream_notification ON notification;
+DROP FUNCTION IF EXISTS stream_notification_event();
+DROP TRIGGER IF EXISTS stream_photo_likes ON photo;
+DROP TRIGGER IF EXISTS stream_photo_created ON photo;
+DROP FUNCTION IF EXISTS stream_photo_event();
@@ -0,0 +1,58 @@
+-- Доменные события для партнеров. Событие пишется в outbox_event в той же транзакции,
+-- что и изменение, затем диспетчер раскладывает его по вебхукам и доставляет
+
+CREATE TABLE outbox_event (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),    -- Идентификатор события
+    type VARCHAR(64) NOT NULL,                        -- Тип события (photo.created, trip.updated, ...)
+    payload JSONB NOT NULL,                           -- Данные события
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),    -- Время события
+    dispatched_at TIMESTAMPTZ NULL                    -- Время раскладки по вебхукам
+);
+CREATE INDEX outbox_event_pending_idx ON outbox_event (created_at) WHERE dispatched_at IS NULL;
+
+-- Зарегистрированные получатели событий
+CREATE TABLE webhook (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),    -- Идентификатор вебхука
+    url TEXT NOT NULL,                                -- Адрес, на который отправляются события
+    secret TEXT NOT NULL,                             -- Ключ подписи HMAC-SHA256
+    event_types TEXT[] NOT NULL DEFAULT '{}',         -- Типы событий; пустой список - все типы
+    active BOOLEAN NOT NULL DEFAULT true,             -- Отправлять ли события
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()     -- Время регистрации
+);
+
+-- Доставка события одному вебхуку
+CREATE TABLE webhook_delivery (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),    -- Идентификатор доставки
+    webhook_id UUID NOT NULL,                         -- Вебхук
+    event_id UUID NOT NULL,                           -- Событие
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',    -- pending, delivered или failed
+    attempts INTEGER NOT NULL DEFAULT 0,              -- Число попыток
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(), -- Время следующей попытки
+    delivered_at TIMESTAMPTZ NULL,                    -- Время успешной доставки
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (webhook_id, event_id),
+    CONSTRAINT webhook_delivery_status_check CHECK (status IN ('pending', 'delivered', 'failed')),
+    CONSTRAINT fk_webhook_delivery_webhook FOREIGN KEY (webhook_id) REFERENCES webhook(id) ON DELETE CASCADE,
+    CONSTRAINT fk_webhook_delivery_event FOREIGN KEY (event_id) REFERENCES outbox_event(id) ON DELETE CASCADE
+);
+CREATE INDEX webhook_delivery_pending_idx ON webhook_delivery (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_delivery_webhook_idx ON webhook_delivery (webhook_id, created_at DESC);
+
+-- Журнал попыток доставки
+CREATE TABLE webhook_delivery_attempt (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id UUID NOT NULL,                        -- Доставка
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT now(),  -- Время попытки
+    status_code INTEGER NULL,                         -- HTTP-статус ответа, NULL если ответа нет
+    response TEXT NULL,                               -- Начало тела ответа
+    error TEXT NULL,                                  -- Ошибка, если попытка неудачна
+    duration_ms INTEGER NOT NULL,                     -- Длительность запроса
+    CONSTRAINT fk_webhook_delivery_attempt_delivery FOREIGN KEY (delivery_id) REFERENCES webhook_delivery(id) ON DELETE CASCADE
+);
+CREATE INDEX webhook_delivery_attempt_delivery_idx ON webhook_delivery_attempt (delivery_id, attempted_at);
+
+-- +migrate Down
+DROP TABLE IF EXISTS webhook_delivery_attempt;
+DROP TABLE IF EXISTS webhook_delivery;
+DROP TABLE IF EXISTS webhook;
+DROP TABLE IF EXISTS outbox_event;
@@ -0,0 +1,28 @@
+-- Очередь фоновых задач. Воркеры забирают задачи через SELECT ... FOR UPDATE SKIP LOCKED,
+-- поэтому несколько реплик могут разбирать одну очередь
+
+CREATE TABLE job (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),    -- Идентификатор задачи
+    kind VARCHAR(64) NOT NULL,                        -- Тип задачи, по нему выбирается обработчик
+    payload JSONB NOT NULL,                           -- Параметры задачи
+    status VARCHAR(16) NOT NULL DEFAULT 'queued',     -- queued, running, done или dead
+    attempts INTEGER NOT NULL DEFAULT 0,              -- Число начатых попыток
+    max_attempts INTEGER NOT NULL DEFAULT 5,          -- После стольких неудач задача уходит в dead
+    run_at TIMESTAMPTZ NOT NULL DEFAULT now(),        -- Не раньше этого времени задачу можно выполнять
+    locked_until TIMESTAMPTZ NULL,                    -- До этого времени выполняющуюся задачу не забирает другой воркер
+    last_error TEXT NULL,                             -- Ошибка последней попытки
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ NULL,                     -- Время успешного завершения или перехода в dead
+    CONSTRAINT job_status_check CHECK (status IN ('queued', 'running', 'done', 'dead'))
+);
+CREATE INDEX job_queued_idx ON job (run_at) WHERE status = 'queued';
+-- Задачи, воркер которых пропал, не продлив блокировку
+CREATE INDEX job_running_idx ON job (locked_until) WHERE status = 'running';
+CREATE INDEX job_dead_idx ON job (finished_at DESC) WHERE status = 'dead';
+
+-- Уменьшенная копия фото, которую строит фоновая задача
+ALTER TABLE photo ADD COLUMN thumbnail_url TEXT NULL;
+
+-- +migrate Down
+ALTER TABLE photo DROP COLUMN thumbnail_url;
+DROP TABLE IF EXISTS job;
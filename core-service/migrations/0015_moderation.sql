@@ -0,0 +1,92 @@
+-- Модерация фото. Новые фото попадают в очередь модерации и видны всем только после одобрения;
+-- фото, загруженные до модерации, считаются одобренными
+
+ALTER TABLE photo
+    ADD COLUMN moderation_status VARCHAR(16) NOT NULL DEFAULT 'approved', -- pending, approved, rejected или hidden
+    ADD COLUMN moderation_reason TEXT NULL,                               -- Причина последнего решения модератора
+    ADD COLUMN moderated_by UUID NULL,                                    -- Модератор, принявший последнее решение
+    ADD COLUMN moderated_at TIMESTAMPTZ NULL,                             -- Время последнего решения
+    ADD CONSTRAINT photo_moderation_status_check CHECK (moderation_status IN ('pending', 'approved', 'rejected', 'hidden')),
+    ADD CONSTRAINT fk_photo_moderated_by FOREIGN KEY (moderated_by) REFERENCES user_account(id) ON DELETE SET NULL;
+ALTER TABLE photo ALTER COLUMN moderation_status SET DEFAULT 'pending';
+CREATE INDEX photo_moderation_queue_idx ON photo (created_at) WHERE moderation_status = 'pending';
+
+-- Модераторы
+ALTER TABLE user_account ADD COLUMN is_moderator BOOLEAN NOT NULL DEFAULT false;
+
+-- Доверенные пользователи региона: их фото в этом регионе и во вложенных одобряются без очереди
+CREATE TABLE region_trusted_user (
+    region_id UUID NOT NULL,                          -- Регион
+    user_id UUID NOT NULL,                            -- Доверенный пользователь
+    granted_by UUID NULL,                             -- Модератор, выдавший доверие
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (region_id, user_id),
+    CONSTRAINT fk_region_trusted_user_region FOREIGN KEY (region_id) REFERENCES region(id) ON DELETE CASCADE,
+    CONSTRAINT fk_region_trusted_user_user FOREIGN KEY (user_id) REFERENCES user_account(id) ON DELETE CASCADE,
+    CONSTRAINT fk_region_trusted_user_granted_by FOREIGN KEY (granted_by) REFERENCES user_account(id) ON DELETE SET NULL
+);
+CREATE INDEX region_trusted_user_user_idx ON region_trusted_user (user_id);
+
+-- Поток обновлений сообщает о новом фото, только когда оно стало видно всем:
+-- сразу при загрузке одобренного фото или при одобрении модератором
+CREATE OR REPLACE FUNCTION stream_photo_event() RETURNS trigger AS $$
+BEGIN
+    IF NEW.moderation_status = 'approved' AND (TG_OP = 'INSERT' OR OLD.moderation_status <> 'approved') THEN
+        PERFORM pg_notify('stream_events', json_build_object(
+            'Type', 'photo.created',
+            'PhotoId', NEW.id,
+            'RegionId', NEW.region_id,
+            'TripId', NEW.trip_id,
+            'UserId', NEW.user_id
+        )::text);
+    ELSIF TG_OP = 'UPDATE' AND NEW.moderation_status = 'approved' AND NEW.likes IS DISTINCT FROM OLD.likes THEN
+        PERFORM pg_notify('stream_events', json_build_object(
+            'Type', 'photo.likes',
+            'PhotoId', NEW.id,
+            'RegionId', NEW.region_id,
+            'TripId', NEW.trip_id,
+            'UserId', NEW.user_id,
+            'Likes', NEW.likes
+        )::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stream_photo_approved
+    AFTER UPDATE OF moderation_status ON photo
+    FOR EACH ROW EXECUTE FUNCTION stream_photo_event();
+
+-- +migrate Down
+DROP TRIGGER IF EXISTS stream_photo_approved ON photo;
+CREATE OR REPLACE FUNCTION stream_photo_event() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM pg_notify('stream_events', json_build_object(
+            'Type', 'photo.created',
+            'PhotoId', NEW.id,
+            'RegionId', NEW.region_id,
+            'TripId', NEW.trip_id,
+            'UserId', NEW.user_id
+        )::text);
+    ELSIF NEW.likes IS DISTINCT FROM OLD.likes THEN
+        PERFORM pg_notify('stream_events', json_build_object(
+            'Type', 'photo.likes',
+            'PhotoId', NEW.id,
+            'RegionId', NEW.region_id,
+            'TripId', NEW.trip_id,
+            'UserId', NEW.user_id,
+            'Likes', NEW.likes
+        )::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+DROP TABLE IF EXISTS region_trusted_user;
+ALTER TABLE user_account DROP COLUMN is_moderator;
+DROP INDEX IF EXISTS photo_moderation_queue_idx;
+ALTER TABLE photo
+    DROP COLUMN moderated_at,
+    DROP COLUMN moderated_by,
+    DROP COLUMN moderation_reason,
+    DROP COLUMN moderation_status;
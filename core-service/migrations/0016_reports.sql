@@ -0,0 +1,33 @@
+-- Жалобы пользователей на фото, комментарии, поездки и пользователей.
+-- Открытая жалоба одного пользователя на объект может быть только одна
+
+CREATE TABLE report (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),    -- Идентификатор жалобы
+    target_type VARCHAR(16) NOT NULL,                 -- photo, comment, trip или user
+    target_id UUID NOT NULL,                          -- Объект жалобы
+    reporter_id UUID NOT NULL,                        -- Пожаловавшийся пользователь
+    reason VARCHAR(32) NOT NULL,                      -- Категория причины (spam, harassment, ...)
+    details TEXT NULL,                                -- Пояснение пользователя
+    content TEXT NULL,                                -- Текст объекта на момент жалобы
+    status VARCHAR(16) NOT NULL DEFAULT 'open',       -- open, resolved или dismissed
+    resolution_note TEXT NULL,                        -- Комментарий модератора к решению
+    resolved_by UUID NULL,                            -- Модератор, закрывший жалобу
+    resolved_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),    -- Время последнего изменения жалобы пользователем
+    CONSTRAINT report_target_type_check CHECK (target_type IN ('photo', 'comment', 'trip', 'user')),
+    CONSTRAINT report_status_check CHECK (status IN ('open', 'resolved', 'dismissed')),
+    CONSTRAINT fk_report_reporter FOREIGN KEY (reporter_id) REFERENCES user_account(id) ON DELETE CASCADE,
+    CONSTRAINT fk_report_resolved_by FOREIGN KEY (resolved_by) REFERENCES user_account(id) ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX report_open_uniq ON report (target_type, target_id, reporter_id) WHERE status = 'open';
+CREATE INDEX report_target_idx ON report (target_type, target_id, created_at);
+
+-- Комментарии и поездки, скрытые по жалобам или модератором
+ALTER TABLE comment ADD COLUMN hidden_at TIMESTAMPTZ NULL;
+ALTER TABLE trip ADD COLUMN hidden_at TIMESTAMPTZ NULL;
+
+-- +migrate Down
+ALTER TABLE trip DROP COLUMN hidden_at;
+ALTER TABLE comment DROP COLUMN hidden_at;
+DROP TABLE IF EXISTS report;
@@ -0,0 +1,12 @@
+-- Жалобы, которые подает система, когда текст требует проверки модератором.
+-- У таких жалоб нет автора; открытая системная жалоба на объект может быть только одна
+
+ALTER TABLE report ALTER COLUMN reporter_id DROP NOT NULL;
+DROP INDEX report_open_uniq;
+CREATE UNIQUE INDEX report_open_uniq ON report (target_type, target_id, reporter_id) NULLS NOT DISTINCT WHERE status = 'open';
+
+-- +migrate Down
+DELETE FROM report WHERE reporter_id IS NULL;
+DROP INDEX report_open_uniq;
+CREATE UNIQUE INDEX report_open_uniq ON report (target_type, target_id, reporter_id) WHERE status = 'open';
+ALTER TABLE report ALTER COLUMN reporter_id SET NOT NULL;
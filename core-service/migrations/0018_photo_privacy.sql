@@ -0,0 +1,84 @@
+-- Видимость фото и ссылки для доступа к фото или поездке по токену
+
+ALTER TABLE photo
+    ADD COLUMN visibility VARCHAR(16) NOT NULL DEFAULT 'public', -- public, followers, trip-members или private
+    ADD CONSTRAINT photo_visibility_check CHECK (visibility IN ('public', 'followers', 'trip-members', 'private'));
+
+CREATE TABLE share_link (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),    -- Идентификатор ссылки
+    token_hash BYTEA NOT NULL,                        -- SHA-256 токена; сам токен отдается только при создании
+    target_type VARCHAR(16) NOT NULL,                 -- photo или trip
+    target_id UUID NOT NULL,                          -- Фото или поездка, к которой ссылка дает доступ
+    created_by UUID NOT NULL,                         -- Пользователь, создавший ссылку
+    expires_at TIMESTAMPTZ NULL,                      -- После этого времени ссылка не действует; NULL - бессрочная
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT share_link_target_type_check CHECK (target_type IN ('photo', 'trip')),
+    CONSTRAINT fk_share_link_created_by FOREIGN KEY (created_by) REFERENCES user_account(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX share_link_token_uniq ON share_link (token_hash);
+CREATE INDEX share_link_created_by_idx ON share_link (created_by, created_at DESC);
+CREATE INDEX share_link_target_idx ON share_link (target_type, target_id);
+
+-- Поток обновлений сообщает только о публичных фото: о новом фото - когда оно одобрено
+-- и стало публичным, о лайках - пока оно публично
+CREATE OR REPLACE FUNCTION stream_photo_event() RETURNS trigger AS $$
+BEGIN
+    IF NEW.moderation_status = 'approved' AND NEW.visibility = 'public'
+       AND (TG_OP = 'INSERT' OR OLD.moderation_status <> 'approved' OR OLD.visibility <> 'public') THEN
+        PERFORM pg_notify('stream_events', json_build_object(
+            'Type', 'photo.created',
+            'PhotoId', NEW.id,
+            'RegionId', NEW.region_id,
+            'TripId', NEW.trip_id,
+            'UserId', NEW.user_id
+        )::text);
+    ELSIF TG_OP = 'UPDATE' AND NEW.moderation_status = 'approved' AND NEW.visibility = 'public'
+          AND NEW.likes IS DISTINCT FROM OLD.likes THEN
+        PERFORM pg_notify('stream_events', json_build_object(
+            'Type', 'photo.likes',
+            'PhotoId', NEW.id,
+            'RegionId', NEW.region_id,
+            'TripId', NEW.trip_id,
+            'UserId', NEW.user_id,
+            'Likes', NEW.likes
+        )::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stream_photo_approved ON photo;
+CREATE TRIGGER stream_photo_approved
+    AFTER UPDATE OF moderation_status, visibility ON photo
+    FOR EACH ROW EXECUTE FUNCTION stream_photo_event();
+
+-- +migrate Down
+DROP TRIGGER IF EXISTS stream_photo_approved ON photo;
+CREATE OR REPLACE FUNCTION stream_photo_event() RETURNS trigger AS $$
+BEGIN
+    IF NEW.moderation_status = 'approved' AND (TG_OP = 'INSERT' OR OLD.moderation_status <> 'approved') THEN
+        PERFORM pg_notify('stream_events', json_build_object(
+            'Type', 'photo.created',
+            'PhotoId', NEW.id,
+            'RegionId', NEW.region_id,
+            'TripId', NEW.trip_id,
+            'UserId', NEW.user_id
+        )::text);
+    ELSIF TG_OP = 'UPDATE' AND NEW.moderation_status = 'approved' AND NEW.likes IS DISTINCT FROM OLD.likes THEN
+        PERFORM pg_notify('stream_events', json_build_object(
+            'Type', 'photo.likes',
+            'PhotoId', NEW.id,
+            'RegionId', NEW.region_id,
+            'TripId', NEW.trip_id,
+            'UserId', NEW.user_id,
+            'Likes', NEW.likes
+        )::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER stream_photo_approved
+    AFTER UPDATE OF moderation_status ON photo
+    FOR EACH ROW EXECUTE FUNCTION stream_photo_event();
+DROP TABLE IF EXISTS share_link;
+ALTER TABLE photo DROP COLUMN visibility;
@@ -0,0 +1,28 @@
+-- Блокировки и скрытие пользователей. Заблокированные пользователи не видят контент друг друга
+-- и не могут его лайкать и комментировать; фото скрытого пользователя не попадают в ленты
+
+CREATE TABLE user_block (
+    blocker_id UUID NOT NULL,                         -- Кто заблокировал
+    blocked_id UUID NOT NULL,                         -- Кого заблокировали
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (blocker_id, blocked_id),
+    CONSTRAINT user_block_self_check CHECK (blocker_id <> blocked_id),
+    CONSTRAINT fk_user_block_blocker FOREIGN KEY (blocker_id) REFERENCES user_account(id) ON DELETE CASCADE,
+    CONSTRAINT fk_user_block_blocked FOREIGN KEY (blocked_id) REFERENCES user_account(id) ON DELETE CASCADE
+);
+-- Блокировка проверяется в обе стороны
+CREATE INDEX user_block_blocked_idx ON user_block (blocked_id, blocker_id);
+
+CREATE TABLE user_mute (
+    user_id UUID NOT NULL,                            -- Кто скрыл
+    muted_id UUID NOT NULL,                           -- Чьи фото скрыты из лент
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, muted_id),
+    CONSTRAINT user_mute_self_check CHECK (user_id <> muted_id),
+    CONSTRAINT fk_user_mute_user FOREIGN KEY (user_id) REFERENCES user_account(id) ON DELETE CASCADE,
+    CONSTRAINT fk_user_mute_muted FOREIGN KEY (muted_id) REFERENCES user_account(id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS user_mute;
+DROP TABLE IF EXISTS user_block;
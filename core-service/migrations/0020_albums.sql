@@ -0,0 +1,33 @@
+-- Альбомы пользователей: упорядоченные подборки фото из разных поездок и от разных авторов
+
+CREATE TABLE album (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),    -- Идентификатор альбома
+    user_id UUID NOT NULL,                            -- Владелец альбома
+    title VARCHAR(250) NOT NULL,                      -- Название
+    description TEXT NULL,                            -- Описание
+    visibility VARCHAR(16) NOT NULL DEFAULT 'public', -- public, followers или private
+    cover_photo_id UUID NULL,                         -- Обложка; если не задана, берется первое фото
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT album_visibility_check CHECK (visibility IN ('public', 'followers', 'private')),
+    CONSTRAINT fk_album_user FOREIGN KEY (user_id) REFERENCES user_account(id) ON DELETE CASCADE,
+    CONSTRAINT fk_album_cover FOREIGN KEY (cover_photo_id) REFERENCES photo(id) ON DELETE SET NULL
+);
+CREATE INDEX album_user_idx ON album (user_id, created_at DESC);
+
+CREATE TABLE album_photo (
+    album_id UUID NOT NULL,                           -- Альбом
+    photo_id UUID NOT NULL,                           -- Фото в альбоме
+    position INTEGER NOT NULL,                        -- Порядок фото в альбоме, по возрастанию
+    added_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (album_id, photo_id),
+    -- Проверка откладывается до конца транзакции, чтобы фото могли обменяться позициями
+    CONSTRAINT album_photo_position_uniq UNIQUE (album_id, position) DEFERRABLE INITIALLY DEFERRED,
+    CONSTRAINT fk_album_photo_album FOREIGN KEY (album_id) REFERENCES album(id) ON DELETE CASCADE,
+    CONSTRAINT fk_album_photo_photo FOREIGN KEY (photo_id) REFERENCES photo(id) ON DELETE CASCADE
+);
+CREATE INDEX album_photo_photo_idx ON album_photo (photo_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS album_photo;
+DROP TABLE IF EXISTS album;
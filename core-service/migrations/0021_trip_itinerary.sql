@@ -0,0 +1,32 @@
+-- Даты и маршрут поездки, время съемки фото для хронологии поездки
+
+ALTER TABLE trip ADD COLUMN start_date DATE NULL;
+ALTER TABLE trip ADD COLUMN end_date DATE NULL;
+ALTER TABLE trip ADD CONSTRAINT trip_dates_check CHECK (end_date >= start_date);
+
+-- Время съемки фото; если не задано, в хронологии используется время загрузки
+ALTER TABLE photo ADD COLUMN taken_at TIMESTAMPTZ NULL;
+CREATE INDEX photo_trip_taken_idx ON photo (trip_id, (coalesce(taken_at, created_at)));
+
+CREATE TABLE trip_stop (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),    -- Идентификатор остановки, сохраняется при изменении маршрута
+    trip_id UUID NOT NULL,                            -- Поездка
+    position INTEGER NOT NULL,                        -- Порядок остановки в маршруте
+    place VARCHAR(250) NULL,                          -- Название места
+    place_id UUID NULL,                               -- Место из каталога
+    arrival_at TIMESTAMPTZ NULL,                      -- Время прибытия
+    departure_at TIMESTAMPTZ NULL,                    -- Время отъезда
+    notes TEXT NULL,                                  -- Заметки
+    CONSTRAINT trip_stop_position_uniq UNIQUE (trip_id, position),
+    CONSTRAINT trip_stop_times_check CHECK (departure_at >= arrival_at),
+    CONSTRAINT fk_trip_stop_trip FOREIGN KEY (trip_id) REFERENCES trip(id) ON DELETE CASCADE,
+    CONSTRAINT fk_trip_stop_place FOREIGN KEY (place_id) REFERENCES place(id) ON DELETE SET NULL
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS trip_stop;
+DROP INDEX IF EXISTS photo_trip_taken_idx;
+ALTER TABLE photo DROP COLUMN taken_at;
+ALTER TABLE trip DROP CONSTRAINT trip_dates_check;
+ALTER TABLE trip DROP COLUMN end_date;
+ALTER TABLE trip DROP COLUMN start_date;
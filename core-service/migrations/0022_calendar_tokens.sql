@@ -0,0 +1,12 @@
+-- Токены подписки на календарь поездок пользователя. Календарные клиенты не передают
+-- заголовки, поэтому доступ к ленте дает секретный токен в адресе
+
+CREATE TABLE calendar_token (
+    user_id UUID PRIMARY KEY,                         -- Владелец ленты; у пользователя один действующий токен
+    token_hash BYTEA NOT NULL UNIQUE,                 -- SHA-256 токена, сам токен не хранится
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT fk_calendar_token_user FOREIGN KEY (user_id) REFERENCES user_account(id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS calendar_token;
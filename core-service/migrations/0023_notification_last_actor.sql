@@ -0,0 +1,14 @@
+-- Удаление последнего пользователя, вызвавшего событие, не удаляет уведомление:
+-- в нем остаются другие пользователи и число событий
+
+ALTER TABLE notification
+    ALTER COLUMN last_actor_id DROP NOT NULL,
+    DROP CONSTRAINT fk_notification_last_actor,
+    ADD CONSTRAINT fk_notification_last_actor FOREIGN KEY (last_actor_id) REFERENCES user_account(id) ON DELETE SET NULL;
+
+-- +migrate Down
+DELETE FROM notification WHERE last_actor_id IS NULL;
+ALTER TABLE notification
+    DROP CONSTRAINT fk_notification_last_actor,
+    ADD CONSTRAINT fk_notification_last_actor FOREIGN KEY (last_actor_id) REFERENCES user_account(id) ON DELETE CASCADE,
+    ALTER COLUMN last_actor_id SET NOT NULL;
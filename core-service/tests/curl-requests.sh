@@ -3,10 +3,12 @@
 API_ENDPOINT="https://mtt.shameoff.ru"
 
 # Фото
-# Загрузка фото
+# Загрузка фото; автор - пользователь из заголовка User-Id. Время съемки TakenAt клиент
+# передает сам (например, из EXIF): сервер EXIF не читает
 curl -X POST "${API_ENDPOINT}/api/photo" \
+-H "User-Id: c56a4180-65aa-42ec-a945-5fd21dec0538" \
 -F "file=@/Users/e.shamov/Downloads/img.jpg" \
--F 'metadata={"Coords": "45.12345, 90.12345", "Description": "Sample photo", "Place": "New York", "RegionId": "f47ac10b-58cc-4372-a567-0e02b2c3d479", "TripId": "d9bfbced-fd19-4886-8e45-cb5e92b4d7d1", "UserId": "c56a4180-65aa-42ec-a945-5fd21dec0538"}'
+-F 'metadata={"Coords": "45.12345, 90.12345", "Description": "Sample photo", "Place": "New York", "RegionId": "f47ac10b-58cc-4372-a567-0e02b2c3d479", "TripId": "d9bfbced-fd19-4886-8e45-cb5e92b4d7d1", "TakenAt": "2024-02-11T12:30:00+03:00"}'
 
 # Получение фото по UUID
 curl -X GET "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000"
@@ -14,13 +16,15 @@ curl -X GET "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000"
 # Получение всех фото с фильтрами (по региону или тегу)
 curl -X GET "${API_ENDPOINT}/api/photos?region=550e8400-e29b-41d4-a716-446655440000&tag=nature"
 
-# Удаление фото по UUID
-curl -X DELETE "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000"
+# Удаление фото по UUID; удалить фото может только его автор из заголовка User-Id
+curl -X DELETE "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002"
 
-# Обновление фото по UUID
+# Обновление фото по UUID; менять фото может только его автор, остальные получают 403
 curl -X PUT "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002" \
 -H "Content-Type: application/json" \
--d '{"Coords": "51.5074,-0.1278", "Description": "Updated description", "Place": "London", "RegionId": "550e8400-e29b-41d4-a716-446655440000", "TripId": "550e8400-e29b-41d4-a716-446655440001", "UserId": "550e8400-e29b-41d4-a716-446655440002"}'
+-d '{"Coords": "51.5074,-0.1278", "Description": "Updated description", "Place": "London", "RegionId": "550e8400-e29b-41d4-a716-446655440000", "TripId": "550e8400-e29b-41d4-a716-446655440001"}'
 
 # Лайк фото
 curl -X POST "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000/like" \
@@ -30,11 +34,45 @@ curl -X POST "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000/lik
 curl -X POST "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000/dislike" \
 -H "User-Id: 550e8400-e29b-41d4-a716-446655440002"
 
+# Кто лайкнул фото
+curl -X GET "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000/likes"
+
+# Просмотр фото (без User-Id учитывается как анонимный)
+curl -X POST "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000/view" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002"
+
+# Лента популярного за неделю в регионе; следующая страница - параметр cursor из NextCursor
+curl -X GET "${API_ENDPOINT}/api/feed/trending?window=7d&region=550e8400-e29b-41d4-a716-446655440000&limit=20"
+
+# Домашняя лента пользователя: фото и поездки подписок
+curl -X GET "${API_ENDPOINT}/api/feed/home?limit=20" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002"
+
+# Подписка на пользователя и отписка
+curl -X POST "${API_ENDPOINT}/api/user/550e8400-e29b-41d4-a716-446655440003/follow" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002"
+curl -X DELETE "${API_ENDPOINT}/api/user/550e8400-e29b-41d4-a716-446655440003/follow" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002"
+
+# Подписчики и подписки пользователя
+curl -X GET "${API_ENDPOINT}/api/user/550e8400-e29b-41d4-a716-446655440002/followers"
+curl -X GET "${API_ENDPOINT}/api/user/550e8400-e29b-41d4-a716-446655440002/following"
+curl -X GET "${API_ENDPOINT}/api/user/550e8400-e29b-41d4-a716-446655440002/following/regions"
+
+# Подписка на регион
+curl -X POST "${API_ENDPOINT}/api/region/550e8400-e29b-41d4-a716-446655440000/follow" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002"
+
 # Регионы
 # Создание региона
 curl -X POST "${API_ENDPOINT}/api/region" \
 -H "Content-Type: application/json" \
--d '{"Id":"550e8400-e29b-41d4-a716-446655440000", "Name":"London", "Country":"UK", "ObjectKey":"london"}'
+-d '{"Id":"550e8400-e29b-41d4-a716-446655440000", "Name":"London", "Country":"UK", "ObjectKey":"london", "ImgUrl":"https://example.com/london.jpg", "Tag":"city", "Kind":"region"}'
+
+# Создание дочернего региона
+curl -X POST "${API_ENDPOINT}/api/region" \
+-H "Content-Type: application/json" \
+-d '{"Name":"Центральный федеральный округ", "Country":"Россия", "ObjectKey":"cfd", "Kind":"federal_district", "ParentId":"550e8400-e29b-41d4-a716-446655440003"}'
 
 # Удаление региона по UUID
 curl -X DELETE "${API_ENDPOINT}/api/region/550e8400-e29b-41d4-a716-446655440000"
@@ -42,6 +80,12 @@ curl -X DELETE "${API_ENDPOINT}/api/region/550e8400-e29b-41d4-a716-446655440000"
 # Получение региона по UUID
 curl -X GET "${API_ENDPOINT}/api/region/550e8400-e29b-41d4-a716-446655440000"
 
+# Получение региона по ключу
+curl -X GET "${API_ENDPOINT}/api/region/by-key/london"
+
+# Получение дочерних регионов
+curl -X GET "${API_ENDPOINT}/api/region/550e8400-e29b-41d4-a716-446655440003/children"
+
 # Получение всех регионов
 curl -X GET "${API_ENDPOINT}/api/regions"
 
@@ -50,6 +94,31 @@ curl -X PUT "${API_ENDPOINT}/api/region/550e8400-e29b-41d4-a716-446655440000" \
 -H "Content-Type: application/json" \
 -d '{"Name":"London Updated", "Country":"UK", "ObjectKey":"london-updated"}'
 
+# Загрузка границы региона (GeoJSON), доступна только модератору
+curl -X PUT "${API_ENDPOINT}/api/region/550e8400-e29b-41d4-a716-446655440000/boundary" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001" \
+-H "Content-Type: application/geo+json" \
+-d '{"type":"Polygon","coordinates":[[[-0.51,51.28],[0.33,51.28],[0.33,51.69],[-0.51,51.69],[-0.51,51.28]]]}'
+
+# Повторное определение регионов существующих фото по координатам, доступно только модератору
+curl -X POST "${API_ENDPOINT}/api/admin/photos/assign-regions" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Импорт справочника регионов из CSV: без apply=true возвращается только отчет (dry-run).
+# Импортировать справочники может только модератор из заголовка User-Id
+curl -X POST "${API_ENDPOINT}/api/admin/import?catalog=regions" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001" \
+-F "file=@regions.csv"
+
+# Применение импорта мест из GeoJSON
+curl -X POST "${API_ENDPOINT}/api/admin/import?catalog=places&format=geojson&apply=true" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001" \
+-H "Content-Type: application/geo+json" \
+--data-binary "@places.geojson"
+
+# Отчет о висячих ссылках между таблицами
+curl -X GET "${API_ENDPOINT}/api/admin/integrity"
+
 # Теги
 # Создание тега
 curl -X POST "${API_ENDPOINT}/api/tag" \
@@ -111,4 +180,301 @@ curl -X PUT "${API_ENDPOINT}/api/trip/550e8400-e29b-41d4-a716-446655440000"
 curl -X GET "${API_ENDPOINT}/api/trip/550e8400-e29b-41d4-a716-446655440000"
 
 # Получение всех поездок
-curl -X GET "${API_ENDPOINT}/api/trips"
\ No newline at end of file
+curl -X GET "${API_ENDPOINT}/api/trips"
+# Места
+# Создание места
+curl -X POST "${API_ENDPOINT}/api/place" \
+-H "Content-Type: application/json" \
+-d '{"Name":"Big Ben", "Description":"Clock tower", "RegionId":"550e8400-e29b-41d4-a716-446655440000", "Latitude":51.5007, "Longitude":-0.1246}'
+
+# Получение места по UUID
+curl -X GET "${API_ENDPOINT}/api/place/550e8400-e29b-41d4-a716-446655440000"
+
+# Получение мест региона
+curl -X GET "${API_ENDPOINT}/api/places?region=550e8400-e29b-41d4-a716-446655440000"
+
+# Обновление места
+curl -X PUT "${API_ENDPOINT}/api/place/550e8400-e29b-41d4-a716-446655440000" \
+-H "Content-Type: application/json" \
+-d '{"Name":"Big Ben", "Description":"Elizabeth Tower", "RegionId":"550e8400-e29b-41d4-a716-446655440000", "Latitude":51.5007, "Longitude":-0.1246}'
+
+# Удаление места
+curl -X DELETE "${API_ENDPOINT}/api/place/550e8400-e29b-41d4-a716-446655440000"
+
+# Комментарии
+# Комментарий к фото (упоминания через @username)
+curl -X POST "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000/comments" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001" \
+-H "Content-Type: application/json" \
+-d '{"Body":"Отличный вид, @ivan"}'
+
+# Ответ на комментарий
+curl -X POST "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000/comments" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002" \
+-H "Content-Type: application/json" \
+-d '{"Body":"Согласен", "ParentId":"550e8400-e29b-41d4-a716-446655440010"}'
+
+# Комментарии к фото с ответами
+curl -X GET "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000/comments"
+
+# Редактирование комментария
+curl -X PUT "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000/comments/550e8400-e29b-41d4-a716-446655440010" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001" \
+-H "Content-Type: application/json" \
+-d '{"Body":"Отличный вид!"}'
+
+# История правок комментария
+curl -X GET "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000/comments/550e8400-e29b-41d4-a716-446655440010/history"
+
+# Удаление комментария
+curl -X DELETE "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440000/comments/550e8400-e29b-41d4-a716-446655440010" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Комментарии к поездке
+curl -X GET "${API_ENDPOINT}/api/trip/550e8400-e29b-41d4-a716-446655440000/comments"
+
+# Уведомления
+# Уведомления пользователя с числом непрочитанных
+curl -X GET "${API_ENDPOINT}/api/notifications?unread=true&limit=20" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Отметить уведомление прочитанным
+curl -X POST "${API_ENDPOINT}/api/notifications/550e8400-e29b-41d4-a716-446655440020/read" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Отметить все уведомления прочитанными
+curl -X POST "${API_ENDPOINT}/api/notifications/read-all" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Настройки уведомлений
+curl -X GET "${API_ENDPOINT}/api/notifications/preferences" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Отключить уведомления о лайках
+curl -X PUT "${API_ENDPOINT}/api/notifications/preferences" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001" \
+-H "Content-Type: application/json" \
+-d '[{"Type":"photo_like", "Enabled":false}]'
+
+# Поток обновлений (Server-Sent Events): новые фото и лайки в регионе, уведомления пользователя
+curl -N "${API_ENDPOINT}/api/stream?region=550e8400-e29b-41d4-a716-446655440000&photo=550e8400-e29b-41d4-a716-446655440003" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Токен потока для EventSource и WebSocket, которые не умеют передавать заголовки; действует минуту
+curl -X POST "${API_ENDPOINT}/api/stream/token" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Тот же поток по токену из ответа выше
+curl -N "${API_ENDPOINT}/api/stream?token=TOKEN&trip=550e8400-e29b-41d4-a716-446655440000"
+
+# WebSocket-вариант потока: ws://.../api/stream/ws с теми же параметрами.
+# Со страниц других сайтов подключение разрешено только для stream.allowed_origins
+
+# Вебхуки партнеров; управляет ими только модератор из заголовка User-Id
+# Регистрация вебхука (пустой EventTypes - все события); секрет подписи вернется в ответе.
+# Адреса localhost, частных и link-local сетей отклоняются с 422
+curl -X POST "${API_ENDPOINT}/api/admin/webhooks" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001" \
+-H "Content-Type: application/json" \
+-d '{"Url":"https://partner.example.com/hooks/mtt", "EventTypes":["photo.created", "trip.updated"]}'
+
+# Список вебхуков
+curl -X GET "${API_ENDPOINT}/api/admin/webhooks" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Журнал доставок вебхука
+curl -X GET "${API_ENDPOINT}/api/admin/webhooks/550e8400-e29b-41d4-a716-446655440030/deliveries" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Попытки доставки с ответами получателя
+curl -X GET "${API_ENDPOINT}/api/admin/webhooks/deliveries/550e8400-e29b-41d4-a716-446655440031/attempts" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Удаление вебхука
+curl -X DELETE "${API_ENDPOINT}/api/admin/webhooks/550e8400-e29b-41d4-a716-446655440030" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Фоновые задачи (User-Id - модератор)
+# Задачи, исчерпавшие попытки (status: queued, running, done, dead)
+curl -X GET "${API_ENDPOINT}/api/admin/jobs?status=dead" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Повторный запуск упавшей задачи
+curl -X POST "${API_ENDPOINT}/api/admin/jobs/550e8400-e29b-41d4-a716-446655440040/retry" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Модерация фото (User-Id - модератор)
+# Выдача роли модератора; выдать ее может только модератор из заголовка User-Id.
+# Первого модератора назначают в БД: UPDATE user_account SET is_moderator = true WHERE id = ...
+curl -X PUT "${API_ENDPOINT}/api/admin/moderators/550e8400-e29b-41d4-a716-446655440002" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Не модератор получает 403, в том числе при попытке назначить модератором себя
+curl -X PUT "${API_ENDPOINT}/api/admin/moderators/550e8400-e29b-41d4-a716-446655440003" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440003"
+
+# Очередь модерации, самые старые фото первыми
+curl -X GET "${API_ENDPOINT}/api/moderation/queue?region=550e8400-e29b-41d4-a716-446655440000&limit=20" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Одобрение фото
+curl -X POST "${API_ENDPOINT}/api/moderation/photos/550e8400-e29b-41d4-a716-446655440003/approve" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Отклонение фото, причина обязательна
+curl -X POST "${API_ENDPOINT}/api/moderation/photos/550e8400-e29b-41d4-a716-446655440003/reject" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001" \
+-H "Content-Type: application/json" \
+-d '{"Reason":"На фото нет места из описания"}'
+
+# Скрытие одобренного фото
+curl -X POST "${API_ENDPOINT}/api/moderation/photos/550e8400-e29b-41d4-a716-446655440003/hide" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001" \
+-H "Content-Type: application/json" \
+-d '{"Reason":"Жалоба правообладателя"}'
+
+# Автоодобрение фото доверенного пользователя в регионе и во вложенных регионах
+curl -X PUT "${API_ENDPOINT}/api/moderation/regions/550e8400-e29b-41d4-a716-446655440000/trusted-users/550e8400-e29b-41d4-a716-446655440002" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+curl -X GET "${API_ENDPOINT}/api/moderation/regions/550e8400-e29b-41d4-a716-446655440000/trusted-users" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Жалобы
+# Жалоба на фото (TargetType: photo, comment, trip, user; Reason: spam, harassment, hate, nudity, violence, copyright, other)
+curl -X POST "${API_ENDPOINT}/api/report" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002" \
+-H "Content-Type: application/json" \
+-d '{"TargetType":"photo", "TargetId":"550e8400-e29b-41d4-a716-446655440003", "Reason":"spam", "Details":"Реклама в описании"}'
+
+# Панель модератора: объекты с открытыми жалобами
+curl -X GET "${API_ENDPOINT}/api/moderation/reports?type=photo&limit=20" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Все жалобы на объект
+curl -X GET "${API_ENDPOINT}/api/moderation/reports/photo/550e8400-e29b-41d4-a716-446655440003" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Решение по жалобам (Action: dismiss - вернуть объект, hide - скрыть, resolve - закрыть без изменений)
+curl -X POST "${API_ENDPOINT}/api/moderation/reports/photo/550e8400-e29b-41d4-a716-446655440003/resolve" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001" \
+-H "Content-Type: application/json" \
+-d '{"Action":"hide", "Note":"Реклама"}'
+
+# Проверка текста
+# Комментарий со ссылкой или телефоном скрывается и попадает в панель модератора как жалоба без автора,
+# нецензурные слова маскируются звездочками (действия настраиваются в секции text_policy)
+curl -X POST "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440003/comments" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002" \
+-H "Content-Type: application/json" \
+-d '{"Body":"Пишите в t.me/example или звоните +7 999 123-45-67"}'
+
+# Системные жалобы на комментарии
+curl -X GET "${API_ENDPOINT}/api/moderation/reports?type=comment&limit=20" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Видимость фото (Visibility: public, followers, trip-members, private)
+curl -X PUT "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440003" \
+-H "Content-Type: application/json" \
+-d '{"Description":"Только для попутчиков", "ImgUrl":"https://example.com/photo.jpg", "RegionId":"550e8400-e29b-41d4-a716-446655440000", "UserId":"550e8400-e29b-41d4-a716-446655440002", "Visibility":"trip-members"}'
+
+# Ссылка на фото; ExpiresAt необязателен. Токен и адрес возвращаются только при создании
+curl -X POST "${API_ENDPOINT}/api/photo/550e8400-e29b-41d4-a716-446655440003/share" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002" \
+-H "Content-Type: application/json" \
+-d '{"ExpiresAt":"2030-01-01T00:00:00Z"}'
+
+# Ссылка на поездку (может создать любой участник поездки)
+curl -X POST "${API_ENDPOINT}/api/trip/550e8400-e29b-41d4-a716-446655440004/share" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002"
+
+# Открыть фото или поездку по ссылке
+curl -X GET "${API_ENDPOINT}/api/share/TOKEN"
+
+# Мои ссылки и отзыв ссылки
+curl -X GET "${API_ENDPOINT}/api/share-links" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002"
+
+curl -X DELETE "${API_ENDPOINT}/api/share-links/550e8400-e29b-41d4-a716-446655440005" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002"
+
+# Блокировки: заблокированные пользователи не видят, не лайкают и не комментируют контент друг друга
+curl -X POST "${API_ENDPOINT}/api/user/550e8400-e29b-41d4-a716-446655440002/block" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+curl -X DELETE "${API_ENDPOINT}/api/user/550e8400-e29b-41d4-a716-446655440002/block" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+curl -X GET "${API_ENDPOINT}/api/blocks" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Скрытие: фото пользователя не попадают в домашнюю ленту и популярное
+curl -X POST "${API_ENDPOINT}/api/user/550e8400-e29b-41d4-a716-446655440002/mute" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+curl -X DELETE "${API_ENDPOINT}/api/user/550e8400-e29b-41d4-a716-446655440002/mute" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+curl -X GET "${API_ENDPOINT}/api/mutes" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Альбомы: подборки фото из разных поездок и от разных авторов
+curl -X POST "${API_ENDPOINT}/api/album" \
+-H "Content-Type: application/json" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001" \
+-d '{"Title": "Лучшее за лето", "Description": "Избранное", "Visibility": "followers"}'
+
+curl -X GET "${API_ENDPOINT}/api/albums?user=550e8400-e29b-41d4-a716-446655440001" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002"
+
+curl -X GET "${API_ENDPOINT}/api/album/550e8400-e29b-41d4-a716-446655440006" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002"
+
+curl -X PUT "${API_ENDPOINT}/api/album/550e8400-e29b-41d4-a716-446655440006" \
+-H "Content-Type: application/json" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001" \
+-d '{"Title": "Лучшее за лето", "Visibility": "public", "CoverPhotoId": "550e8400-e29b-41d4-a716-446655440003"}'
+
+curl -X DELETE "${API_ENDPOINT}/api/album/550e8400-e29b-41d4-a716-446655440006" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Фото альбома: добавление, удаление и порядок
+curl -X GET "${API_ENDPOINT}/api/album/550e8400-e29b-41d4-a716-446655440006/photos" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002"
+
+curl -X POST "${API_ENDPOINT}/api/album/550e8400-e29b-41d4-a716-446655440006/photos" \
+-H "Content-Type: application/json" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001" \
+-d '{"PhotoId": "550e8400-e29b-41d4-a716-446655440003"}'
+
+curl -X PUT "${API_ENDPOINT}/api/album/550e8400-e29b-41d4-a716-446655440006/photos/order" \
+-H "Content-Type: application/json" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001" \
+-d '{"PhotoIds": ["550e8400-e29b-41d4-a716-446655440004", "550e8400-e29b-41d4-a716-446655440003"]}'
+
+curl -X DELETE "${API_ENDPOINT}/api/album/550e8400-e29b-41d4-a716-446655440006/photos/550e8400-e29b-41d4-a716-446655440003" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+# Маршрут поездки: даты и остановки; менять его могут участники поездки
+curl -X PUT "${API_ENDPOINT}/api/trip/550e8400-e29b-41d4-a716-446655440000/itinerary" \
+-H "Content-Type: application/json" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001" \
+-d '{"StartDate": "2024-07-01", "EndDate": "2024-07-05", "Stops": [{"Place": "Казань", "ArrivalAt": "2024-07-01T10:00:00+03:00", "DepartureAt": "2024-07-03T09:00:00+03:00", "Notes": "Кремль и набережная"}, {"Place": "Свияжск", "ArrivalAt": "2024-07-03T11:00:00+03:00"}]}'
+
+curl -X GET "${API_ENDPOINT}/api/trip/550e8400-e29b-41d4-a716-446655440000/itinerary"
+
+# Хронология поездки: фото по дням и остановкам по времени съемки
+curl -X GET "${API_ENDPOINT}/api/trip/550e8400-e29b-41d4-a716-446655440000/timeline?tz=Europe/Moscow" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440002"
+
+# Календарь поездки в формате iCalendar
+curl -X GET "${API_ENDPOINT}/api/trip/550e8400-e29b-41d4-a716-446655440000/calendar.ics"
+
+# Лента календаря с поездками пользователя: получить адрес с токеном, подписаться, отключить
+curl -X POST "${API_ENDPOINT}/api/user/550e8400-e29b-41d4-a716-446655440001/calendar-token" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"
+
+curl -X GET "${API_ENDPOINT}/api/user/550e8400-e29b-41d4-a716-446655440001/trips.ics?token=TOKEN"
+
+curl -X DELETE "${API_ENDPOINT}/api/user/550e8400-e29b-41d4-a716-446655440001/calendar-token" \
+-H "User-Id: 550e8400-e29b-41d4-a716-446655440001"